@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"fiatjaf.com/nostr"
+	"github.com/jerry-harm/nosmec/nostr_sdk"
+	"github.com/jerry-harm/nosmec/utils"
+	"github.com/spf13/cobra"
+)
+
+func registerBackfillCommands() {
+	backfillCmd := &cobra.Command{
+		Use:   "backfill <npub|alias>",
+		Short: "Download an author's complete history into the local store",
+		Long: `Walk an author's outbox relays backwards page by page until they run out of events,
+storing everything locally.
+
+Progress is saved after every page, so running the same command again after an
+interruption resumes where it stopped, and running it after it completed only fetches
+what was published since. Use --reset to walk the whole history again.`,
+		Example: `  nosmec backfill npub1... --kinds 1,6,30023
+  nosmec backfill alice --since 2024-01-01`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			app := getApp()
+			flags := cmd.Flags()
+
+			pk, err := utils.ResolveAliasToPubKey(app, args[0])
+			if err != nil {
+				return newError("invalid author", err)
+			}
+
+			var kinds []nostr.Kind
+			kindInts, _ := flags.GetIntSlice("kinds")
+			for _, k := range kindInts {
+				kinds = append(kinds, nostr.Kind(k))
+			}
+
+			var since nostr.Timestamp
+			if s, _ := flags.GetString("since"); s != "" {
+				if since, err = utils.ParseTimestamp(s); err != nil {
+					return newError("invalid --since", err)
+				}
+			}
+
+			ctx := context.Background()
+			sys := app.System()
+			relays, _ := flags.GetStringSlice("relays")
+			if len(relays) == 0 {
+				relays = sys.FetchOutboxRelays(ctx, pk, 4)
+			}
+			if len(relays) == 0 {
+				return newError("no relays found for this author", nil)
+			}
+
+			if reset, _ := flags.GetBool("reset"); reset {
+				if err := sys.ResetBackfill(pk, kinds, relays); err != nil {
+					return newError("failed to reset checkpoints", err)
+				}
+			}
+
+			errOut := cmd.ErrOrStderr()
+			results := sys.Backfill(ctx, pk, kinds, since, relays, func(p nostr_sdk.BackfillProgress) {
+				at := "done"
+				if !p.Done {
+					at = "at " + p.Until.Time().Format(time.DateOnly)
+				}
+				fmt.Fprintf(errOut, "%s: page %d, %d received, %d new, %s\n", p.Relay, p.Pages, p.Received, p.Stored, at)
+			})
+
+			out := backfillOutput{Author: utils.PubKeyToNpub(pk), Relays: []relayBackfillOutput{}}
+			failed := 0
+			for _, r := range results {
+				relay := relayBackfillOutput{Relay: r.Relay, Pages: r.Pages, Received: r.Received, Stored: r.Stored, Until: r.Until, Done: r.Done}
+				if r.Err != nil {
+					relay.Error = r.Err.Error()
+					failed++
+				}
+				out.Relays = append(out.Relays, relay)
+				out.Stored += r.Stored
+			}
+			if err := printResult(cmd, backfillResult(out)); err != nil {
+				return err
+			}
+
+			if failed > 0 {
+				return newError(fmt.Sprintf("%d relays were interrupted, run again to resume", failed), nil)
+			}
+			return nil
+		},
+	}
+	backfillCmd.Flags().IntSlice("kinds", nil, "Only these kinds (e.g., --kinds 1,6; default all)")
+	backfillCmd.Flags().String("since", "", "Stop at this time (unix timestamp, date or duration ago)")
+	backfillCmd.Flags().StringSlice("relays", nil, "Relays to walk instead of the author's outbox relays")
+	backfillCmd.Flags().Bool("reset", false, "Forget saved progress and start from the newest events")
+
+	supportsOutput(backfillCmd)
+	RegisterCommandGroup("Backfill", "Author history download", backfillCmd)
+}
+
+// backfillOutput is the outcome of a backfill; the progress goes to stderr.
+type backfillOutput struct {
+	Author string                `json:"author"`
+	Stored int                   `json:"stored"`
+	Relays []relayBackfillOutput `json:"relays"`
+}
+
+type relayBackfillOutput struct {
+	Relay    string          `json:"relay"`
+	Pages    int             `json:"pages"`
+	Received int             `json:"received"`
+	Stored   int             `json:"stored"`
+	Until    nostr.Timestamp `json:"until"`
+	Done     bool            `json:"done"`
+	Error    string          `json:"error,omitempty"`
+}
+
+func (r relayBackfillOutput) status() string {
+	switch {
+	case r.Error != "":
+		return "interrupted: " + r.Error
+	case !r.Done:
+		return "incomplete"
+	}
+	return "complete"
+}
+
+func backfillResult(out backfillOutput) result {
+	rows := make([][]string, len(out.Relays))
+	for i, r := range out.Relays {
+		rows[i] = []string{r.Relay, strconv.Itoa(r.Pages), strconv.Itoa(r.Received), strconv.Itoa(r.Stored), r.status()}
+	}
+
+	return result{
+		Data:   out,
+		Header: []string{"RELAY", "PAGES", "RECEIVED", "NEW", "STATUS"},
+		Rows:   rows,
+		Text: func(w io.Writer) error {
+			for _, r := range out.Relays {
+				fmt.Fprintf(w, "%-40s %5d pages %7d received %7d new  %s\n", r.Relay, r.Pages, r.Received, r.Stored, r.status())
+			}
+			_, err := fmt.Fprintf(w, "Stored %d new events from %s\n", out.Stored, out.Author)
+			return err
+		},
+	}
+}
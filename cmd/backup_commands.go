@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/spf13/cobra"
+)
+
+// backupPassphraseEnv lets scripts provide the backup passphrase without a prompt.
+const backupPassphraseEnv = "NOSMEC_BACKUP_PASSPHRASE"
+
+func registerBackupCommands() {
+	backupCmd := &cobra.Command{
+		Use:   "backup",
+		Short: "Back up and restore the whole account state",
+	}
+
+	backupCreateCmd := &cobra.Command{
+		Use:   "create <path>",
+		Short: "Write config, hints, kvstore and events into one archive",
+		Long: `Write the config file, the hints database, the kvstore records and the local
+events into a single versioned archive.
+
+The config file holds your private key. Use --encrypt to protect the archive with a
+passphrase, which is asked for on the terminal or read from ` + backupPassphraseEnv + `.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			encrypt, _ := cmd.Flags().GetBool("encrypt")
+
+			var passphrase string
+			if encrypt {
+				var err error
+				if passphrase, err = readNewPassphrase(cmd); err != nil {
+					return err
+				}
+			}
+
+			// write next to the destination and move it into place once complete
+			path := args[0]
+			f, err := os.CreateTemp(filepath.Dir(path), ".nosmec-backup-*")
+			if err != nil {
+				return newError("failed to create backup file", err)
+			}
+			defer os.Remove(f.Name())
+
+			manifest, err := getApp().CreateBackup(context.Background(), f, passphrase)
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				return newError("backup failed", err)
+			}
+			if err := os.Rename(f.Name(), path); err != nil {
+				return newError("failed to write backup file", err)
+			}
+
+			w := cmd.OutOrStdout()
+			fmt.Fprintf(w, "Backed up %d events, %d hint entries and %d kvstore records to %s\n",
+				manifest.Events, manifest.Hints, manifest.KVEntries, path)
+			if !encrypt && manifest.Config {
+				fmt.Fprintln(w, "The archive is not encrypted and contains your private key, keep it safe")
+			}
+			return nil
+		},
+	}
+	backupCreateCmd.Flags().Bool("encrypt", false, "Encrypt the archive with a passphrase")
+
+	backupRestoreCmd := &cobra.Command{
+		Use:   "restore <path>",
+		Short: "Restore config, hints, kvstore and events from an archive",
+		Long: `Restore an archive written by backup create. The archive version is checked
+before anything is written.
+
+Hints are merged with the existing ones and events already stored are skipped, so
+restoring twice is harmless. The current config file is kept as nosmec.yaml.bak and
+the data_dir setting of this machine is preserved.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			skipConfig, _ := cmd.Flags().GetBool("skip-config")
+
+			f, err := os.Open(args[0])
+			if err != nil {
+				return newError("failed to open backup", err)
+			}
+			defer f.Close()
+
+			passphrase := func() (string, error) {
+				return readPassphrase(cmd, "Backup passphrase: ")
+			}
+			report, err := getApp().RestoreBackup(context.Background(), bufio.NewReader(f), passphrase, !skipConfig)
+			if err != nil {
+				return newError("restore failed", err)
+			}
+
+			w := cmd.OutOrStdout()
+			fmt.Fprintf(w, "Backup from %s", report.Manifest.CreatedAt.Local().Format("2006-01-02 15:04"))
+			if report.Manifest.PubKey != "" {
+				fmt.Fprintf(w, " for %s", report.Manifest.PubKey)
+			}
+			fmt.Fprintln(w)
+			fmt.Fprintf(w, "Restored %d of %d events (%d already stored, %d invalid)\n",
+				report.Events.Imported, report.Events.Read, report.Events.Duplicates, report.Events.Invalid)
+			fmt.Fprintf(w, "Restored %d hint entries and %d kvstore records\n", report.Hints, report.KVEntries)
+			if report.ConfigPath != "" {
+				fmt.Fprintf(w, "Restored config to %s, it takes effect on the next run\n", report.ConfigPath)
+			}
+			return nil
+		},
+	}
+	backupRestoreCmd.Flags().Bool("skip-config", false, "Keep the current config file")
+
+	backupCmd.AddCommand(backupCreateCmd)
+	backupCmd.AddCommand(backupRestoreCmd)
+
+	RegisterCommandGroup("Backup", "Account backup and restore", backupCmd)
+}
+
+// readPassphrase returns the passphrase from the environment, or asks for it without
+// echoing when stdin is a terminal and reads a line from stdin otherwise.
+func readPassphrase(cmd *cobra.Command, prompt string) (string, error) {
+	if pass := os.Getenv(backupPassphraseEnv); pass != "" {
+		return pass, nil
+	}
+
+	in := cmd.InOrStdin()
+	if f, ok := in.(*os.File); ok && term.IsTerminal(f.Fd()) {
+		fmt.Fprint(cmd.ErrOrStderr(), prompt)
+		pass, err := term.ReadPassword(f.Fd())
+		fmt.Fprintln(cmd.ErrOrStderr())
+		if err != nil {
+			return "", newError("failed to read passphrase", err)
+		}
+		return string(pass), nil
+	}
+
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", newError("failed to read passphrase", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// readNewPassphrase asks for a passphrase twice when it is typed on a terminal.
+func readNewPassphrase(cmd *cobra.Command) (string, error) {
+	pass, err := readPassphrase(cmd, "New backup passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if pass == "" {
+		return "", newError("empty passphrase", nil)
+	}
+
+	if f, ok := cmd.InOrStdin().(*os.File); ok && term.IsTerminal(f.Fd()) && os.Getenv(backupPassphraseEnv) == "" {
+		again, err := readPassphrase(cmd, "Repeat passphrase: ")
+		if err != nil {
+			return "", err
+		}
+		if again != pass {
+			return "", newError("passphrases do not match", nil)
+		}
+	}
+	return pass, nil
+}
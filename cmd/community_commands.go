@@ -3,6 +3,7 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"io"
 	"strings"
 
 	"fiatjaf.com/nostr"
@@ -52,20 +53,21 @@ func registerCommunityCommands() {
 			def.Moderators = append(def.Moderators, pubKey)
 
 			ctx := context.Background()
-			event, err := utils.CreateCommunity(ctx, app, def)
+			report, err := utils.CreateCommunity(ctx, app, def)
 			if err != nil {
 				handleError(newError("failed to create community", err))
 			}
 
-			fmt.Printf("Community created!\n")
-			if dTag := event.Tags.Find("d"); len(dTag) > 1 {
-				fmt.Printf("ID: %s\n", dTag[1])
+			headline := []string{"Community created!"}
+			if dTag := report.Event.Tags.Find("d"); len(dTag) > 1 {
+				headline = append(headline, "ID: "+dTag[1])
 			}
-			fmt.Printf("Name: %s\n", name)
+			headline = append(headline, "Name: "+name)
 			if description != "" {
-				fmt.Printf("Description: %s\n", description)
+				headline = append(headline, "Description: "+description)
 			}
-			fmt.Printf("Event ID: %s\n", nip19.EncodeNevent(event.ID, nil, event.PubKey))
+			headline = append(headline, "Event ID: "+nip19.EncodeNevent(report.Event.ID, nil, report.Event.PubKey))
+			handleError(printResult(cmd, publishResult(report, headline...)))
 		},
 	}
 	communityCreateCmd.Flags().String("image", "", "Community image URL")
@@ -82,13 +84,15 @@ func registerCommunityCommands() {
 			app := getApp()
 			ctx := context.Background()
 
-			event, err := utils.PostToCommunity(ctx, app, communityAddr, content, "")
+			report, err := utils.PostToCommunity(ctx, app, communityAddr, content, "")
 			if err != nil {
 				handleError(newError("failed to post", err))
 			}
 
-			fmt.Printf("Posted to community!\n")
-			fmt.Printf("Post ID: %s\n", nip19.EncodeNevent(event.ID, nil, event.PubKey))
+			handleError(printResult(cmd, publishResult(report,
+				"Posted to community!",
+				"Post ID: "+nip19.EncodeNevent(report.Event.ID, nil, report.Event.PubKey),
+			)))
 		},
 	}
 
@@ -133,13 +137,15 @@ func registerCommunityCommands() {
 				handleError(newError("parent post is not associated with a community", nil))
 			}
 
-			event, err := utils.PostToCommunity(ctx, app, communityAddr, content, parentEvent.ID.Hex())
+			report, err := utils.PostToCommunity(ctx, app, communityAddr, content, parentEvent.ID.Hex())
 			if err != nil {
 				handleError(newError("failed to reply", err))
 			}
 
-			fmt.Printf("Replied!\n")
-			fmt.Printf("Reply ID: %s\n", nip19.EncodeNevent(event.ID, nil, event.PubKey))
+			handleError(printResult(cmd, publishResult(report,
+				"Replied!",
+				"Reply ID: "+nip19.EncodeNevent(report.Event.ID, nil, report.Event.PubKey),
+			)))
 		},
 	}
 
@@ -151,104 +157,71 @@ func registerCommunityCommands() {
 			ctx := context.Background()
 			app := getApp()
 
-			fmt.Println("=== My Communities ===")
-			fmt.Println()
-
 			// Get relay list for queries
 			relays := app.AllReadableRelays()
 			timeoutMs := app.QueryTimeoutms()
 
-			// --- Following (Kind 10004) ---
-			fmt.Println("[Following] (Kind 10004)")
 			myPubKey, err := app.GetMyPubKey()
 			if err != nil {
-				fmt.Printf("Error: %v\n", err)
-			} else {
-				followedFilter := nostr.Filter{
-					Kinds:   []nostr.Kind{10004},
-					Authors: []nostr.PubKey{myPubKey},
-					Limit:   1,
-				}
-				followedEvent := app.System().FetchEventByFilter(ctx, followedFilter, timeoutMs)
-				if followedEvent != nil {
-					var followed []string
-					for _, tag := range followedEvent.Tags {
-						if tag[0] == "a" && strings.HasPrefix(tag[1], "34550:") {
-							followed = append(followed, tag[1])
-						}
-					}
-					if len(followed) == 0 {
-						fmt.Println("  (none)")
-					} else {
-						for _, addr := range followed {
-							fmt.Printf("  - %s\n", addr)
-						}
+				handleError(newError("failed to get public key", err))
+			}
+			list := communityList{Followed: []string{}, Created: []createdCommunity{}, Posted: []string{}}
+
+			// --- Following (Kind 10004) ---
+			followedFilter := nostr.Filter{
+				Kinds:   []nostr.Kind{10004},
+				Authors: []nostr.PubKey{myPubKey},
+				Limit:   1,
+			}
+			if followedEvent := app.System().FetchEventByFilter(ctx, followedFilter, timeoutMs); followedEvent != nil {
+				for _, tag := range followedEvent.Tags {
+					if tag[0] == "a" && strings.HasPrefix(tag[1], "34550:") {
+						list.Followed = append(list.Followed, tag[1])
 					}
-				} else {
-					fmt.Println("  (none)")
 				}
 			}
-			fmt.Println()
 
 			// --- Created (Kind 34550) ---
-			fmt.Println("[Created] (Kind 34550)")
-			if err != nil {
-				fmt.Printf("Error: %v\n", err)
-			} else {
-				createdFilter := nostr.Filter{
-					Kinds:   []nostr.Kind{nostr.KindCommunityDefinition},
-					Authors: []nostr.PubKey{myPubKey},
-				}
-				ctxQuery, cancel := context.WithTimeout(ctx, app.QueryTimeout())
-				defer cancel()
-				var events []nostr.Event
-				for ie := range app.Pool().FetchMany(ctxQuery, relays, createdFilter, nostr.SubscriptionOptions{}) {
-					events = append(events, ie.Event)
+			createdFilter := nostr.Filter{
+				Kinds:   []nostr.Kind{nostr.KindCommunityDefinition},
+				Authors: []nostr.PubKey{myPubKey},
+			}
+			ctxQuery, cancel := context.WithTimeout(ctx, app.QueryTimeout())
+			defer cancel()
+			for ie := range app.Pool().FetchMany(ctxQuery, relays, createdFilter, nostr.SubscriptionOptions{}) {
+				event := ie.Event
+				id := nip72.GetDefinitionIdentifier(&event)
+				name := nip72.GetDefinitionName(&event)
+				if name == "" {
+					name = id
 				}
-				if len(events) == 0 {
-					fmt.Println("  (none)")
-				} else {
-					for _, event := range events {
-						name := nip72.GetDefinitionName(&event)
-						if name == "" {
-							name = nip72.GetDefinitionIdentifier(&event)
-						}
-						if name == "" {
-							name = nip19.EncodeNevent(event.ID, nil, event.PubKey)[:32] + "..."
-						}
-						fmt.Printf("  - %s\n", name)
-					}
+				if name == "" {
+					name = nip19.EncodeNevent(event.ID, nil, event.PubKey)[:32] + "..."
 				}
+				list.Created = append(list.Created, createdCommunity{
+					Address: fmt.Sprintf("34550:%s:%s", event.PubKey.Hex(), id),
+					Name:    name,
+				})
 			}
-			fmt.Println()
 
 			// --- Posted (Kind 1111) ---
-			fmt.Println("[Posted] (Kind 1111)")
 			postedFilter := nostr.Filter{
 				Kinds:   []nostr.Kind{nostr.KindComment},
 				Authors: []nostr.PubKey{myPubKey},
 			}
 			ctxQuery2, cancel2 := context.WithTimeout(ctx, app.QueryTimeout())
 			defer cancel2()
-			var postedAddrs []string
+			seen := make(map[string]bool)
 			for ie := range app.Pool().FetchMany(ctxQuery2, relays, postedFilter, nostr.SubscriptionOptions{}) {
 				for _, tag := range ie.Event.Tags {
-					if tag[0] == "a" && strings.HasPrefix(tag[1], "34550:") {
-						postedAddrs = append(postedAddrs, tag[1])
-					}
-				}
-			}
-			if len(postedAddrs) == 0 {
-				fmt.Println("  (none)")
-			} else {
-				seen := make(map[string]bool)
-				for _, addr := range postedAddrs {
-					if !seen[addr] {
-						seen[addr] = true
-						fmt.Printf("  - %s\n", addr)
+					if tag[0] == "a" && strings.HasPrefix(tag[1], "34550:") && !seen[tag[1]] {
+						seen[tag[1]] = true
+						list.Posted = append(list.Posted, tag[1])
 					}
 				}
 			}
+
+			handleError(printResult(cmd, communityListResult(list)))
 		},
 	}
 
@@ -277,25 +250,7 @@ func registerCommunityCommands() {
 				handleError(newError("community not found", nil))
 			}
 
-			fmt.Printf("Community Information:\n")
-			if name := nip72.GetDefinitionName(event); name != "" {
-				fmt.Printf("Name: %s\n", name)
-			}
-			if desc := nip72.GetDefinitionDescription(event); desc != "" {
-				fmt.Printf("Description: %s\n", desc)
-			}
-			if image := nip72.GetDefinitionImage(event); image != "" {
-				fmt.Printf("Image: %s\n", image)
-			}
-			fmt.Printf("ID: %s\n", communityID)
-			fmt.Printf("Author: %s\n", nip19.EncodeNpub(authorPubKey))
-			fmt.Printf("Event ID: %s\n", nip19.EncodeNevent(event.ID, nil, event.PubKey))
-			fmt.Printf("Created: %v\n", event.CreatedAt.Time())
-
-			fmt.Printf("\nModerators:\n")
-			for _, moderator := range nip72.GetDefinitionModerators(event) {
-				fmt.Printf("  - %s\n", nip19.EncodeNpub(moderator))
-			}
+			handleError(printResult(cmd, communityInfoResult(event, communityID)))
 		},
 	}
 
@@ -341,5 +296,125 @@ func registerCommunityCommands() {
 	communityCmd.AddCommand(communityTimelineCmd)
 	communityCmd.AddCommand(communityDiscoverCmd)
 
+	supportsOutput(communityCreateCmd, communityPostCmd, communityReplyCmd, communityListCmd, communityInfoCmd)
 	RegisterCommandGroup("Community", "Community operations (NIP-72)", communityCmd)
 }
+
+// communityList is the output of "community list".
+type communityList struct {
+	Followed []string           `json:"followed"`
+	Created  []createdCommunity `json:"created"`
+	Posted   []string           `json:"posted"`
+}
+
+type createdCommunity struct {
+	Address string `json:"address"`
+	Name    string `json:"name"`
+}
+
+func communityListResult(list communityList) result {
+	var created []string
+	for _, c := range list.Created {
+		created = append(created, c.Name)
+	}
+
+	var rows [][]string
+	for _, addr := range list.Followed {
+		rows = append(rows, []string{"followed", addr})
+	}
+	for _, c := range list.Created {
+		rows = append(rows, []string{"created", c.Address, c.Name})
+	}
+	for _, addr := range list.Posted {
+		rows = append(rows, []string{"posted", addr})
+	}
+
+	section := func(w io.Writer, title string, items []string) {
+		fmt.Fprintln(w, title)
+		if len(items) == 0 {
+			fmt.Fprintln(w, "  (none)")
+		}
+		for _, item := range items {
+			fmt.Fprintf(w, "  - %s\n", item)
+		}
+	}
+
+	return result{
+		Data:   list,
+		Header: []string{"LIST", "COMMUNITY", "NAME"},
+		Rows:   rows,
+		Text: func(w io.Writer) error {
+			fmt.Fprintln(w, "=== My Communities ===")
+			fmt.Fprintln(w)
+			section(w, "[Following] (Kind 10004)", list.Followed)
+			fmt.Fprintln(w)
+			section(w, "[Created] (Kind 34550)", created)
+			fmt.Fprintln(w)
+			section(w, "[Posted] (Kind 1111)", list.Posted)
+			return nil
+		},
+	}
+}
+
+// communityInfo is the output of "community info".
+type communityInfo struct {
+	Address     string          `json:"address"`
+	ID          string          `json:"id"`
+	Name        string          `json:"name,omitempty"`
+	Description string          `json:"description,omitempty"`
+	Image       string          `json:"image,omitempty"`
+	Author      string          `json:"author"`
+	Event       string          `json:"event"`
+	CreatedAt   nostr.Timestamp `json:"created_at"`
+	Moderators  []string        `json:"moderators"`
+}
+
+func communityInfoResult(event *nostr.Event, communityID string) result {
+	info := communityInfo{
+		Address:     fmt.Sprintf("34550:%s:%s", event.PubKey.Hex(), communityID),
+		ID:          communityID,
+		Name:        nip72.GetDefinitionName(event),
+		Description: nip72.GetDefinitionDescription(event),
+		Image:       nip72.GetDefinitionImage(event),
+		Author:      nip19.EncodeNpub(event.PubKey),
+		Event:       nip19.EncodeNevent(event.ID, nil, event.PubKey),
+		CreatedAt:   event.CreatedAt,
+		Moderators:  []string{},
+	}
+	for _, moderator := range nip72.GetDefinitionModerators(event) {
+		info.Moderators = append(info.Moderators, nip19.EncodeNpub(moderator))
+	}
+
+	rows := [][]string{
+		{"Name", info.Name},
+		{"Description", info.Description},
+		{"Image", info.Image},
+		{"ID", info.ID},
+		{"Author", info.Author},
+		{"Event ID", info.Event},
+		{"Created", event.CreatedAt.Time().String()},
+		{"Moderators", strings.Join(info.Moderators, ", ")},
+	}
+	return result{
+		Data:   info,
+		Header: []string{"FIELD", "VALUE"},
+		Rows:   rows,
+		Text: func(w io.Writer) error {
+			fmt.Fprintf(w, "Community Information:\n")
+			for _, row := range rows[:3] {
+				if row[1] != "" {
+					fmt.Fprintf(w, "%s: %s\n", row[0], row[1])
+				}
+			}
+			for _, row := range rows[3:7] {
+				fmt.Fprintf(w, "%s: %s\n", row[0], row[1])
+			}
+
+			fmt.Fprintf(w, "\nModerators:\n")
+			for _, moderator := range info.Moderators {
+				fmt.Fprintf(w, "  - %s\n", moderator)
+			}
+			return nil
+		},
+	}
+}
@@ -1,8 +1,12 @@
 package cmd
 
 import (
+	"bufio"
 	"context"
 	"fmt"
+	"io"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -76,13 +80,14 @@ func registerConfigCommands() {
 			lud16, _ := cmd.Flags().GetString("lud16")
 
 			ctx := context.Background()
-			event, err := utils.SetProfile(ctx, getApp(), false, name, about, picture, displayName, website, banner, bot, birthday, nip05, lud06, lud16)
+			report, err := utils.SetProfile(ctx, getApp(), false, name, about, picture, displayName, website, banner, bot, birthday, nip05, lud06, lud16)
 			if err != nil {
 				handleError(newError("failed to set profile", err))
 			}
 
 			fmt.Printf("Profile updated!\n")
-			fmt.Printf("Event ID: %s\n", nip19.EncodeNevent(event.ID, nil, event.PubKey))
+			fmt.Printf("Event ID: %s\n", nip19.EncodeNevent(report.Event.ID, nil, report.Event.PubKey))
+			fmt.Print(utils.FormatPublishReport(report))
 		},
 	}
 
@@ -172,25 +177,8 @@ func registerConfigCommands() {
 		Use:   "list",
 		Short: "List relays",
 		Args:  cobra.NoArgs,
-		Run: func(cmd *cobra.Command, args []string) {
-			relays := getApp().ListRelays()
-			if len(relays) == 0 {
-				fmt.Println("No relays configured.")
-				return
-			}
-
-			fmt.Println("=== Relays ===")
-			for _, r := range relays {
-				read := "false"
-				if r.Read != nil && *r.Read {
-					read = "true"
-				}
-				write := "false"
-				if r.Write != nil && *r.Write {
-					write = "true"
-				}
-				fmt.Printf("  %s  read=%s write=%s\n", r.URL, read, write)
-			}
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return printResult(cmd, configRelaysResult(getApp().ListRelays()))
 		},
 	}
 
@@ -205,7 +193,7 @@ func registerConfigCommands() {
 				handleError(err)
 			}
 			ctx := context.Background()
-			if err := utils.PublishRelayList(ctx, getApp()); err != nil {
+			if _, err := utils.PublishRelayList(ctx, getApp()); err != nil {
 				handleError(newError("failed to publish relay list", err))
 			}
 			fmt.Printf("Relay added: %s\n", url)
@@ -223,7 +211,7 @@ func registerConfigCommands() {
 				handleError(err)
 			}
 			ctx := context.Background()
-			if err := utils.PublishRelayList(ctx, getApp()); err != nil {
+			if _, err := utils.PublishRelayList(ctx, getApp()); err != nil {
 				handleError(newError("failed to publish relay list", err))
 			}
 			fmt.Printf("Relay removed: %s\n", url)
@@ -232,61 +220,35 @@ func registerConfigCommands() {
 
 	configRelaySyncCmd := &cobra.Command{
 		Use:   "sync",
-		Short: "Sync relay list from network",
+		Short: "Merge the relay list from the network into the local one",
 		Args:  cobra.NoArgs,
 		Run: func(cmd *cobra.Command, args []string) {
 			ctx := context.Background()
 			app := getApp()
 
-			pk, err := app.GetMyPubKey()
+			opts, err := syncOptionsFromFlags(cmd)
 			if err != nil {
-				handleError(newError("failed to get public key", err))
-			}
-
-			relays := app.WritableRelays()
-			if len(relays) == 0 {
-				relays = app.ReadableRelays()
+				handleError(err)
 			}
 
-			filter := nostr.Filter{
-				Kinds:   []nostr.Kind{nostr.KindRelayListMetadata},
-				Authors: []nostr.PubKey{pk},
-				Limit:   1,
+			diff, err := utils.SyncRelayListFromNetwork(ctx, app, opts)
+			if err != nil {
+				handleError(newError("failed to sync relay list", err))
 			}
-
-			result := app.Pool().QuerySingle(ctx, relays, filter, nostr.SubscriptionOptions{})
-			if result == nil {
+			if !diff.Found {
 				handleError(newError("relay list not found on network", nil))
 			}
 
-			event := result.Event
-			relayList := make([]config.Relay, 0)
-			for _, tag := range event.Tags {
-				if len(tag) >= 2 && tag[0] == "r" {
-					url := tag[1]
-					relay := config.Relay{
-						URL:   url,
-						Read:  config.BoolPtr(false),
-						Write: config.BoolPtr(false),
-					}
-					for _, p := range tag[2:] {
-						if p == "read" {
-							relay.Read = config.BoolPtr(true)
-						} else if p == "write" {
-							relay.Write = config.BoolPtr(true)
-						}
-					}
-					relayList = append(relayList, relay)
+			fmt.Print(utils.FormatListDiff(diff))
+			handleError(finishSync(cmd, opts, []utils.ListDiff{diff}, func() error {
+				if _, err := utils.PublishRelayList(ctx, app); err != nil {
+					return newError("failed to publish relay list", err)
 				}
-			}
-
-			app.SyncRelayList(relayList)
-			if err := utils.PublishRelayList(ctx, app); err != nil {
-				handleError(newError("failed to publish relay list", err))
-			}
-			fmt.Printf("Synced %d relays from network\n", len(relayList))
+				return nil
+			}))
 		},
 	}
+	addSyncFlags(configRelaySyncCmd)
 
 	configRelayCmd.AddCommand(configRelayListCmd)
 	configRelayCmd.AddCommand(configRelayAddCmd)
@@ -302,16 +264,8 @@ func registerConfigCommands() {
 		Use:   "list",
 		Short: "List search relays",
 		Args:  cobra.NoArgs,
-		Run: func(cmd *cobra.Command, args []string) {
-			relays := getApp().ListSearchRelays()
-			if len(relays) == 0 {
-				fmt.Println("No search relays configured.")
-				return
-			}
-			fmt.Println("Search relays:")
-			for _, url := range relays {
-				fmt.Printf("  %s\n", url)
-			}
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return printResult(cmd, headedValuesResult("Search relays:", "RELAY", getApp().ListSearchRelays(), "No search relays configured."))
 		},
 	}
 
@@ -352,16 +306,8 @@ func registerConfigCommands() {
 		Use:   "list",
 		Short: "List DM relays",
 		Args:  cobra.NoArgs,
-		Run: func(cmd *cobra.Command, args []string) {
-			relays := getApp().ListDMRelays()
-			if len(relays) == 0 {
-				fmt.Println("No DM relays configured.")
-				return
-			}
-			fmt.Println("DM relays:")
-			for _, url := range relays {
-				fmt.Printf("  %s\n", url)
-			}
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return printResult(cmd, headedValuesResult("DM relays:", "RELAY", getApp().ListDMRelays(), "No DM relays configured."))
 		},
 	}
 
@@ -374,7 +320,7 @@ func registerConfigCommands() {
 				handleError(err)
 			}
 			ctx := context.Background()
-			if err := utils.PublishRelayList(ctx, getApp()); err != nil {
+			if _, err := utils.PublishRelayList(ctx, getApp()); err != nil {
 				handleError(newError("failed to publish relay list", err))
 			}
 			fmt.Printf("DM relay added: %s\n", args[0])
@@ -390,7 +336,7 @@ func registerConfigCommands() {
 				handleError(err)
 			}
 			ctx := context.Background()
-			if err := utils.PublishRelayList(ctx, getApp()); err != nil {
+			if _, err := utils.PublishRelayList(ctx, getApp()); err != nil {
 				handleError(newError("failed to publish relay list", err))
 			}
 			fmt.Printf("DM relay removed: %s\n", args[0])
@@ -403,16 +349,31 @@ func registerConfigCommands() {
 
 	configDMRelaySyncCmd := &cobra.Command{
 		Use:   "sync",
-		Short: "Sync DM relays from network",
+		Short: "Merge DM relays from the network into the local list",
 		Args:  cobra.NoArgs,
 		Run: func(cmd *cobra.Command, args []string) {
 			ctx := context.Background()
-			if err := utils.SyncDMRelaysFromNetwork(ctx, getApp()); err != nil {
+
+			opts, err := syncOptionsFromFlags(cmd)
+			if err != nil {
+				handleError(err)
+			}
+
+			diff, err := utils.SyncDMRelaysFromNetwork(ctx, getApp(), opts)
+			if err != nil {
 				handleError(newError("failed to sync DM relays", err))
 			}
-			fmt.Println("DM relays synced from network")
+
+			fmt.Print(utils.FormatListDiff(diff))
+			handleError(finishSync(cmd, opts, []utils.ListDiff{diff}, func() error {
+				if _, err := utils.PublishRelayList(ctx, getApp()); err != nil {
+					return newError("failed to publish DM relays", err)
+				}
+				return nil
+			}))
 		},
 	}
+	addSyncFlags(configDMRelaySyncCmd)
 	configDMRelayCmd.AddCommand(configDMRelaySyncCmd)
 
 	configSubscribeCmd := &cobra.Command{
@@ -424,52 +385,27 @@ func registerConfigCommands() {
 		Use:   "list",
 		Short: "List subscriptions",
 		Args:  cobra.NoArgs,
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			app := getApp()
 
 			users := app.ListSubscriptions("user")
 			communities := app.ListSubscriptions("community")
 			hashtags := app.ListSubscriptions("hashtag")
-
-			if len(users) == 0 && len(communities) == 0 && len(hashtags) == 0 {
-				fmt.Println("No subscriptions.")
-				return
-			}
-
-			fmt.Println("=== Subscriptions ===")
-
-			if len(users) > 0 {
-				fmt.Println("\n[Users]")
-				for _, s := range users {
-					petname := ""
-					if s.Petname != "" {
-						petname = " (" + s.Petname + ")"
-					}
-					relay := ""
-					if s.Relay != "" {
-						relay = " @ " + s.Relay
-					}
-					fmt.Printf("  - %s%s%s\n", s.ID, relay, petname)
-				}
-			}
-
-			if len(communities) > 0 {
-				fmt.Println("\n[Communities]")
-				for _, s := range communities {
-					relay := ""
-					if s.Relay != "" {
-						relay = " @ " + s.Relay
-					}
-					fmt.Printf("  - %s%s\n", s.ID, relay)
-				}
-			}
-
-			if len(hashtags) > 0 {
-				fmt.Println("\n[Hashtags]")
-				for _, s := range hashtags {
-					fmt.Printf("  - #%s\n", s.ID)
-				}
-			}
+			all := slices.Concat(users, communities, hashtags)
+
+			rows := make([][]string, len(all))
+			for i, s := range all {
+				rows[i] = []string{s.Type, s.ID, s.Relay, s.Petname}
+			}
+			return printResult(cmd, result{
+				Data:   all,
+				Header: []string{"TYPE", "ID", "RELAY", "PETNAME"},
+				Rows:   rows,
+				Text: func(w io.Writer) error {
+					writeSubscriptions(w, users, communities, hashtags)
+					return nil
+				},
+			})
 		},
 	}
 
@@ -493,7 +429,7 @@ func registerConfigCommands() {
 			if err := utils.FollowUser(ctx, getApp(), identifier, relay, petname); err != nil {
 				handleError(newError("failed to subscribe", err))
 			}
-			if err := utils.PublishSubscriptions(ctx, getApp()); err != nil {
+			if _, err := utils.PublishSubscriptions(ctx, getApp()); err != nil {
 				handleError(newError("failed to publish subscriptions", err))
 			}
 
@@ -517,7 +453,7 @@ func registerConfigCommands() {
 			if err := utils.FollowCommunity(ctx, getApp(), addr, relay); err != nil {
 				handleError(newError("failed to subscribe", err))
 			}
-			if err := utils.PublishSubscriptions(ctx, getApp()); err != nil {
+			if _, err := utils.PublishSubscriptions(ctx, getApp()); err != nil {
 				handleError(newError("failed to publish subscriptions", err))
 			}
 
@@ -537,7 +473,7 @@ func registerConfigCommands() {
 			if err := utils.FollowHashtag(ctx, getApp(), tag); err != nil {
 				handleError(newError("failed to subscribe", err))
 			}
-			if err := utils.PublishSubscriptions(ctx, getApp()); err != nil {
+			if _, err := utils.PublishSubscriptions(ctx, getApp()); err != nil {
 				handleError(newError("failed to publish subscriptions", err))
 			}
 
@@ -575,7 +511,7 @@ func registerConfigCommands() {
 			}
 
 			ctx := context.Background()
-			if err := utils.PublishSubscriptions(ctx, getApp()); err != nil {
+			if _, err := utils.PublishSubscriptions(ctx, getApp()); err != nil {
 				handleError(newError("failed to publish subscriptions", err))
 			}
 
@@ -587,16 +523,33 @@ func registerConfigCommands() {
 
 	configSubscribeSyncCmd := &cobra.Command{
 		Use:   "sync",
-		Short: "Sync subscriptions from network",
+		Short: "Merge subscriptions from the network into the local lists",
 		Args:  cobra.NoArgs,
 		Run: func(cmd *cobra.Command, args []string) {
 			ctx := context.Background()
-			if err := utils.SyncSubscriptionsFromNetwork(ctx, getApp()); err != nil {
+
+			opts, err := syncOptionsFromFlags(cmd)
+			if err != nil {
+				handleError(err)
+			}
+
+			diffs, err := utils.SyncSubscriptionsFromNetwork(ctx, getApp(), opts)
+			if err != nil {
 				handleError(newError("failed to sync subscriptions", err))
 			}
-			fmt.Println("Subscriptions synced from network")
+
+			for _, diff := range diffs {
+				fmt.Print(utils.FormatListDiff(diff))
+			}
+			handleError(finishSync(cmd, opts, diffs, func() error {
+				if _, err := utils.PublishSubscriptions(ctx, getApp()); err != nil {
+					return newError("failed to publish subscriptions", err)
+				}
+				return nil
+			}))
 		},
 	}
+	addSyncFlags(configSubscribeSyncCmd)
 
 	configSubscribePublishCmd := &cobra.Command{
 		Use:   "publish",
@@ -604,7 +557,7 @@ func registerConfigCommands() {
 		Args:  cobra.NoArgs,
 		Run: func(cmd *cobra.Command, args []string) {
 			ctx := context.Background()
-			if err := utils.PublishSubscriptions(ctx, getApp()); err != nil {
+			if _, err := utils.PublishSubscriptions(ctx, getApp()); err != nil {
 				handleError(newError("failed to publish subscriptions", err))
 			}
 			fmt.Println("Subscriptions published to network")
@@ -760,6 +713,7 @@ This will overwrite your local configuration with data from relays.
 	configCmd.AddCommand(configSyncCmd)
 	configCmd.AddCommand(configPublishCmd)
 
+	supportsOutput(configRelayListCmd, configSearchRelayListCmd, configDMRelayListCmd, configSubscribeListCmd)
 	RegisterCommandGroup("Config", "Configuration management", configCmd)
 }
 
@@ -772,3 +726,159 @@ func maskString(s string, visible int) string {
 	}
 	return s[:visible] + "***"
 }
+
+func addSyncFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("dry-run", false, "Only print the differences, change nothing")
+	cmd.Flags().Bool("publish", false, "Publish the merged list if the network copy lacks local entries")
+	cmd.Flags().String("strategy", string(utils.MergeThreeWay), "How to combine local and network lists: merge, interactive, local or remote")
+	cmd.RegisterFlagCompletionFunc("strategy", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		names := make([]string, 0, len(utils.MergeStrategies))
+		for _, strategy := range utils.MergeStrategies {
+			names = append(names, string(strategy))
+		}
+		return names, cobra.ShellCompDirectiveNoFileComp
+	})
+}
+
+func syncOptionsFromFlags(cmd *cobra.Command) (utils.SyncOptions, error) {
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	strategyName, _ := cmd.Flags().GetString("strategy")
+
+	strategy, err := utils.ParseMergeStrategy(strategyName)
+	if err != nil {
+		return utils.SyncOptions{}, newError("invalid --strategy", err)
+	}
+
+	opts := utils.SyncOptions{Strategy: strategy, DryRun: dryRun}
+	// a dry run throws the merge away, so there is nothing to ask about
+	if strategy == utils.MergeInteractive && !dryRun {
+		opts.Resolve = promptConflict(cmd.InOrStdin(), cmd.OutOrStdout())
+	}
+	return opts, nil
+}
+
+// promptConflict asks on the terminal which side of a conflicting entry to keep.
+func promptConflict(in io.Reader, out io.Writer) utils.ConflictResolver {
+	reader := bufio.NewReader(in)
+	return func(change utils.ListChange) nostr.Tag {
+		fmt.Fprintf(out, "Conflict on %s\n", change.Key)
+		fmt.Fprintf(out, "  [l] local:   %v\n", change.Local)
+		fmt.Fprintf(out, "  [r] network: %v\n", change.Remote)
+		fmt.Fprintf(out, "  [d] drop\n")
+		fmt.Fprint(out, "Keep which? [l]: ")
+
+		line, _ := reader.ReadString('\n')
+		switch strings.ToLower(strings.TrimSpace(line)) {
+		case "r":
+			return change.Remote
+		case "d":
+			return nil
+		default:
+			return change.Local
+		}
+	}
+}
+
+// finishSync reports how a sync ended. When the network copy lacks local entries the
+// merged list is only published with --publish; otherwise a hint is printed.
+func finishSync(cmd *cobra.Command, opts utils.SyncOptions, diffs []utils.ListDiff, publish func() error) error {
+	if opts.DryRun {
+		return nil
+	}
+	for _, diff := range diffs {
+		if !diff.RemoteOutdated() {
+			continue
+		}
+		if doPublish, _ := cmd.Flags().GetBool("publish"); !doPublish {
+			fmt.Println("Local lists have entries the network does not; run again with --publish to push them")
+			return nil
+		}
+		if err := publish(); err != nil {
+			return err
+		}
+		fmt.Println("Synced from network and published the merged list")
+		return nil
+	}
+	fmt.Println("Synced from network")
+	return nil
+}
+
+func writeSubscriptions(w io.Writer, users, communities, hashtags []config.Subscription) {
+	if len(users) == 0 && len(communities) == 0 && len(hashtags) == 0 {
+		fmt.Fprintln(w, "No subscriptions.")
+		return
+	}
+
+	fmt.Fprintln(w, "=== Subscriptions ===")
+
+	if len(users) > 0 {
+		fmt.Fprintln(w, "\n[Users]")
+		for _, s := range users {
+			petname := ""
+			if s.Petname != "" {
+				petname = " (" + s.Petname + ")"
+			}
+			relay := ""
+			if s.Relay != "" {
+				relay = " @ " + s.Relay
+			}
+			fmt.Fprintf(w, "  - %s%s%s\n", s.ID, relay, petname)
+		}
+	}
+
+	if len(communities) > 0 {
+		fmt.Fprintln(w, "\n[Communities]")
+		for _, s := range communities {
+			relay := ""
+			if s.Relay != "" {
+				relay = " @ " + s.Relay
+			}
+			fmt.Fprintf(w, "  - %s%s\n", s.ID, relay)
+		}
+	}
+
+	if len(hashtags) > 0 {
+		fmt.Fprintln(w, "\n[Hashtags]")
+		for _, s := range hashtags {
+			fmt.Fprintf(w, "  - #%s\n", s.ID)
+		}
+	}
+}
+
+func configRelaysResult(relays []config.Relay) result {
+	rows := make([][]string, len(relays))
+	for i, r := range relays {
+		rows[i] = []string{r.URL, strconv.FormatBool(r.Read != nil && *r.Read), strconv.FormatBool(r.Write != nil && *r.Write)}
+	}
+	return result{
+		Data:   relays,
+		Header: []string{"URL", "READ", "WRITE"},
+		Rows:   rows,
+		Text: func(w io.Writer) error {
+			if len(relays) == 0 {
+				fmt.Fprintln(w, "No relays configured.")
+				return nil
+			}
+			fmt.Fprintln(w, "=== Relays ===")
+			for _, row := range rows {
+				fmt.Fprintf(w, "  %s  read=%s write=%s\n", row[0], row[1], row[2])
+			}
+			return nil
+		},
+	}
+}
+
+// headedValuesResult is a valuesResult whose text form has a title line and indented values.
+func headedValuesResult(title, column string, values []string, empty string) result {
+	r := valuesResult(column, values, empty)
+	if len(values) > 0 {
+		r.Text = func(w io.Writer) error {
+			fmt.Fprintln(w, title)
+			for _, v := range values {
+				fmt.Fprintf(w, "  %s\n", v)
+			}
+			return nil
+		}
+	}
+	return r
+}
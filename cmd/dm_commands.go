@@ -3,6 +3,7 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"io"
 	"time"
 
 	"fiatjaf.com/nostr"
@@ -17,10 +18,14 @@ func registerDMCommands() {
 	dmCmd := &cobra.Command{
 		Use:   "dm [npub]",
 		Short: "Direct messages (or open DM TUI with a specific user)",
-		Args:  cobra.MaximumNArgs(1),
+		Long: `Open the DM TUI with a user. Without an npub, search for the recipient
+by name, NIP-05 address or alias first.`,
+		Args: cobra.MaximumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
 			if len(args) == 0 {
-				cmd.Help()
+				if err := dm.RunDMPicker(getApp()); err != nil {
+					handleError(newError("failed to open DM TUI", err))
+				}
 				return
 			}
 			npubOrHex := args[0]
@@ -48,11 +53,13 @@ func registerDMCommands() {
 			}
 
 			ctx := context.Background()
-			if err := utils.SendDM(ctx, getApp(), recipientPubKey, content); err != nil {
+			reports, err := utils.SendDM(ctx, getApp(), recipientPubKey, content)
+			if err != nil {
 				handleError(newError("failed to send DM", err))
 			}
 
 			fmt.Printf("DM sent to %s\n", nip19.EncodeNpub(recipientPubKey)[:32]+"...")
+			fmt.Print(utils.FormatPublishReport(reports[0]))
 		},
 	}
 
@@ -74,31 +81,33 @@ func registerDMCommands() {
 				handleError(newError("failed to list conversations", err))
 			}
 
-			if len(conversations) == 0 {
-				fmt.Println("No DM conversations found.")
-				return
+			names := make([]string, len(conversations))
+			rows := make([][]string, len(conversations))
+			for i, conv := range conversations {
+				names[i] = dmPeerName(ctx, conv.PubKey)
+				rows[i] = []string{conv.LatestDM.Timestamp.Time().Format("2006-01-02 15:04"), names[i], dmDirection(conv.LatestDM), conv.LatestDM.Content}
 			}
 
-			fmt.Println("Recent DM conversations:")
-			fmt.Println()
-			for _, conv := range conversations {
-				prefix := "←"
-				if conv.LatestDM.FromMe {
-					prefix = "→"
-				}
-			name := conv.PubKey[:16] + "..."
-			if otherPK, err := nostr.PubKeyFromHex(conv.PubKey); err == nil {
-				pm := getApp().System().FetchProfileMetadata(ctx, otherPK)
-				if pm.Event != nil {
-					if meta, err := sdk.ParseMetadata(*pm.Event); err == nil && meta.Name != "" {
-						name = meta.Name
+			err = printResult(cmd, result{
+				Data:   conversations,
+				Header: []string{"TIME", "NAME", "DIR", "LATEST"},
+				Rows:   rows,
+				Text: func(w io.Writer) error {
+					if len(conversations) == 0 {
+						fmt.Fprintln(w, "No DM conversations found.")
+						return nil
 					}
-				}
-			}
-				fmt.Printf("[%s] %s\n", conv.LatestDM.Timestamp.Time().Format("2006-01-02 15:04"), name)
-				fmt.Printf("  %s %s\n", prefix, conv.LatestDM.Content)
-				fmt.Println()
-			}
+					fmt.Fprintln(w, "Recent DM conversations:")
+					fmt.Fprintln(w)
+					for _, row := range rows {
+						fmt.Fprintf(w, "[%s] %s\n", row[0], row[1])
+						fmt.Fprintf(w, "  %s %s\n", row[2], row[3])
+						fmt.Fprintln(w)
+					}
+					return nil
+				},
+			})
+			handleError(err)
 		},
 	}
 	dmListCmd.Flags().IntP("limit", "n", 20, "Number of conversations to show")
@@ -130,31 +139,32 @@ func registerDMCommands() {
 				handleError(newError("failed to query DM history", err))
 			}
 
-			if len(messages) == 0 {
-				fmt.Printf("No DM history with %s.\n", nip19.EncodeNpub(recipientPubKey)[:32]+"...")
-				return
+			rows := make([][]string, len(messages))
+			for i, msg := range messages {
+				rows[i] = []string{msg.Timestamp.Time().Format("2006-01-02 15:04:05"), dmDirection(msg), msg.Content}
 			}
 
-		recipientName := nip19.EncodeNpub(recipientPubKey)[:32] + "..."
-		pm := getApp().System().FetchProfileMetadata(ctx, recipientPubKey)
-		if pm.Event != nil {
-			if meta, err := sdk.ParseMetadata(*pm.Event); err == nil && meta.Name != "" {
-				recipientName = meta.Name
-			}
-		}
-
-			fmt.Printf("=== DM History with %s ===\n", recipientName)
-			fmt.Println()
+			err = printResult(cmd, result{
+				Data:   messages,
+				Header: []string{"TIME", "DIR", "CONTENT"},
+				Rows:   rows,
+				Text: func(w io.Writer) error {
+					if len(messages) == 0 {
+						fmt.Fprintf(w, "No DM history with %s.\n", nip19.EncodeNpub(recipientPubKey)[:32]+"...")
+						return nil
+					}
 
-			for _, msg := range messages {
-				prefix := "←"
-				if msg.FromMe {
-					prefix = "→"
-				}
-				fmt.Printf("[%s] %s\n", msg.Timestamp.Time().Format("15:04:05"), prefix)
-				fmt.Printf("  %s\n", msg.Content)
-				fmt.Println()
-			}
+					fmt.Fprintf(w, "=== DM History with %s ===\n", dmPeerName(ctx, recipientPubKey.Hex()))
+					fmt.Fprintln(w)
+					for _, msg := range messages {
+						fmt.Fprintf(w, "[%s] %s\n", msg.Timestamp.Time().Format("15:04:05"), dmDirection(msg))
+						fmt.Fprintf(w, "  %s\n", msg.Content)
+						fmt.Fprintln(w)
+					}
+					return nil
+				},
+			})
+			handleError(err)
 		},
 	}
 	dmHistoryCmd.Flags().IntP("limit", "n", 50, "Number of messages to show")
@@ -194,5 +204,27 @@ func registerDMCommands() {
 	dmCmd.AddCommand(dmHistoryCmd)
 	dmCmd.AddCommand(dmListenCmd)
 
+	supportsOutput(dmListCmd, dmHistoryCmd)
 	RegisterCommandGroup("DM", "Direct messages", dmCmd)
 }
+
+// dmPeerName returns the profile name of the hex pubkey, or a shortened pubkey.
+func dmPeerName(ctx context.Context, pubkeyHex string) string {
+	name := pubkeyHex[:16] + "..."
+	if pk, err := nostr.PubKeyFromHex(pubkeyHex); err == nil {
+		pm := getApp().System().FetchProfileMetadata(ctx, pk)
+		if pm.Event != nil {
+			if meta, err := sdk.ParseMetadata(*pm.Event); err == nil && meta.Name != "" {
+				name = meta.Name
+			}
+		}
+	}
+	return name
+}
+
+func dmDirection(msg utils.DMMessage) string {
+	if msg.FromMe {
+		return "→"
+	}
+	return "←"
+}
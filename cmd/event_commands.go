@@ -1,14 +1,21 @@
 package cmd
 
 import (
+	"bufio"
+	"context"
 	"fmt"
+	"io"
 	"os"
+	"strconv"
+	"strings"
 
 	"fiatjaf.com/nostr/nip19"
 	"github.com/jerry-harm/nosmec/tui/component/bubblon"
 	tea "charm.land/bubbletea/v2"
 	"github.com/jerry-harm/nosmec/config"
+	sdk "github.com/jerry-harm/nosmec/nostr_sdk"
 	"github.com/jerry-harm/nosmec/tui/event"
+	"github.com/jerry-harm/nosmec/utils"
 	"github.com/spf13/cobra"
 )
 
@@ -49,9 +56,215 @@ func registerEventCommands() {
 		},
 	}
 
+	eventInspectCmd := &cobra.Command{
+		Use:   "inspect <event-id>",
+		Short: "Verify an event and show where it can be found",
+		Long: `Fetch an event and print it without opening the interactive view: whether its
+ID and signature are valid, its tags and nostr: mentions decoded into NIP-19 codes,
+its NIP-40 expiration, whether its author deleted it (NIP-09) and which relays
+have it. The relays asked are the ones it was seen on, the hints in the code, the
+author's outbox relays and our read relays.
+
+The event can be given as a hex ID or a note, nevent or naddr code.`,
+		Example: `  nosmec event inspect nevent1...
+  nosmec event inspect note1... -o json`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			app := getApp()
+			ctx, cancel := context.WithTimeout(context.Background(), app.QueryTimeout())
+			defer cancel()
+
+			insp, err := utils.InspectEvent(ctx, app, args[0])
+			if err != nil {
+				return newError("failed to inspect event", err)
+			}
+			return printResult(cmd, inspectionResult(insp))
+		},
+	}
+
+	eventBroadcastCmd := &cobra.Command{
+		Use:   "broadcast [event-id...]",
+		Short: "Publish existing events unchanged to more relays",
+		Long: `Fetch signed events and publish them again, unchanged, to spread events that only
+a few relays have. Events with an invalid ID or signature are not sent.
+
+The relays are chosen with --to, --write, --outbox, --inbox-of and --mentions,
+which can be combined; without any of them our write relays are used.
+
+Without event IDs, or with "-", IDs are read from stdin, one per line. Blank lines
+and lines starting with # are skipped.`,
+		Example: `  nosmec event broadcast nevent1...
+  nosmec event broadcast note1... --outbox --mentions
+  nosmec event broadcast note1... --to wss://nos.lol,wss://relay.damus.io
+  nosmec event broadcast note1... --inbox-of alice
+  nosmec req -a alice -k 1 --limit 20 | jq -r .id | nosmec event broadcast --write`,
+		Args: cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			app := getApp()
+			flags := cmd.Flags()
+
+			var targets utils.BroadcastTargets
+			targets.Relays, _ = flags.GetStringSlice("to")
+			targets.Write, _ = flags.GetBool("write")
+			targets.Outbox, _ = flags.GetBool("outbox")
+			targets.Mentioned, _ = flags.GetBool("mentions")
+			inboxOf, _ := flags.GetStringSlice("inbox-of")
+			for _, who := range inboxOf {
+				pk, err := utils.ResolveAliasToPubKey(app, who)
+				if err != nil {
+					return newError("invalid user "+who, err)
+				}
+				targets.InboxOf = append(targets.InboxOf, pk)
+			}
+
+			ids := args
+			if len(ids) == 0 || (len(ids) == 1 && ids[0] == "-") {
+				var err error
+				if ids, err = readEventIDs(cmd.InOrStdin()); err != nil {
+					return newError("failed to read event ids", err)
+				}
+				if len(ids) == 0 {
+					return newError("no event ids given", nil)
+				}
+			}
+
+			outcomes := make([]broadcastOutcome, 0, len(ids))
+			failed := 0
+			for _, id := range ids {
+				ctx, cancel := context.WithTimeout(context.Background(), app.QueryTimeout())
+				report, err := utils.BroadcastEvent(ctx, app, id, targets)
+				cancel()
+
+				outcome := broadcastOutcome{Input: id, Report: report}
+				if err == nil {
+					err = report.Err()
+				}
+				if err != nil {
+					outcome.Error = err.Error()
+					failed++
+				}
+				outcomes = append(outcomes, outcome)
+			}
+
+			if err := printResult(cmd, broadcastResult(outcomes)); err != nil {
+				return err
+			}
+			if failed > 0 {
+				return newError(fmt.Sprintf("%d of %d events were not broadcast", failed, len(outcomes)), nil)
+			}
+			return nil
+		},
+	}
+	eventBroadcastCmd.Flags().StringSlice("to", nil, "Relays to publish to")
+	eventBroadcastCmd.Flags().Bool("write", false, "Publish to our write relays")
+	eventBroadcastCmd.Flags().Bool("outbox", false, "Publish to the outbox relays of the event author")
+	eventBroadcastCmd.Flags().StringSlice("inbox-of", nil, "Publish to the inbox relays of these users (npub or alias)")
+	eventBroadcastCmd.Flags().Bool("mentions", false, "Publish to the inbox relays of the users the event mentions")
+
+	eventCmd.AddCommand(eventInspectCmd, eventBroadcastCmd)
+
+	supportsOutput(eventInspectCmd, eventBroadcastCmd)
 	RegisterCommandGroup("Events", "Event operations", eventCmd)
 }
 
+// inspectionResult lays out an event inspection for printResult.
+func inspectionResult(insp *utils.EventInspection) result {
+	evt := insp.Event
+	valid := func(ok bool, bad string) string {
+		if ok {
+			return "ok"
+		}
+		return bad
+	}
+
+	deleted := "no"
+	if insp.Deleted {
+		deleted = "yes, by " + strings.Join(insp.Deletions, ", ")
+	}
+	expires := "never"
+	if insp.ExpiresAt != 0 {
+		expires = formatSearchTime(insp.ExpiresAt)
+		if insp.Expired {
+			expires += " (expired)"
+		}
+	}
+	found, unreachable := 0, 0
+	for _, r := range insp.Relays {
+		switch {
+		case r.Found:
+			found++
+		case r.Error != "":
+			unreachable++
+		}
+	}
+	presence := fmt.Sprintf("%d of %d", found, len(insp.Relays))
+	if unreachable > 0 {
+		presence += fmt.Sprintf(" (%d unreachable)", unreachable)
+	}
+
+	rows := [][]string{
+		{"nevent", insp.Nevent},
+		{"note", insp.Note},
+	}
+	if insp.Naddr != "" {
+		rows = append(rows, []string{"naddr", insp.Naddr})
+	}
+	rows = append(rows,
+		[]string{"kind", strconv.Itoa(int(evt.Kind))},
+		[]string{"author", insp.Npub},
+		[]string{"created", formatSearchTime(evt.CreatedAt)},
+		[]string{"id", valid(insp.IDValid, "MISMATCH")},
+		[]string{"signature", valid(insp.SigValid, "INVALID")},
+		[]string{"expires", expires},
+		[]string{"deleted", deleted},
+		[]string{"relays", presence},
+	)
+
+	return result{
+		Data:   insp,
+		Header: []string{"FIELD", "VALUE"},
+		Rows:   rows,
+		Text: func(w io.Writer) error {
+			for _, row := range rows {
+				fmt.Fprintf(w, "%-10s %s\n", row[0]+":", row[1])
+			}
+
+			if len(insp.Tags) > 0 {
+				fmt.Fprintln(w, "\nTags:")
+				for _, t := range insp.Tags {
+					fmt.Fprintf(w, "  %s\n", strings.Join(t.Tag, " "))
+					if t.Decoded != "" {
+						fmt.Fprintf(w, "    = %s\n", t.Decoded)
+					}
+				}
+			}
+			if len(insp.Mentions) > 0 {
+				fmt.Fprintln(w, "\nMentions:")
+				for _, m := range insp.Mentions {
+					fmt.Fprintf(w, "  %s\n    = %s\n", m.URI, m.Decoded)
+				}
+			}
+			if len(insp.Relays) > 0 {
+				fmt.Fprintln(w, "\nRelays:")
+				for _, r := range insp.Relays {
+					switch {
+					case r.Found:
+						fmt.Fprintf(w, "  ✓ %s\n", r.Relay)
+					case r.Error != "":
+						fmt.Fprintf(w, "  ? %s (%s)\n", r.Relay, r.Error)
+					default:
+						fmt.Fprintf(w, "  ✗ %s\n", r.Relay)
+					}
+				}
+			}
+			if evt.Content != "" {
+				fmt.Fprintf(w, "\nContent:\n%s\n", evt.Content)
+			}
+			return nil
+		},
+	}
+}
+
 func RunEventDetail(app *config.AppContext, eventID string) error {
 	m := event.NewFromID(eventID, app, 80, 24, nil)
 	ctrl, err := bubblon.New(m)
@@ -62,3 +275,89 @@ func RunEventDetail(app *config.AppContext, eventID string) error {
 	_, err = tea.NewProgram(ctrl).Run()
 	return err
 }
+
+// broadcastOutcome is what happened to one event given to "event broadcast".
+type broadcastOutcome struct {
+	Input  string             `json:"input"`
+	Report *sdk.PublishReport `json:"report,omitempty"`
+	Error  string             `json:"error,omitempty"`
+}
+
+// broadcastResult lays out broadcast outcomes for printResult, one row per relay.
+func broadcastResult(outcomes []broadcastOutcome) result {
+	var rows [][]string
+	for _, o := range outcomes {
+		if o.Report == nil {
+			rows = append(rows, []string{o.Input, "", o.Error})
+			continue
+		}
+		for _, res := range o.Report.Results {
+			rows = append(rows, []string{o.Input, res.Relay, publishStatus(res)})
+		}
+	}
+
+	return result{
+		Data:   outcomes,
+		Header: []string{"EVENT", "RELAY", "RESULT"},
+		Rows:   rows,
+		Text: func(w io.Writer) error {
+			for _, o := range outcomes {
+				if o.Report == nil {
+					fmt.Fprintf(w, "%s: %s\n", o.Input, o.Error)
+					continue
+				}
+				evt := o.Report.Event
+				fmt.Fprintln(w, nip19.EncodeNevent(evt.ID, nil, evt.PubKey))
+				fmt.Fprint(w, utils.FormatPublishReport(o.Report))
+			}
+			return nil
+		},
+	}
+}
+
+// publishResult lays out the report of a command that published an event: the report as
+// data, one row per relay, and in text mode the headline lines followed by the report.
+func publishResult(report *sdk.PublishReport, headline ...string) result {
+	rows := make([][]string, len(report.Results))
+	for i, res := range report.Results {
+		rows[i] = []string{res.Relay, publishStatus(res)}
+	}
+
+	return result{
+		Data:   report,
+		Header: []string{"RELAY", "RESULT"},
+		Rows:   rows,
+		Text: func(w io.Writer) error {
+			for _, line := range headline {
+				fmt.Fprintln(w, line)
+			}
+			_, err := fmt.Fprint(w, utils.FormatPublishReport(report))
+			return err
+		},
+	}
+}
+
+func publishStatus(res sdk.RelayPublishResult) string {
+	switch {
+	case res.OK:
+		return "ok"
+	case res.TimedOut:
+		return "timeout"
+	default:
+		return "failed: " + res.Reason
+	}
+}
+
+// readEventIDs reads one event ID or code per line, skipping blank lines and # comments.
+func readEventIDs(r io.Reader) ([]string, error) {
+	var ids []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		ids = append(ids, line)
+	}
+	return ids, scanner.Err()
+}
@@ -0,0 +1,19 @@
+package cmd
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestReadEventIDs(t *testing.T) {
+	in := "note1abc\n\n  # a comment\n  nevent1def  \n" + strings.Repeat("ab", 32)
+	ids, err := readEventIDs(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"note1abc", "nevent1def", strings.Repeat("ab", 32)}
+	if !slices.Equal(ids, want) {
+		t.Errorf("readEventIDs() = %v, want %v", ids, want)
+	}
+}
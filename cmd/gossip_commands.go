@@ -3,6 +3,9 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"io"
+	"maps"
+	"slices"
 	"sync/atomic"
 	"time"
 
@@ -21,6 +24,7 @@ func registerGossipCommands() {
 		Run:   runGossip,
 	}
 
+	supportsOutput(gossipCmd)
 	RegisterCommandGroup("Gossip", "Relay discovery", gossipCmd)
 }
 
@@ -29,7 +33,13 @@ func runGossip(cmd *cobra.Command, args []string) {
 
 	subs := app.ListSubscriptions("user")
 	if len(subs) == 0 {
-		fmt.Println("No user subscriptions found. Following users first.")
+		handleError(printResult(cmd, result{
+			Data: gossipOutput{Relays: []string{}, Throttled: []relayThrottle{}},
+			Text: func(w io.Writer) error {
+				_, err := fmt.Fprintln(w, "No user subscriptions found. Following users first.")
+				return err
+			},
+		}))
 		return
 	}
 
@@ -80,14 +90,56 @@ func runGossip(cmd *cobra.Command, args []string) {
 				}
 				atomic.AddInt32(&relayCount, int32(len(r)))
 			}
-			fmt.Printf("\rProcessing: %d/%d users, %d relays found", completed, len(subs), atomic.LoadInt32(&relayCount))
+			fmt.Fprintf(cmd.ErrOrStderr(), "\rProcessing: %d/%d users, %d relays found", completed, len(subs), atomic.LoadInt32(&relayCount))
 		case <-ticker.C:
 		}
 	}
 
-	fmt.Printf("\nDiscovered %d unique relays from %d users\n", len(relaySet), len(subs))
+	fmt.Fprintln(cmd.ErrOrStderr())
 
-	if len(relaySet) > 0 {
-		fmt.Printf("Ensured %d relays in pool for this session.\n", len(relaySet))
+	stats := app.System().RelayLimiter.Stats()
+	out := gossipOutput{
+		Users:     len(subs),
+		Relays:    slices.Sorted(maps.Keys(relaySet)),
+		Throttled: []relayThrottle{},
 	}
+	for _, s := range stats {
+		if s.Delayed == 0 && s.RateLimited == 0 {
+			continue
+		}
+		out.Throttled = append(out.Throttled, relayThrottle{
+			Relay:       s.Relay,
+			Requests:    s.Requests,
+			Delayed:     s.Delayed,
+			WaitedMs:    s.Waited.Milliseconds(),
+			RateLimited: s.RateLimited,
+		})
+	}
+
+	r := valuesResult("RELAY", out.Relays, "")
+	r.Data = out
+	r.Text = func(w io.Writer) error {
+		fmt.Fprintf(w, "Discovered %d unique relays from %d users\n", len(out.Relays), out.Users)
+		if len(out.Relays) > 0 {
+			fmt.Fprintf(w, "Ensured %d relays in pool for this session.\n", len(out.Relays))
+		}
+		_, err := fmt.Fprint(w, utils.FormatRelayLimitStats(stats))
+		return err
+	}
+	handleError(printResult(cmd, r))
+}
+
+// gossipOutput is the outcome of "gossip": the relays found and the relays that throttled us.
+type gossipOutput struct {
+	Users     int             `json:"users"`
+	Relays    []string        `json:"relays"`
+	Throttled []relayThrottle `json:"throttled"`
+}
+
+type relayThrottle struct {
+	Relay       string `json:"relay"`
+	Requests    int    `json:"requests"`
+	Delayed     int    `json:"delayed"`
+	WaitedMs    int64  `json:"waited_ms"`
+	RateLimited int    `json:"rate_limited"`
 }
@@ -0,0 +1,215 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"fiatjaf.com/nostr"
+	"github.com/jerry-harm/nosmec/cmd/completion"
+	"github.com/jerry-harm/nosmec/nostr_sdk/hints"
+	"github.com/jerry-harm/nosmec/utils"
+	"github.com/spf13/cobra"
+)
+
+func registerHintsCommands() {
+	hintsCmd := &cobra.Command{
+		Use:   "hints",
+		Short: "Inspect and maintain the relay hints database",
+	}
+
+	hintsShowCmd := &cobra.Command{
+		Use:               "show <npub>",
+		Short:             "Show relay scores for a user",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completion.PubKeyCompletionFunc,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			db, err := getHintsDB()
+			if err != nil {
+				return err
+			}
+
+			pubkey, err := utils.ResolveAliasToPubKey(getApp(), args[0])
+			if err != nil {
+				return newError("failed to parse identifier", err)
+			}
+
+			limit, _ := cmd.Flags().GetInt("limit")
+			return writeHintScores(cmd.OutOrStdout(), db.GetDetailedScores(pubkey, limit))
+		},
+	}
+	hintsShowCmd.Flags().IntP("limit", "n", 20, "Maximum number of relays to show")
+	hintsShowCmd.RegisterFlagCompletionFunc("limit", completion.LimitCompletionFunc)
+
+	hintsRelaysCmd := &cobra.Command{
+		Use:   "relays",
+		Short: "List every relay known to the hints database",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			db, err := getHintsDB()
+			if err != nil {
+				return err
+			}
+
+			relays, err := db.GetAllKnownRelays()
+			if err != nil {
+				return newError("failed to list relays", err)
+			}
+			return printResult(cmd, valuesResult("RELAY", relays, ""))
+		},
+	}
+
+	hintsPruneCmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Remove hints that have not been refreshed recently",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			db, err := getHintsDB()
+			if err != nil {
+				return err
+			}
+
+			olderThan, _ := cmd.Flags().GetString("older-than")
+			age, err := utils.ParseDuration(olderThan)
+			if err != nil {
+				return newError("invalid --older-than", err)
+			}
+
+			cutoff := nostr.Now() - nostr.Timestamp(age/time.Second)
+			removed, err := db.Prune(cutoff)
+			if err != nil {
+				return newError("failed to prune hints", err)
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Pruned %d hint entries older than %s\n", removed, olderThan)
+			return nil
+		},
+	}
+	hintsPruneCmd.Flags().String("older-than", "90d", "Remove entries last updated before this age (e.g. 30d, 2w, 720h)")
+
+	hintsDeleteCmd := &cobra.Command{
+		Use:               "delete <npub>",
+		Short:             "Delete all hints for a user",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completion.PubKeyCompletionFunc,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			db, err := getHintsDB()
+			if err != nil {
+				return err
+			}
+
+			pubkey, err := utils.ResolveAliasToPubKey(getApp(), args[0])
+			if err != nil {
+				return newError("failed to parse identifier", err)
+			}
+			if err := db.Delete(pubkey); err != nil {
+				return newError("failed to delete hints", err)
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Deleted hints for %s\n", args[0])
+			return nil
+		},
+	}
+
+	hintsExportCmd := &cobra.Command{
+		Use:   "export [file]",
+		Short: "Export the hints database as JSON (stdout by default)",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			db, err := getHintsDB()
+			if err != nil {
+				return err
+			}
+
+			var w io.Writer = cmd.OutOrStdout()
+			if len(args) == 1 {
+				f, err := os.Create(args[0])
+				if err != nil {
+					return newError("failed to create export file", err)
+				}
+				defer f.Close()
+				w = f
+			}
+
+			count, err := hints.Export(db, w)
+			if err != nil {
+				return newError("failed to export hints", err)
+			}
+			if len(args) == 1 {
+				fmt.Fprintf(cmd.OutOrStdout(), "Exported %d hint entries to %s\n", count, args[0])
+			}
+			return nil
+		},
+	}
+
+	hintsImportCmd := &cobra.Command{
+		Use:   "import <file>",
+		Short: "Import hints from a JSON export, merging with existing data",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			db, err := getHintsDB()
+			if err != nil {
+				return err
+			}
+
+			f, err := os.Open(args[0])
+			if err != nil {
+				return newError("failed to open import file", err)
+			}
+			defer f.Close()
+
+			count, err := hints.Import(db, f)
+			if err != nil {
+				return newError("failed to import hints", err)
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Imported %d hint entries\n", count)
+			return nil
+		},
+	}
+
+	hintsCmd.AddCommand(hintsShowCmd)
+	hintsCmd.AddCommand(hintsRelaysCmd)
+	hintsCmd.AddCommand(hintsPruneCmd)
+	hintsCmd.AddCommand(hintsDeleteCmd)
+	hintsCmd.AddCommand(hintsExportCmd)
+	hintsCmd.AddCommand(hintsImportCmd)
+
+	supportsOutput(hintsRelaysCmd)
+	RegisterCommandGroup("Hints", "Relay hints database", hintsCmd)
+}
+
+func getHintsDB() (hints.HintsDB, error) {
+	app := getApp()
+	if app == nil {
+		return nil, newError("app not initialized", nil)
+	}
+	sys := app.System()
+	if sys == nil || sys.Hints == nil {
+		return nil, newError("hints database not available", nil)
+	}
+	return sys.Hints, nil
+}
+
+func writeHintScores(w io.Writer, scores []hints.RelayScores) error {
+	if len(scores) == 0 {
+		_, err := fmt.Fprintln(w, "No hints found.")
+		return err
+	}
+
+	for _, rs := range scores {
+		if _, err := fmt.Fprintf(w, "%-40s %14d\n", rs.Relay, rs.Sum); err != nil {
+			return err
+		}
+		for i, ts := range rs.Scores {
+			if ts == 0 {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "    %-26s %s\n", hints.HintKey(i).String(), formatTime(ts)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
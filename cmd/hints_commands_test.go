@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"fiatjaf.com/nostr"
+	"github.com/jerry-harm/nosmec/nostr_sdk/hints"
+)
+
+func TestWriteHintScores(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	err := writeHintScores(&out, []hints.RelayScores{
+		{Relay: "wss://relay-a.example", Scores: [4]nostr.Timestamp{0, 0, 1700000000, 0}, Sum: 42},
+	})
+	if err != nil {
+		t.Fatalf("writeHintScores() error = %v", err)
+	}
+
+	got := out.String()
+	if !strings.Contains(got, "wss://relay-a.example") || !strings.Contains(got, "42") {
+		t.Fatalf("writeHintScores() missing relay line: %q", got)
+	}
+	if !strings.Contains(got, hints.LastInRelayList.String()) {
+		t.Fatalf("writeHintScores() missing populated key: %q", got)
+	}
+	if strings.Contains(got, hints.LastFetchAttempt.String()) {
+		t.Fatalf("writeHintScores() printed empty key: %q", got)
+	}
+}
+
+func TestWriteHintScores_Empty(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	if err := writeHintScores(&out, nil); err != nil {
+		t.Fatalf("writeHintScores() error = %v", err)
+	}
+	if out.String() != "No hints found.\n" {
+		t.Fatalf("writeHintScores() output = %q", out.String())
+	}
+}
@@ -47,6 +47,12 @@ func registerNoteCommands() {
 			}
 
 			app := getApp()
+			if name, _ := cmd.Flags().GetString("search"); name != "" {
+				if err := timeline.RunSearchTimeline(app, name, limit); err != nil {
+					handleError(err)
+				}
+				return
+			}
 			if err := timeline.RunTimeline(app, filter, hashtags, limit, ""); err != nil {
 				handleError(err)
 			}
@@ -58,6 +64,7 @@ func registerNoteCommands() {
 	noteTimelineCmd.Flags().Bool("global", false, "Show global timeline")
 	noteTimelineCmd.Flags().IntP("limit", "n", 50, "Number of notes to show")
 	noteTimelineCmd.Flags().StringSliceP("hashtag", "t", nil, "Filter by hashtags")
+	noteTimelineCmd.Flags().String("search", "", "Follow a saved search, highlighting hits not seen before")
 
 	noteTimelineCmd.RegisterFlagCompletionFunc("limit", completion.LimitCompletionFunc)
 	noteTimelineCmd.RegisterFlagCompletionFunc("hashtag", completion.HashtagCompletionFunc)
@@ -72,13 +79,19 @@ func registerNoteCommands() {
 			ctx := context.Background()
 			app := getApp()
 
-			event, err := utils.PostNote(ctx, app, content)
+			report, err := utils.PostNote(ctx, app, content)
 			if err != nil {
 				handleError(err)
 			}
 
-			fmt.Printf("Posted successfully!\n")
-			fmt.Printf("Note ID: %s\n", nip19.EncodeNevent(event.ID, nil, event.PubKey))
+			headline := "Posted successfully!"
+			if len(report.Accepted()) == 0 {
+				headline = "No relay reachable; note saved to the outgoing queue."
+			}
+			handleError(printResult(cmd, publishResult(report,
+				headline,
+				"Note ID: "+nip19.EncodeNevent(report.Event.ID, nil, report.Event.PubKey),
+			)))
 		},
 	}
 
@@ -136,6 +149,8 @@ func registerNoteCommands() {
 	noteCmd.AddCommand(noteReplyCmd)
 	noteCmd.AddCommand(noteComposeCmd)
 
+	supportsOutput(notePostCmd)
+
 	RegisterCommandGroup("Notes", "Note operations", noteCmd)
 }
 
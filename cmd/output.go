@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// Formats accepted by the global --output flag.
+const (
+	outputText  = "text"
+	outputJSON  = "json"
+	outputJSONL = "jsonl"
+	outputTable = "table"
+)
+
+var outputFormats = []string{outputText, outputJSON, outputJSONL, outputTable}
+
+// outputFormat is set by --output.
+var outputFormat = outputText
+
+// outputAnnotation marks the commands that print through printResult and so honour
+// --output; the others only print text and refuse any other format.
+const outputAnnotation = "nosmec/output"
+
+// supportsOutput marks cmds as honouring --output.
+func supportsOutput(cmds ...*cobra.Command) {
+	for _, c := range cmds {
+		if c.Annotations == nil {
+			c.Annotations = make(map[string]string)
+		}
+		c.Annotations[outputAnnotation] = "true"
+	}
+}
+
+func validateOutputFormat(cmd *cobra.Command) error {
+	if !slices.Contains(outputFormats, outputFormat) {
+		return newError(fmt.Sprintf("invalid --output %q, want one of %s", outputFormat, strings.Join(outputFormats, ", ")), nil)
+	}
+	if outputFormat != outputText && cmd.Annotations[outputAnnotation] == "" {
+		return newError(fmt.Sprintf("%q only prints text, --output %s is not supported", cmd.CommandPath(), outputFormat), nil)
+	}
+	return nil
+}
+
+func outputFormatCompletion(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return outputFormats, cobra.ShellCompDirectiveNoFileComp
+}
+
+// result is what a command prints, so that every output format is rendered the same way.
+//
+// Data is encoded as is for json; for jsonl a slice is written one element per line.
+// Header and Rows make up the table. Text writes the usual human-readable form; without
+// it, text mode prints the rows without the header.
+type result struct {
+	Data   any
+	Header []string
+	Rows   [][]string
+	Text   func(w io.Writer) error
+}
+
+// writeResult writes r to w in the format chosen with --output.
+func writeResult(w io.Writer, r result) error {
+	switch outputFormat {
+	case outputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(nonNilData(r.Data))
+
+	case outputJSONL:
+		enc := json.NewEncoder(w)
+		v := reflect.ValueOf(r.Data)
+		if v.Kind() != reflect.Slice {
+			return enc.Encode(r.Data)
+		}
+		for i := range v.Len() {
+			if err := enc.Encode(v.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case outputTable:
+		if r.Header == nil {
+			break
+		}
+		return writeTable(w, r.Header, r.Rows)
+	}
+
+	if r.Text != nil {
+		return r.Text(w)
+	}
+	if r.Rows != nil {
+		return writeTable(w, nil, r.Rows)
+	}
+	// nothing human-readable was given, JSON is the next best thing
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(nonNilData(r.Data))
+}
+
+// printResult writes r to the standard output of cmd.
+func printResult(cmd *cobra.Command, r result) error {
+	return writeResult(cmd.OutOrStdout(), r)
+}
+
+func writeTable(w io.Writer, header []string, rows [][]string) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if header != nil {
+		if _, err := fmt.Fprintln(tw, strings.Join(header, "\t")); err != nil {
+			return err
+		}
+	}
+	for _, row := range rows {
+		if _, err := fmt.Fprintln(tw, strings.Join(row, "\t")); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
+
+// nonNilData turns a nil slice into an empty one, so that an empty list is "[]" rather than "null".
+func nonNilData(data any) any {
+	if v := reflect.ValueOf(data); v.Kind() == reflect.Slice && v.IsNil() {
+		return []any{}
+	}
+	return data
+}
+
+// valuesResult is the result of a command listing plain values such as relay URLs:
+// one per line in text mode and a JSON array of strings.
+func valuesResult(column string, values []string, empty string) result {
+	rows := make([][]string, len(values))
+	for i, v := range values {
+		rows[i] = []string{v}
+	}
+	r := result{Data: values, Header: []string{column}, Rows: rows}
+	if len(values) == 0 && empty != "" {
+		r.Text = func(w io.Writer) error {
+			_, err := fmt.Fprintln(w, empty)
+			return err
+		}
+	}
+	return r
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestWriteResult(t *testing.T) {
+	type item struct {
+		Name  string `json:"name"`
+		Count int    `json:"count"`
+	}
+	r := result{
+		Data:   []item{{"a", 1}, {"bb", 22}},
+		Header: []string{"NAME", "COUNT"},
+		Rows:   [][]string{{"a", "1"}, {"bb", "22"}},
+		Text: func(w io.Writer) error {
+			_, err := fmt.Fprintln(w, "two items")
+			return err
+		},
+	}
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{outputText, "two items\n"},
+		{outputJSON, "[\n  {\n    \"name\": \"a\",\n    \"count\": 1\n  },\n  {\n    \"name\": \"bb\",\n    \"count\": 22\n  }\n]\n"},
+		{outputJSONL, "{\"name\":\"a\",\"count\":1}\n{\"name\":\"bb\",\"count\":22}\n"},
+		{outputTable, "NAME  COUNT\na     1\nbb    22\n"},
+	}
+
+	defer func(format string) { outputFormat = format }(outputFormat)
+	for _, tt := range tests {
+		outputFormat = tt.format
+		var out bytes.Buffer
+		if err := writeResult(&out, r); err != nil {
+			t.Fatalf("%s: writeResult() error = %v", tt.format, err)
+		}
+		if out.String() != tt.want {
+			t.Errorf("%s: writeResult() output = %q, want %q", tt.format, out.String(), tt.want)
+		}
+	}
+}
+
+func TestWriteResult_Fallbacks(t *testing.T) {
+	defer func(format string) { outputFormat = format }(outputFormat)
+
+	// an empty list is still a JSON array
+	outputFormat = outputJSON
+	var out bytes.Buffer
+	if err := writeResult(&out, valuesResult("RELAY", nil, "")); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "[]\n" {
+		t.Errorf("empty list output = %q, want %q", out.String(), "[]\n")
+	}
+
+	// without Text, text mode prints the rows without the header
+	outputFormat = outputText
+	out.Reset()
+	if err := writeResult(&out, valuesResult("RELAY", []string{"wss://a.example", "wss://b.example"}, "")); err != nil {
+		t.Fatal(err)
+	}
+	if want := "wss://a.example\nwss://b.example\n"; out.String() != want {
+		t.Errorf("text output = %q, want %q", out.String(), want)
+	}
+
+	// without a table, table mode falls back to the text form
+	outputFormat = outputTable
+	out.Reset()
+	if err := writeResult(&out, result{Data: 1, Text: func(w io.Writer) error {
+		_, err := fmt.Fprint(w, "one")
+		return err
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "one" {
+		t.Errorf("table fallback output = %q, want %q", out.String(), "one")
+	}
+
+	outputFormat = "yaml"
+	if err := validateOutputFormat(&cobra.Command{}); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+func TestValidateOutputFormat_Unsupported(t *testing.T) {
+	defer func(format string) { outputFormat = format }(outputFormat)
+
+	plain := &cobra.Command{Use: "plain"}
+	formatted := &cobra.Command{Use: "formatted"}
+	supportsOutput(formatted)
+
+	outputFormat = outputText
+	if err := validateOutputFormat(plain); err != nil {
+		t.Errorf("text output must always be accepted, got %v", err)
+	}
+	outputFormat = outputJSON
+	if err := validateOutputFormat(plain); err == nil {
+		t.Error("expected an error for json on a text-only command")
+	}
+	if err := validateOutputFormat(formatted); err != nil {
+		t.Errorf("json output on a formatted command: %v", err)
+	}
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jerry-harm/nosmec/utils"
+	"github.com/spf13/cobra"
+)
+
+func registerPeopleCommands() {
+	peopleCmd := &cobra.Command{
+		Use:   "people",
+		Short: "Find people",
+	}
+
+	peopleSearchCmd := &cobra.Command{
+		Use:   "search <query>",
+		Short: "Search profiles by name, NIP-05 address or alias",
+		Long: `Search profiles in your aliases, the locally stored profiles and on the NIP-50
+user search relays. People you follow are listed first, then the ones in your web of
+trust (followed by someone you follow).`,
+		Example: `  nosmec people search fiatjaf
+  nosmec people search alice --local-only`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			app := getApp()
+			flags := cmd.Flags()
+
+			opts := utils.PeopleSearchOptions{}
+			opts.Limit, _ = flags.GetInt("limit")
+			opts.LocalOnly, _ = flags.GetBool("local-only")
+			opts.SkipWoT, _ = flags.GetBool("no-wot")
+
+			ctx, cancel := context.WithTimeout(context.Background(), app.QueryTimeout())
+			defer cancel()
+
+			found, err := utils.SearchPeople(ctx, app, strings.Join(args, " "), opts)
+			if err != nil {
+				return newError("search failed", err)
+			}
+
+			people := make([]personOutput, len(found))
+			rows := make([][]string, len(found))
+			for i, p := range found {
+				people[i] = personOutput{
+					Npub:        p.Profile.Npub(),
+					PubKey:      p.Profile.PubKey.Hex(),
+					Name:        p.Profile.Name,
+					DisplayName: p.Profile.DisplayName,
+					NIP05:       p.Profile.NIP05,
+					Alias:       p.Alias,
+					Followed:    p.Followed,
+					InWoT:       p.InWoT,
+				}
+				rows[i] = []string{p.Profile.ShortName(), strings.Join(personMarks(p), ", "), p.Profile.NIP05, p.Profile.Npub()}
+			}
+
+			return printResult(cmd, result{
+				Data:   people,
+				Header: []string{"NAME", "MARKS", "NIP-05", "NPUB"},
+				Rows:   rows,
+				Text: func(w io.Writer) error {
+					if len(found) == 0 {
+						fmt.Fprintln(w, "Nobody found.")
+						return nil
+					}
+					for _, row := range rows {
+						fmt.Fprintf(w, "%s", row[0])
+						if row[1] != "" {
+							fmt.Fprintf(w, " [%s]", row[1])
+						}
+						fmt.Fprintf(w, "\n    %s\n", row[3])
+						if row[2] != "" {
+							fmt.Fprintf(w, "    %s\n", row[2])
+						}
+					}
+					return nil
+				},
+			})
+		},
+	}
+	peopleSearchCmd.Flags().IntP("limit", "n", 20, "Maximum number of results")
+	peopleSearchCmd.Flags().Bool("local-only", false, "Only search aliases and stored profiles")
+	peopleSearchCmd.Flags().Bool("no-wot", false, "Skip loading the web of trust (faster, follows still rank first)")
+
+	peopleCmd.AddCommand(peopleSearchCmd)
+
+	supportsOutput(peopleSearchCmd)
+	RegisterCommandGroup("People", "Profile search", peopleCmd)
+}
+
+// personOutput is a person as printed by "people search".
+type personOutput struct {
+	Npub        string `json:"npub"`
+	PubKey      string `json:"pubkey"`
+	Name        string `json:"name,omitempty"`
+	DisplayName string `json:"display_name,omitempty"`
+	NIP05       string `json:"nip05,omitempty"`
+	Alias       string `json:"alias,omitempty"`
+	Followed    bool   `json:"followed"`
+	InWoT       bool   `json:"in_wot"`
+}
+
+func personMarks(p utils.Person) []string {
+	var marks []string
+	if p.Alias != "" {
+		marks = append(marks, "alias "+p.Alias)
+	}
+	switch {
+	case p.Followed:
+		marks = append(marks, "following")
+	case p.InWoT:
+		marks = append(marks, "web of trust")
+	}
+	return marks
+}
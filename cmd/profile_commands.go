@@ -2,7 +2,9 @@ package cmd
 
 import (
 	"context"
-	"os"
+	"fmt"
+	"io"
+	"strconv"
 
 	"fiatjaf.com/nostr"
 	"github.com/jerry-harm/nosmec/cmd/completion"
@@ -37,29 +39,74 @@ func registerProfileCommands() {
 
 			full, _ := cmd.Flags().GetBool("full")
 
-		if full {
-			fp, err := utils.GetFullProfile(ctx, pubKey, app)
-			if err != nil {
-				handleError(newError("failed to get full profile", err))
+			if full {
+				fp, err := utils.GetFullProfile(ctx, pubKey, app)
+				if err != nil {
+					handleError(newError("failed to get full profile", err))
+				}
+				handleError(printResult(cmd, profileResult(fp, func(w io.Writer) error {
+					data, err := utils.SerializeProfile(fp)
+					if err != nil {
+						return newError("failed to serialize profile", err)
+					}
+					_, err = fmt.Fprintf(w, "%s\n", data)
+					return err
+				})))
+				return
 			}
 
-			data, err := utils.SerializeProfile(fp)
-			if err != nil {
-				handleError(newError("failed to serialize profile", err))
-			}
-			os.Stdout.Write(data)
-			os.Stdout.Write([]byte("\n"))
-		} else {
 			pm := app.System().FetchProfileMetadata(ctx, pubKey)
 			if pm.Event == nil {
 				handleError(newError("profile not found", nil))
 			}
-			utils.PrintEvent(pm.Event, false)
-		}
+			fp := &utils.FullProfile{
+				NPub:      utils.PubKeyToNpub(pubKey),
+				PubKey:    pubKey.Hex(),
+				Metadata:  &pm,
+				Followers: utils.CountFollowers(ctx, app, pubKey),
+			}
+			handleError(printResult(cmd, profileResult(fp, func(w io.Writer) error {
+				utils.FprintEvent(w, pm.Event, false)
+				if fp.Followers != nil {
+					fmt.Fprintf(w, "Followers: %s\n", fp.Followers)
+				}
+				return nil
+			})))
 		},
 	}
 
-	profileCmd.Flags().Bool("full", false, "Show full profile including relays, dm_relays, and follows")
+	profileCmd.Flags().Bool("full", false, "Show full profile including relays, dm_relays, follows and follower count")
 
+	supportsOutput(profileCmd)
 	RegisterCommandGroup("Profile", "Profile operations", profileCmd)
-}
\ No newline at end of file
+}
+
+// profileResult shows fp as a table of fields, text writes the usual form.
+func profileResult(fp *utils.FullProfile, text func(w io.Writer) error) result {
+	rows := [][]string{{"npub", fp.NPub}}
+	if m := fp.Metadata; m != nil {
+		for _, field := range [][]string{
+			{"name", m.Name},
+			{"display_name", m.DisplayName},
+			{"about", m.About},
+			{"website", m.Website},
+			{"picture", m.Picture},
+			{"nip05", m.NIP05},
+			{"lud16", m.LUD16},
+		} {
+			if field[1] != "" {
+				rows = append(rows, field)
+			}
+		}
+	}
+	for _, r := range fp.Relays {
+		rows = append(rows, []string{"relay", r.URL})
+	}
+	if len(fp.Follows) > 0 {
+		rows = append(rows, []string{"follows", strconv.Itoa(len(fp.Follows))})
+	}
+	if fp.Followers != nil {
+		rows = append(rows, []string{"followers", fp.Followers.String()})
+	}
+	return result{Data: fp, Header: []string{"FIELD", "VALUE"}, Rows: rows, Text: text}
+}
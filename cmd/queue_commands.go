@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"fiatjaf.com/nostr"
+	"fiatjaf.com/nostr/nip19"
+	"github.com/jerry-harm/nosmec/logger"
+	"github.com/jerry-harm/nosmec/nostr_sdk"
+	"github.com/jerry-harm/nosmec/utils"
+	"github.com/spf13/cobra"
+)
+
+func registerQueueCommands() {
+	queueCmd := &cobra.Command{
+		Use:     "queue",
+		Aliases: []string{"outbox"},
+		Short:   "Inspect and flush the outgoing event queue",
+	}
+
+	queueListCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List events waiting to be published",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			app := getApp()
+			if app == nil {
+				return newError("app not initialized", nil)
+			}
+
+			pending, err := app.System().ListPendingPublishes()
+			if err != nil {
+				return newError("failed to list queued events", err)
+			}
+			return writePendingPublishes(cmd.OutOrStdout(), pending)
+		},
+	}
+
+	queueFlushCmd := &cobra.Command{
+		Use:     "flush",
+		Aliases: []string{"retry"},
+		Short:   "Publish queued events to the relays they are still pending on",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			app := getApp()
+			if app == nil {
+				return newError("app not initialized", nil)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), app.QueryTimeout())
+			defer cancel()
+
+			reports, err := app.System().FlushPendingPublishes(ctx)
+			if err != nil {
+				return newError("failed to flush queue", err)
+			}
+			if len(reports) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "Nothing to flush.")
+				return nil
+			}
+
+			for _, report := range reports {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s\n", nip19.EncodeNevent(report.Event.ID, nil, report.Event.PubKey))
+				fmt.Fprint(cmd.OutOrStdout(), utils.FormatPublishReport(report))
+			}
+			return nil
+		},
+	}
+
+	queueDropCmd := &cobra.Command{
+		Use:   "drop [event-id...]",
+		Short: "Remove events from the queue without publishing them",
+		Args:  cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			app := getApp()
+			if app == nil {
+				return newError("app not initialized", nil)
+			}
+
+			all, _ := cmd.Flags().GetBool("all")
+			if !all && len(args) == 0 {
+				return newError("pass event ids or --all", nil)
+			}
+			ids := make([]nostr.ID, 0, len(args))
+			if all {
+				pending, err := app.System().ListPendingPublishes()
+				if err != nil {
+					return newError("failed to list queued events", err)
+				}
+				for _, p := range pending {
+					ids = append(ids, p.Event.ID)
+				}
+			} else {
+				for _, arg := range args {
+					id, err := utils.ParseEventID(arg)
+					if err != nil {
+						return newError("invalid event id "+arg, err)
+					}
+					ids = append(ids, id)
+				}
+			}
+
+			for _, id := range ids {
+				if err := app.System().RemovePendingPublish(id); err != nil {
+					return newError("failed to drop "+id.Hex(), err)
+				}
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Dropped %d queued events\n", len(ids))
+			return nil
+		},
+	}
+	queueDropCmd.Flags().Bool("all", false, "Drop every queued event")
+
+	queueCmd.AddCommand(queueListCmd)
+	queueCmd.AddCommand(queueFlushCmd)
+	queueCmd.AddCommand(queueDropCmd)
+
+	supportsOutput(queueListCmd)
+	RegisterCommandGroup("Queue", "Outgoing event queue", queueCmd)
+}
+
+func writePendingPublishes(w io.Writer, pending []nostr_sdk.PendingPublish) error {
+	rows := make([][]string, len(pending))
+	for i, p := range pending {
+		rows[i] = []string{
+			nip19.EncodeNevent(p.Event.ID, nil, p.Event.PubKey),
+			strconv.Itoa(int(p.Event.Kind)),
+			formatTime(p.QueuedAt),
+			strconv.Itoa(p.Attempts),
+			strings.Join(p.Relays, " "),
+		}
+	}
+	return writeResult(w, result{
+		Data:   pending,
+		Header: []string{"EVENT", "KIND", "QUEUED", "ATTEMPTS", "RELAYS"},
+		Rows:   rows,
+		Text: func(w io.Writer) error {
+			return writePendingPublishesText(w, pending)
+		},
+	})
+}
+
+func writePendingPublishesText(w io.Writer, pending []nostr_sdk.PendingPublish) error {
+	if len(pending) == 0 {
+		_, err := fmt.Fprintln(w, "Outbox is empty.")
+		return err
+	}
+
+	for _, p := range pending {
+		if _, err := fmt.Fprintf(w, "%s  kind %d  queued %s  attempts %d\n",
+			nip19.EncodeNevent(p.Event.ID, nil, p.Event.PubKey), p.Event.Kind, formatTime(p.QueuedAt), p.Attempts); err != nil {
+			return err
+		}
+		for _, relay := range p.Relays {
+			if _, err := fmt.Fprintf(w, "    %s\n", relay); err != nil {
+				return err
+			}
+		}
+		if p.LastError != "" {
+			if _, err := fmt.Fprintf(w, "    last error: %s\n", p.LastError); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// flushPendingPublishes quietly sends whatever is left in the outgoing queue.
+// It is skipped for shell completion, help and the queue commands themselves.
+func flushPendingPublishes(cmd *cobra.Command) {
+	if app == nil || !app.Config().Outbox.AutoRetry {
+		return
+	}
+	switch cmd.Name() {
+	case cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd, "completion", "help":
+		return
+	}
+	if cmd.Parent() != nil && cmd.Parent().Name() == "queue" {
+		return
+	}
+
+	pending, err := app.System().ListPendingPublishes()
+	if err != nil || len(pending) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), app.QueryTimeout())
+	defer cancel()
+
+	reports, err := app.System().FlushPendingPublishes(ctx)
+	if err != nil {
+		logger.Warn("automatic queue flush failed", "error", err.Error())
+		return
+	}
+	for _, report := range reports {
+		logger.Info("flushed queued event", "id", report.Event.ID.Hex(), "accepted", len(report.Accepted()), "failed", len(report.Failed()))
+	}
+}
@@ -30,6 +30,14 @@ func registerDefaultCommands() {
 	registerSearchCommands()
 	registerGossipCommands()
 	registerRelayCommands()
+	registerHintsCommands()
+	registerQueueCommands()
+	registerStoreCommands()
+	registerBackupCommands()
+	registerSyncCommands()
+	registerBackfillCommands()
+	registerPeopleCommands()
+	registerReqCommands()
 }
 
 type commandGroup struct {
@@ -1,8 +1,12 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"net"
+	"net/http"
+	"strconv"
 
 	"github.com/jerry-harm/nosmec/config"
 	"github.com/spf13/cobra"
@@ -31,7 +35,55 @@ func registerRelayCommands() {
 		},
 	}
 
+	relayServeCmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve the local event store as a relay",
+		Long: `Serve the local event store as a NIP-01 websocket relay, so other clients and
+scripts on this machine can read the cached events. REQ, COUNT and NIP-50 search
+(through the search index) are open to anyone who can connect; EVENT only accepts
+events signed with our own key.
+
+Listens on 127.0.0.1:7777 by default. Stop with Ctrl+C.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			app := getApp()
+			listen, _ := cmd.Flags().GetString("listen")
+
+			host, portStr, err := net.SplitHostPort(listen)
+			if err != nil {
+				return newError("invalid --listen address", err)
+			}
+			port, err := strconv.Atoi(portStr)
+			if err != nil {
+				return newError("invalid --listen port", err)
+			}
+
+			me, err := app.GetMyPubKey()
+			if err != nil {
+				return newError("failed to get public key", err)
+			}
+
+			if !app.System().HasLocalStore() {
+				return newError("no local event store to serve; check data_dir", nil)
+			}
+
+			relay := app.System().NewLocalRelay(me)
+			started := make(chan bool)
+			go func() {
+				<-started
+				fmt.Fprintf(cmd.OutOrStdout(), "Serving the local store at ws://%s\n", listen)
+			}()
+			if err := relay.Start(host, port, started); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				return newError("relay stopped", err)
+			}
+			return nil
+		},
+	}
+	relayServeCmd.Flags().String("listen", "127.0.0.1:7777", "Address to listen on (host:port)")
+
 	relayCmd.AddCommand(relayListCmd)
+	relayCmd.AddCommand(relayServeCmd)
+	supportsOutput(relayListCmd)
 	RegisterCommandGroup("Relay", "Relay operations", relayCmd)
 }
 
@@ -46,11 +98,5 @@ func writeRelayList(w io.Writer, app *config.AppContext) error {
 		return err
 	}
 
-	for _, relay := range relays {
-		if _, err := fmt.Fprintln(w, relay); err != nil {
-			return err
-		}
-	}
-
-	return nil
+	return writeResult(w, valuesResult("RELAY", relays, ""))
 }
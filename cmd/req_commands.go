@@ -0,0 +1,220 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"fiatjaf.com/nostr"
+	"github.com/jerry-harm/nosmec/config"
+	"github.com/jerry-harm/nosmec/logger"
+	"github.com/jerry-harm/nosmec/utils"
+	"github.com/spf13/cobra"
+)
+
+func registerReqCommands() {
+	reqCmd, countCmd := newReqCmd(), newCountCmd()
+	supportsOutput(reqCmd, countCmd)
+	RegisterCommandGroup("Req", "Raw filter queries", reqCmd, countCmd)
+}
+
+func newReqCmd() *cobra.Command {
+	reqCmd := &cobra.Command{
+		Use:   "req [filter]",
+		Short: "Run a NIP-01 filter and print the events as JSON lines",
+		Long: `Send a REQ with the given filter and print every matching event as NIP-01 JSON,
+one per line, as relays return them. That is what --output text, json and jsonl all
+give; table is refused. The filter is a JSON object ("-" reads it from stdin); the
+flags are added on top of it.
+
+Without --relay the relays are chosen from the filter: the outbox relays of a single
+author, the ID relays for event IDs, otherwise the fallback relays.
+
+The command exits once every relay has sent all stored events, or with --stream keeps
+the subscription open and prints new events as they arrive until interrupted.
+Times can be unix timestamps, dates (2024-05-01) or durations ago (7d).`,
+		Example: `  nosmec req -k 1 -a alice --since 1d
+  nosmec req '{"kinds":[30023],"#t":["nostr"]}' --limit 20 | jq .content
+  nosmec req -k 1 -t t=nostr --stream --relay wss://relay.damus.io`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if outputFormat == outputTable {
+				return newError("req prints JSON lines, --output table is not supported", nil)
+			}
+			app := getApp()
+			flags := cmd.Flags()
+
+			filter, err := reqFilter(app, cmd, args)
+			if err != nil {
+				return err
+			}
+
+			stream, _ := flags.GetBool("stream")
+			ctx := context.Background()
+			if !stream {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, app.QueryTimeout())
+				defer cancel()
+			}
+
+			relays, _ := flags.GetStringSlice("relay")
+			if len(relays) == 0 {
+				relays = app.System().RelaysForFilter(ctx, filter)
+			}
+			if len(relays) == 0 {
+				return newError("no relays to query", nil)
+			}
+			logger.Debug("req", "filter", filter, "relays", relays)
+
+			opts := nostr.SubscriptionOptions{Label: "req"}
+			var events chan nostr.RelayEvent
+			if stream {
+				events = app.Pool().SubscribeMany(ctx, relays, filter, opts)
+			} else {
+				events = app.Pool().FetchMany(ctx, relays, filter, opts)
+			}
+
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			seen := make(map[nostr.ID]struct{})
+			for re := range events {
+				if _, ok := seen[re.Event.ID]; ok {
+					continue
+				}
+				seen[re.Event.ID] = struct{}{}
+				if err := enc.Encode(re.Event); err != nil {
+					// the reader went away, e.g. "| head"
+					return nil
+				}
+				// every relay applies the limit, the stored events as a whole should too
+				if !stream && filter.Limit > 0 && len(seen) >= filter.Limit {
+					break
+				}
+			}
+			return nil
+		},
+	}
+	addReqFilterFlags(reqCmd)
+	reqCmd.Flags().IntP("limit", "n", 0, "Maximum number of stored events")
+	reqCmd.Flags().Bool("stream", false, "Keep the subscription open and print new events as they arrive")
+
+	return reqCmd
+}
+
+func newCountCmd() *cobra.Command {
+	countCmd := &cobra.Command{
+		Use:   "count [filter]",
+		Short: "Count the events matching a NIP-01 filter (NIP-45)",
+		Long: `Send a NIP-45 COUNT with the given filter and print how many events match. The
+filter is given as for req.
+
+Relays answering with HyperLogLog registers are merged into one estimate, shown with
+a "~", so that events stored by several relays are counted once. Otherwise the largest
+count any relay gave is printed. Without --relay the relays known to support COUNT
+are asked along with the ones chosen from the filter.`,
+		Example: `  nosmec count -k 3 -t p=<hex pubkey>
+  nosmec count '{"kinds":[7],"#e":["<event id>"]}' --verbose`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			app := getApp()
+			flags := cmd.Flags()
+
+			filter, err := reqFilter(app, cmd, args)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), app.QueryTimeout())
+			defer cancel()
+
+			relays, _ := flags.GetStringSlice("relay")
+			if len(relays) == 0 {
+				relays = app.System().CountRelaysForFilter(ctx, filter)
+			}
+			logger.Debug("count", "filter", filter, "relays", relays)
+
+			counts := app.System().Count(ctx, relays, filter)
+			if counts.Answered() == 0 {
+				return newError("no relay answered the COUNT request", nil)
+			}
+
+			rows := make([][]string, len(counts.Relays))
+			for i, rc := range counts.Relays {
+				rows[i] = []string{rc.Relay, strconv.FormatUint(uint64(rc.Count), 10), strconv.FormatBool(rc.HLL), rc.Error}
+			}
+			verbose, _ := flags.GetBool("verbose")
+			return printResult(cmd, result{
+				Data:   counts,
+				Header: []string{"RELAY", "COUNT", "HLL", "ERROR"},
+				Rows:   rows,
+				Text: func(w io.Writer) error {
+					fmt.Fprintln(w, counts)
+					if !verbose {
+						return nil
+					}
+					for _, rc := range counts.Relays {
+						switch {
+						case rc.Error != "":
+							fmt.Fprintf(w, "  %s: %s\n", rc.Relay, rc.Error)
+						case rc.HLL:
+							fmt.Fprintf(w, "  %s: %d (hll)\n", rc.Relay, rc.Count)
+						default:
+							fmt.Fprintf(w, "  %s: %d\n", rc.Relay, rc.Count)
+						}
+					}
+					return nil
+				},
+			})
+		},
+	}
+	addReqFilterFlags(countCmd)
+	countCmd.Flags().BoolP("verbose", "v", false, "Also print what each relay answered")
+
+	return countCmd
+}
+
+// addReqFilterFlags adds the filter and relay flags shared by req and count.
+func addReqFilterFlags(cmd *cobra.Command) {
+	cmd.Flags().IntSliceP("kinds", "k", nil, "Event kinds (e.g., -k 1,6)")
+	cmd.Flags().StringSliceP("authors", "a", nil, "Authors (npub, hex or alias)")
+	cmd.Flags().StringSliceP("ids", "e", nil, "Event IDs (hex, note or nevent)")
+	cmd.Flags().StringArrayP("tag", "t", nil, "Tag filter as name=value, e.g. -t t=nostr (repeatable)")
+	cmd.Flags().String("since", "", "Only events created at or after this time")
+	cmd.Flags().String("until", "", "Only events created at or before this time")
+	cmd.Flags().StringSliceP("relay", "r", nil, "Relays to query instead of the ones chosen from the filter")
+}
+
+// reqFilter builds the filter of req and count from the JSON argument and flags.
+func reqFilter(app *config.AppContext, cmd *cobra.Command, args []string) (nostr.Filter, error) {
+	var filter nostr.Filter
+	if len(args) == 1 {
+		raw := []byte(args[0])
+		if args[0] == "-" {
+			var err error
+			if raw, err = io.ReadAll(cmd.InOrStdin()); err != nil {
+				return filter, newError("failed to read the filter", err)
+			}
+		}
+		if err := json.Unmarshal(raw, &filter); err != nil {
+			return filter, newError("invalid filter", err)
+		}
+	}
+
+	filter, err := addFilterFlags(app, cmd, filter)
+	if err != nil {
+		return filter, err
+	}
+	ids, _ := cmd.Flags().GetStringSlice("ids")
+	for _, s := range ids {
+		id, err := utils.ParseEventID(s)
+		if err != nil {
+			return filter, newError("invalid event ID "+s, err)
+		}
+		filter.IDs = append(filter.IDs, id)
+	}
+	if limit, _ := cmd.Flags().GetInt("limit"); limit > 0 {
+		filter.Limit = limit
+	}
+	return filter, nil
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"fiatjaf.com/nostr"
+	"github.com/jerry-harm/nosmec/config"
+	"github.com/spf13/viper"
+)
+
+func TestReqFilter(t *testing.T) {
+	app := config.NewAppContext(nil, config.Config{DataDir: t.TempDir()}, viper.New())
+	defer app.Close()
+
+	pk := nostr.GetPublicKey(nostr.Generate())
+	id := strings.Repeat("ab", 32)
+
+	cmd := newReqCmd()
+	for flag, value := range map[string]string{
+		"kinds":   "1,6",
+		"authors": pk.Hex(),
+		"ids":     id,
+		"tag":     "t=nostr",
+		"since":   "1700000000",
+		"limit":   "5",
+	} {
+		if err := cmd.Flags().Set(flag, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	filter, err := reqFilter(app, cmd, []string{`{"kinds":[30023],"#t":["bitcoin"]}`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(filter.Kinds, []nostr.Kind{30023, 1, 6}) {
+		t.Errorf("kinds = %v", filter.Kinds)
+	}
+	if len(filter.Authors) != 1 || filter.Authors[0] != pk {
+		t.Errorf("authors = %v", filter.Authors)
+	}
+	if len(filter.IDs) != 1 || filter.IDs[0].Hex() != id {
+		t.Errorf("ids = %v", filter.IDs)
+	}
+	if !slices.Equal(filter.Tags["t"], []string{"bitcoin", "nostr"}) {
+		t.Errorf("tags = %v", filter.Tags)
+	}
+	if filter.Since != 1700000000 || filter.Limit != 5 {
+		t.Errorf("since = %d, limit = %d", filter.Since, filter.Limit)
+	}
+
+	if _, err := reqFilter(app, newReqCmd(), []string{`{"kinds":`}); err == nil {
+		t.Error("expected an error for invalid JSON")
+	}
+}
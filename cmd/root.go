@@ -23,6 +23,9 @@ var rootCmd = &cobra.Command{
 		if debug {
 			logger.SetDebug(true)
 		}
+		handleError(validateOutputFormat(cmd))
+		flushPendingPublishes(cmd)
+		collectGarbage(cmd)
 	},
 }
 
@@ -51,6 +54,8 @@ func init() {
 	initCommands()
 
 	rootCmd.PersistentFlags().BoolVarP(&debug, "debug", "d", false, "Enable debug file output")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", outputText, "Output format: text, json, jsonl or table")
+	rootCmd.RegisterFlagCompletionFunc("output", outputFormatCompletion)
 
 	setupHTTPTransport()
 }
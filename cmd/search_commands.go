@@ -3,12 +3,15 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"io"
 	"sort"
+	"strconv"
 	"strings"
 
 	"fiatjaf.com/nostr"
 	"fiatjaf.com/nostr/nip19"
 	sdk "github.com/jerry-harm/nosmec/nostr_sdk"
+	searchtui "github.com/jerry-harm/nosmec/tui/search"
 	"github.com/jerry-harm/nosmec/utils"
 	"github.com/spf13/cobra"
 )
@@ -17,89 +20,255 @@ func registerSearchCommands() {
 	searchCmd := &cobra.Command{
 		Use:   "search",
 		Short: "Search events (NIP-50)",
-		Long: `Search for events using NIP-50 full-text search.
+		Long: `Search for events using NIP-50 full-text search. The local search index is
+queried together with the search relays, and --local-only works offline.
+
+Without a query, or with --tui, an interactive view opens instead: results show
+up as relays answer, enter opens a result, / starts a new search and m loads
+older results. The view is text only, so --output needs a query and no --tui.
 
 Examples:
+  nosmec search
+  nosmec search "nostr apps" --tui
   nosmec search "nostr apps"
   nosmec search "bitcoin" --kinds 1
   nosmec search "nostr" --limit 20
+  nosmec search "nostr" --local-only
+  nosmec search save daily 'nostr kinds:1 since:1d'
+  nosmec search run daily
 
-NIP-50 filter syntax:
-  kinds:1,3       Filter by event kinds
-  authors:npub1.. Filter by author npub or hex
-  #t:hashtag     Filter by hashtag`,
-		Args: cobra.MinimumNArgs(1),
+Query syntax:
+  kinds:1,3            Filter by event kinds
+  authors:alice,bob    Filter by npub, nprofile, hex, alias or NIP-05 address
+  since:7d until:2024-05-01
+                       Time bounds (unix time, date, RFC 3339 or a duration ago)
+  #t:nostr #p:<hex>    Filter by any single-letter tag
+  "exact phrase"       Search a phrase
+  -term                Drop results whose content contains term
+  bitcoin OR lightning Search alternatives; filters apply to all of them`,
+		Args: cobra.ArbitraryArgs,
 		Run: func(cmd *cobra.Command, args []string) {
-			query := args[0]
-
-			kinds, _ := cmd.Flags().GetIntSlice("kinds")
-			limit, _ := cmd.Flags().GetInt("limit")
+			runSearch(cmd, strings.Join(args, " "))
+		},
+	}
 
-			app := getApp()
-			ctx, cancel := context.WithTimeout(context.Background(), app.QueryTimeout())
-			defer cancel()
+	searchSaveCmd := &cobra.Command{
+		Use:   "save <name> <query>",
+		Short: "Save a search query under a name",
+		Long: `Save a search query to run it again with "search run <name>" or follow it live
+with "note timeline --search <name>". Saving under an existing name replaces its query.`,
+		Example: `  nosmec search save lightning 'lightning OR lnurl kinds:1 -scam'`,
+		Args:    cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name, query := args[0], strings.Join(args[1:], " ")
+			// catch syntax errors now rather than on every run
+			if _, err := utils.ParseSearchQuery(query); err != nil {
+				return newError("invalid query", err)
+			}
+			if err := getApp().SaveSearch(name, query); err != nil {
+				return newError("failed to save search", err)
+			}
+			fmt.Printf("Saved search %s: %s\n", name, query)
+			return nil
+		},
+	}
 
-			results, err := utils.SearchEvents(ctx, app, query, limit)
-			if err != nil {
-				handleError(err)
-				return
+	searchRunCmd := &cobra.Command{
+		Use:   "run <name>",
+		Short: "Run a saved search",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			query, ok := getApp().GetSavedSearch(args[0])
+			if !ok {
+				return newError("saved search not found: "+args[0], nil)
 			}
+			runSearch(cmd, query)
+			return nil
+		},
+	}
 
-			if len(results) == 0 {
-				fmt.Println("No results found.")
-				return
+	searchListCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List saved searches",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			app := getApp()
+			searches := app.ListSavedSearches()
+			names := make([]string, 0, len(searches))
+			for name := range searches {
+				names = append(names, name)
 			}
+			sort.Strings(names)
 
-			// Apply kinds filter if specified (NIP-50 relays may not support client-side filtering)
-			if len(kinds) > 0 {
-				filtered := make([]utils.SearchResult, 0)
-				for _, r := range results {
-					for _, fk := range kinds {
-						if int(r.Event.Kind) == fk {
-							filtered = append(filtered, r)
-							break
-						}
-					}
+			type savedSearch struct {
+				Name     string          `json:"name"`
+				Query    string          `json:"query"`
+				LastSeen nostr.Timestamp `json:"last_seen,omitempty"`
+			}
+			saved := make([]savedSearch, len(names))
+			rows := make([][]string, len(names))
+			for i, name := range names {
+				saved[i] = savedSearch{Name: name, Query: searches[name], LastSeen: app.System().SavedSearchLastSeen(name)}
+				lastSeen := ""
+				if saved[i].LastSeen != 0 {
+					lastSeen = formatSearchTime(saved[i].LastSeen)
 				}
-				results = filtered
+				rows[i] = []string{name, searches[name], lastSeen}
 			}
 
-			// Sort by created_at descending (relay may have already sorted by relevance)
-			sort.Slice(results, func(i, j int) bool {
-				return results[i].Event.CreatedAt > results[j].Event.CreatedAt
+			return printResult(cmd, result{
+				Data:   saved,
+				Header: []string{"NAME", "QUERY", "LAST SEEN"},
+				Rows:   rows,
+				Text: func(w io.Writer) error {
+					if len(saved) == 0 {
+						fmt.Fprintln(w, "No saved searches.")
+						return nil
+					}
+					for _, row := range rows {
+						fmt.Fprintf(w, "%s: %s\n", row[0], row[1])
+						if row[2] != "" {
+							fmt.Fprintf(w, "    last seen: %s\n", row[2])
+						}
+					}
+					return nil
+				},
 			})
+		},
+	}
 
-			fmt.Printf("Found %d result(s):\n\n", len(results))
-			for i, r := range results {
-				printSearchResult(r, i)
+	searchRemoveCmd := &cobra.Command{
+		Use:   "remove <name>",
+		Short: "Delete a saved search",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			app := getApp()
+			if err := app.RemoveSavedSearch(args[0]); err != nil {
+				return newError("failed to remove saved search", err)
+			}
+			if err := app.System().ForgetSavedSearch(args[0]); err != nil {
+				return newError("failed to clear last seen state", err)
 			}
+			fmt.Printf("Removed saved search %s\n", args[0])
+			return nil
 		},
 	}
 
-	searchCmd.Flags().IntSlice("kinds", nil, "Filter by event kinds (e.g., --kinds 1,3)")
-	searchCmd.Flags().IntP("limit", "n", 50, "Maximum number of results")
+	searchCmd.AddCommand(searchSaveCmd, searchRunCmd, searchListCmd, searchRemoveCmd)
+
+	for _, c := range []*cobra.Command{searchCmd, searchRunCmd} {
+		c.Flags().IntSlice("kinds", nil, "Filter by event kinds (e.g., --kinds 1,3)")
+		c.Flags().IntP("limit", "n", 50, "Maximum number of results")
+		c.Flags().Bool("local-only", false, "Only search the local index, without contacting relays")
+		c.Flags().Bool("tui", false, "Open the interactive search view")
+	}
 
+	supportsOutput(searchCmd, searchRunCmd, searchListCmd)
 	RegisterCommandGroup("Search", "Search operations", searchCmd)
 }
 
-func printSearchResult(r utils.SearchResult, index int) {
-	e := r.Event
+// runSearch runs query with the flags of cmd, in the interactive view if asked for
+// or if there is no query yet.
+func runSearch(cmd *cobra.Command, query string) {
+	kinds, _ := cmd.Flags().GetIntSlice("kinds")
+	limit, _ := cmd.Flags().GetInt("limit")
+	localOnly, _ := cmd.Flags().GetBool("local-only")
+	tui, _ := cmd.Flags().GetBool("tui")
 
-	// Get author name
-	authorName := nip19.EncodeNpub(e.PubKey)[:16] + "..."
+	app := getApp()
+	if tui || query == "" {
+		// the interactive view cannot be rendered as anything but itself
+		if outputFormat != outputText {
+			if query == "" {
+				handleError(newError("a query is required with --output", nil))
+			}
+			handleError(newError("--tui cannot be combined with --output", nil))
+		}
+		if err := searchtui.RunSearch(app, query, localOnly); err != nil {
+			handleError(err)
+		}
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), app.QueryTimeout())
+	defer cancel()
 
-	// Try to get profile name
-	pm := getApp().System().FetchProfileMetadata(context.Background(), e.PubKey)
+	results, err := utils.SearchEvents(ctx, app, query, utils.SearchOptions{Limit: limit, LocalOnly: localOnly})
+	if err != nil {
+		handleError(err)
+		return
+	}
+
+	// Apply kinds filter if specified (NIP-50 relays may not support client-side filtering)
+	if len(kinds) > 0 {
+		filtered := make([]utils.SearchResult, 0)
+		for _, r := range results {
+			for _, fk := range kinds {
+				if int(r.Event.Kind) == fk {
+					filtered = append(filtered, r)
+					break
+				}
+			}
+		}
+		results = filtered
+	}
+
+	// Sort by created_at descending (relay may have already sorted by relevance)
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Event.CreatedAt > results[j].Event.CreatedAt
+	})
+
+	r := result{Data: results, Header: []string{"TIME", "AUTHOR", "KIND", "ID", "CONTENT"}, Text: func(w io.Writer) error {
+		if len(results) == 0 {
+			fmt.Fprintln(w, "No results found.")
+			return nil
+		}
+		fmt.Fprintf(w, "Found %d result(s):\n\n", len(results))
+		for i, r := range results {
+			printSearchResult(w, r, i)
+		}
+		return nil
+	}}
+	if outputFormat == outputTable {
+		for _, res := range results {
+			r.Rows = append(r.Rows, []string{
+				formatSearchTime(res.Event.CreatedAt),
+				searchAuthorName(res.Event.PubKey),
+				strconv.Itoa(int(res.Event.Kind)),
+				searchNevent(res),
+				searchTruncate(strings.Join(strings.Fields(res.Event.Content), " "), 60),
+			})
+		}
+	}
+	handleError(printResult(cmd, r))
+}
+
+// searchAuthorName returns the profile name of pubkey, or a shortened npub.
+func searchAuthorName(pubkey nostr.PubKey) string {
+	pm := getApp().System().FetchProfileMetadata(context.Background(), pubkey)
 	if pm.Event != nil {
 		if meta, err := sdk.ParseMetadata(*pm.Event); err == nil && meta.Name != "" {
-			authorName = meta.Name
+			return meta.Name
 		}
 	}
+	return nip19.EncodeNpub(pubkey)[:16] + "..."
+}
+
+func searchNevent(r utils.SearchResult) string {
+	var relayHints []string
+	if r.Relay != "" {
+		relayHints = []string{r.Relay}
+	}
+	return nip19.EncodeNevent(r.Event.ID, relayHints, r.Event.PubKey)
+}
+
+func printSearchResult(w io.Writer, r utils.SearchResult, index int) {
+	e := r.Event
 
-	fmt.Printf("[%d] %s @%s\n", index+1, formatSearchTime(e.CreatedAt), authorName)
-	fmt.Printf("    ID: %s\n", nip19.EncodeNevent(e.ID, []string{r.Relay}, e.PubKey))
-	fmt.Printf("    Relay: %s\n", r.Relay)
-	fmt.Printf("    Kind: %d\n", e.Kind)
+	fmt.Fprintf(w, "[%d] %s @%s\n", index+1, formatSearchTime(e.CreatedAt), searchAuthorName(e.PubKey))
+	fmt.Fprintf(w, "    ID: %s\n", searchNevent(r))
+	fmt.Fprintf(w, "    Source: %s\n", strings.Join(r.Sources, ", "))
+	fmt.Fprintf(w, "    Kind: %d\n", e.Kind)
 
 	// Print tags summary
 	if len(e.Tags) > 0 {
@@ -112,7 +281,7 @@ func printSearchResult(r utils.SearchResult, index int) {
 			}
 		}
 		if len(tagSummary) > 0 {
-			fmt.Printf("    Tags: %s\n", searchTruncate(strings.Join(tagSummary, ", "), 80))
+			fmt.Fprintf(w, "    Tags: %s\n", searchTruncate(strings.Join(tagSummary, ", "), 80))
 		}
 	}
 
@@ -122,10 +291,10 @@ func printSearchResult(r utils.SearchResult, index int) {
 		content = content[:200] + "..."
 	}
 	if content != "" {
-		fmt.Printf("    %s\n", content)
+		fmt.Fprintf(w, "    %s\n", content)
 	}
 
-	fmt.Println()
+	fmt.Fprintln(w)
 }
 
 func formatSearchTime(t nostr.Timestamp) string {
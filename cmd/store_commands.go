@@ -0,0 +1,561 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"fiatjaf.com/nostr"
+
+	"github.com/jerry-harm/nosmec/cmd/completion"
+	"github.com/jerry-harm/nosmec/config"
+	"github.com/jerry-harm/nosmec/logger"
+	"github.com/jerry-harm/nosmec/nostr_sdk"
+	"github.com/jerry-harm/nosmec/utils"
+	"github.com/spf13/cobra"
+)
+
+func registerStoreCommands() {
+	storeCmd := &cobra.Command{
+		Use:   "store",
+		Short: "Inspect and maintain the local event store",
+	}
+
+	storeGCCmd := &cobra.Command{
+		Use:   "gc",
+		Short: "Evict least recently accessed events from the local store",
+		Long: `Evict events from the local store and search index, oldest access first.
+
+Defaults come from the storage.gc config section. An event that was never
+accessed counts as accessed when it was created.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			app := getApp()
+			cfg := app.Config().Storage.GC
+
+			flags := cmd.Flags()
+			if flags.Changed("max-age") {
+				cfg.MaxAge, _ = flags.GetString("max-age")
+			}
+			if flags.Changed("max-size") {
+				cfg.MaxSize, _ = flags.GetString("max-size")
+			}
+			if flags.Changed("keep-own") {
+				cfg.KeepOwn, _ = flags.GetBool("keep-own")
+			}
+			if flags.Changed("keep-follows") {
+				cfg.KeepFollows, _ = flags.GetBool("keep-follows")
+			}
+			if flags.Changed("keep-bookmarks") {
+				cfg.KeepBookmarks, _ = flags.GetBool("keep-bookmarks")
+			}
+			dryRun, _ := flags.GetBool("dry-run")
+
+			ctx := context.Background()
+			policy, err := utils.GCPolicyFromConfig(ctx, app, cfg)
+			if err != nil {
+				return newError("invalid gc policy", err)
+			}
+			if policy.MaxAge == 0 && policy.MaxSize == 0 {
+				return newError("nothing to do: set --max-age or --max-size", nil)
+			}
+			policy.DryRun = dryRun
+
+			report, err := app.System().CollectGarbage(ctx, policy)
+			if err != nil {
+				return newError("garbage collection failed", err)
+			}
+
+			return printResult(cmd, result{
+				Data:   gcOutput{DryRun: dryRun, GCReport: report},
+				Header: []string{"FIELD", "VALUE"},
+				Rows: [][]string{
+					{"scanned", strconv.Itoa(report.Scanned)},
+					{"protected", strconv.Itoa(report.Protected)},
+					{"evicted", strconv.Itoa(report.Evicted)},
+					{"freed", utils.FormatByteSize(report.FreedBytes)},
+					{"kept", utils.FormatByteSize(report.KeptBytes)},
+					{"expired cache", strconv.Itoa(report.ExpiredCache)},
+				},
+				Text: func(w io.Writer) error {
+					_, err := fmt.Fprint(w, utils.FormatGCReport(report, dryRun))
+					return err
+				},
+			})
+		},
+	}
+	storeGCCmd.Flags().String("max-age", "", "Evict events not accessed for this long (e.g. 90d, 2w; default from config)")
+	storeGCCmd.Flags().String("max-size", "", "Evict until the events take at most this much (e.g. 500MB, 2GiB)")
+	storeGCCmd.Flags().Bool("keep-own", true, "Never evict our own events")
+	storeGCCmd.Flags().Bool("keep-follows", true, "Never evict events by followed users")
+	storeGCCmd.Flags().Bool("keep-bookmarks", true, "Never evict bookmarked events")
+	storeGCCmd.Flags().Bool("dry-run", false, "Only report what would be evicted")
+
+	storeStatsCmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Show what the local databases contain",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			app := getApp()
+			top, _ := cmd.Flags().GetInt("top")
+
+			stats, err := app.System().CollectStoreStats(context.Background())
+			if err != nil {
+				return newError("failed to collect statistics", err)
+			}
+			out, err := newStoreStatsOutput(app.Config(), stats, top)
+			if err != nil {
+				return err
+			}
+			return printResult(cmd, storeStatsResult(out))
+		},
+	}
+	storeStatsCmd.Flags().IntP("top", "n", 10, "Number of kinds and authors to list")
+	storeStatsCmd.RegisterFlagCompletionFunc("top", completion.LimitCompletionFunc)
+
+	storeCompactCmd := &cobra.Command{
+		Use:   "compact",
+		Short: "Rewrite the LMDB databases to reclaim free space",
+		Long: `Rewrite the event store, kvstore and hints databases without their free pages.
+
+LMDB files never shrink on their own, so run this after a large store gc.
+Use store reindex to rebuild the search index.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			results, err := getApp().CompactStorage(context.Background())
+			rows := make([][]string, len(results))
+			for i, r := range results {
+				rows[i] = []string{r.Name, utils.FormatByteSize(r.Before), utils.FormatByteSize(r.After)}
+			}
+			if perr := printResult(cmd, result{
+				Data:   nonNilData(results),
+				Header: []string{"DATABASE", "BEFORE", "AFTER"},
+				Rows:   rows,
+				Text: func(w io.Writer) error {
+					for _, row := range rows {
+						fmt.Fprintf(w, "%-8s %10s -> %s\n", row[0], row[1], row[2])
+					}
+					if len(results) == 0 && err == nil {
+						fmt.Fprintln(w, "No LMDB databases to compact")
+					}
+					return nil
+				},
+			}); perr != nil {
+				return perr
+			}
+			if err != nil {
+				return newError("failed to compact storage", err)
+			}
+			return nil
+		},
+	}
+
+	storeVerifyCmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Re-check event signatures and compare the search index with the event store",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			repair, _ := cmd.Flags().GetBool("repair")
+
+			report, err := getApp().System().VerifyStore(context.Background(), repair)
+			if err != nil {
+				return newError("verification failed", err)
+			}
+
+			rows := [][]string{
+				{"checked", strconv.Itoa(report.Checked)},
+				{"invalid", strconv.Itoa(len(report.BadEvents))},
+				{"indexed", strconv.Itoa(report.Indexed)},
+				{"orphaned index entries", strconv.Itoa(len(report.OrphanedIndex))},
+				{"unindexed", strconv.Itoa(len(report.Unindexed))},
+				{"repaired", strconv.FormatBool(report.Repaired)},
+			}
+			for _, id := range report.BadEvents {
+				rows = append(rows, []string{"bad event", id.Hex()})
+			}
+
+			return printResult(cmd, result{
+				Data:   report,
+				Header: []string{"FIELD", "VALUE"},
+				Rows:   rows,
+				Text: func(w io.Writer) error {
+					fmt.Fprintf(w, "Checked %d events and %d search index entries\n", report.Checked, report.Indexed)
+					for _, id := range report.BadEvents {
+						fmt.Fprintf(w, "  bad id or signature: %s\n", id.Hex())
+					}
+					fmt.Fprintf(w, "%d invalid events, %d index entries without an event, %d events missing from the index\n",
+						len(report.BadEvents), len(report.OrphanedIndex), len(report.Unindexed))
+
+					switch {
+					case report.Repaired:
+						fmt.Fprintln(w, "Removed invalid events and orphaned index entries, indexed the missing events")
+					case len(report.BadEvents) > 0 || len(report.OrphanedIndex) > 0 || len(report.Unindexed) > 0:
+						fmt.Fprintln(w, "Run with --repair to fix them")
+					}
+					return nil
+				},
+			})
+		},
+	}
+	storeVerifyCmd.Flags().Bool("repair", false, "Delete invalid events and orphaned index entries, index missing events")
+
+	storeReindexCmd := &cobra.Command{
+		Use:   "reindex",
+		Short: "Rebuild the search index from the event store",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			count, err := getApp().RebuildSearchIndex(context.Background())
+			if err != nil {
+				return newError("failed to rebuild search index", err)
+			}
+			return printResult(cmd, result{
+				Data:   map[string]int{"indexed": count},
+				Header: []string{"INDEXED"},
+				Rows:   [][]string{{strconv.Itoa(count)}},
+				Text: func(w io.Writer) error {
+					_, err := fmt.Fprintf(w, "Indexed %d events\n", count)
+					return err
+				},
+			})
+		},
+	}
+
+	storeExportCmd := &cobra.Command{
+		Use:   "export [file]",
+		Short: "Write stored events as JSON lines",
+		Long: `Write the stored events matching the filter as NIP-01 JSON, one event per line,
+newest first. Writes to stdout when no file (or "-") is given.
+
+--filter takes a NIP-01 filter as JSON; the other flags are added on top of it.
+Times can be unix timestamps, dates (2024-05-01) or durations ago (7d).`,
+		Example: `  nosmec store export notes.jsonl --kinds 1 --authors alice --since 30d
+  nosmec store export --filter '{"kinds":[0,3,10002]}' > lists.jsonl`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			app := getApp()
+			filter, err := storeFilterFromFlags(app, cmd)
+			if err != nil {
+				return err
+			}
+
+			w := cmd.OutOrStdout()
+			if len(args) == 1 && args[0] != "-" {
+				f, err := os.Create(args[0])
+				if err != nil {
+					return newError("failed to create export file", err)
+				}
+				defer f.Close()
+				w = f
+			}
+
+			count, err := app.System().ExportEvents(context.Background(), filter, w)
+			if err != nil {
+				return newError("export failed", err)
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "Exported %d events\n", count)
+			return nil
+		},
+	}
+	storeExportCmd.Flags().String("filter", "", "NIP-01 filter as JSON")
+	storeExportCmd.Flags().IntSlice("kinds", nil, "Only events of these kinds (e.g., --kinds 1,6)")
+	storeExportCmd.Flags().StringSlice("authors", nil, "Only events by these authors (npub, hex or alias)")
+	storeExportCmd.Flags().String("since", "", "Only events created at or after this time")
+	storeExportCmd.Flags().String("until", "", "Only events created at or before this time")
+	storeExportCmd.Flags().StringArray("tag", nil, "Only events with this tag, as name=value (repeatable)")
+
+	storeImportCmd := &cobra.Command{
+		Use:   "import <file>",
+		Short: "Load events from a JSON lines file into the local store",
+		Long: `Load NIP-01 JSON events, one per line, into the local store and search index.
+Events with a wrong id or signature are skipped, and so are events that are already stored.
+Reads from stdin when the file is "-".`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			r := cmd.InOrStdin()
+			if args[0] != "-" {
+				f, err := os.Open(args[0])
+				if err != nil {
+					return newError("failed to open import file", err)
+				}
+				defer f.Close()
+				r = f
+			}
+
+			report, err := getApp().System().ImportEvents(context.Background(), r)
+			if err != nil {
+				return newError(fmt.Sprintf("import failed after %d lines", report.Read), err)
+			}
+			return printResult(cmd, result{
+				Data:   report,
+				Header: []string{"READ", "IMPORTED", "DUPLICATES", "INVALID"},
+				Rows: [][]string{{
+					strconv.Itoa(report.Read), strconv.Itoa(report.Imported),
+					strconv.Itoa(report.Duplicates), strconv.Itoa(report.Invalid),
+				}},
+				Text: func(w io.Writer) error {
+					_, err := fmt.Fprintf(w, "Imported %d of %d events (%d duplicates, %d invalid)\n",
+						report.Imported, report.Read, report.Duplicates, report.Invalid)
+					return err
+				},
+			})
+		},
+	}
+
+	storeMigrateCmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Copy the kvstore and hints databases to another backend",
+		Long: `Copy every kvstore record and hint entry from one storage backend to another,
+then switch storage.backend in the config file to the new one.
+
+The old databases are left untouched, remove them once you are happy with the
+new backend. The event store and search index are not affected.`,
+		Example: `  nosmec store migrate --to bbolt
+  nosmec store migrate --from bbolt --to lmdb`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			app := getApp()
+			from, _ := cmd.Flags().GetString("from")
+			to, _ := cmd.Flags().GetString("to")
+
+			report, err := app.MigrateStorage(from, to)
+			if err != nil {
+				return newError("migration failed", err)
+			}
+			return printResult(cmd, result{
+				Data:   report,
+				Header: []string{"FROM", "TO", "KVSTORE RECORDS", "HINTS"},
+				Rows:   [][]string{{report.From, report.To, strconv.Itoa(report.KVEntries), strconv.Itoa(report.Hints)}},
+				Text: func(w io.Writer) error {
+					fmt.Fprintf(w, "Copied %d kvstore records and %d hint entries from %s to %s\n",
+						report.KVEntries, report.Hints, report.From, report.To)
+					fmt.Fprintf(w, "storage.backend is now %s, it takes effect on the next run\n", report.To)
+					return nil
+				},
+			})
+		},
+	}
+	storeMigrateCmd.Flags().String("from", "", "Backend to copy from: lmdb or bbolt (default the configured one)")
+	storeMigrateCmd.Flags().String("to", "", "Backend to copy to: lmdb or bbolt")
+	storeMigrateCmd.MarkFlagRequired("to")
+
+	storeCmd.AddCommand(storeStatsCmd)
+	storeCmd.AddCommand(storeGCCmd)
+	storeCmd.AddCommand(storeCompactCmd)
+	storeCmd.AddCommand(storeVerifyCmd)
+	storeCmd.AddCommand(storeReindexCmd)
+	storeCmd.AddCommand(storeExportCmd)
+	storeCmd.AddCommand(storeImportCmd)
+	storeCmd.AddCommand(storeMigrateCmd)
+
+	// export writes events, not a report, so it is left out
+	supportsOutput(storeStatsCmd, storeGCCmd, storeCompactCmd, storeVerifyCmd, storeReindexCmd, storeImportCmd, storeMigrateCmd)
+	RegisterCommandGroup("Store", "Local event store", storeCmd)
+}
+
+// collectGarbage runs the automatic storage.gc policy when it is due.
+func collectGarbage(cmd *cobra.Command) {
+	if app == nil || !app.Config().Storage.GC.Auto {
+		return
+	}
+	switch cmd.Name() {
+	case cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd, "completion", "help":
+		return
+	}
+	if cmd.Parent() != nil && cmd.Parent().Name() == "store" {
+		return
+	}
+
+	report, err := utils.RunAutoGC(context.Background(), app)
+	if err != nil {
+		logger.Warn("automatic garbage collection failed", "error", err.Error())
+		return
+	}
+	if report != nil {
+		logger.Info("collected garbage", "evicted", report.Evicted, "scanned", report.Scanned, "freed", report.FreedBytes)
+	}
+}
+
+// storeFilterFromFlags builds the filter of store export from --filter and the
+// kinds, authors, since, until and tag flags.
+func storeFilterFromFlags(app *config.AppContext, cmd *cobra.Command) (nostr.Filter, error) {
+	var filter nostr.Filter
+	if raw, _ := cmd.Flags().GetString("filter"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &filter); err != nil {
+			return filter, newError("invalid --filter", err)
+		}
+	}
+	return addFilterFlags(app, cmd, filter)
+}
+
+// addFilterFlags adds the kinds, authors, since, until and tag flags of cmd to filter.
+func addFilterFlags(app *config.AppContext, cmd *cobra.Command, filter nostr.Filter) (nostr.Filter, error) {
+	flags := cmd.Flags()
+
+	kinds, _ := flags.GetIntSlice("kinds")
+	for _, k := range kinds {
+		filter.Kinds = append(filter.Kinds, nostr.Kind(k))
+	}
+
+	authors, _ := flags.GetStringSlice("authors")
+	for _, a := range authors {
+		pk, err := utils.ResolveAliasToPubKey(app, a)
+		if err != nil {
+			return filter, newError("invalid author "+a, err)
+		}
+		filter.Authors = append(filter.Authors, pk)
+	}
+
+	for _, bound := range []struct {
+		flag string
+		ts   *nostr.Timestamp
+	}{{"since", &filter.Since}, {"until", &filter.Until}} {
+		if v, _ := flags.GetString(bound.flag); v != "" {
+			ts, err := utils.ParseTimestamp(v)
+			if err != nil {
+				return filter, newError("invalid --"+bound.flag, err)
+			}
+			*bound.ts = ts
+		}
+	}
+
+	pairs, _ := flags.GetStringArray("tag")
+	tags, err := utils.ParseTagFilters(pairs)
+	if err != nil {
+		return filter, newError("invalid --tag", err)
+	}
+	for name, values := range tags {
+		if filter.Tags == nil {
+			filter.Tags = make(nostr.TagMap)
+		}
+		filter.Tags[name] = append(filter.Tags[name], values...)
+	}
+
+	return filter, nil
+}
+
+// gcOutput is the output of "store gc".
+type gcOutput struct {
+	DryRun bool `json:"dry_run"`
+	nostr_sdk.GCReport
+}
+
+// storeStatsOutput is the output of "store stats".
+type storeStatsOutput struct {
+	Events     int              `json:"events"`
+	Bytes      int64            `json:"bytes"`
+	TopKinds   []kindCount      `json:"top_kinds"`
+	TopAuthors []authorCount    `json:"top_authors"`
+	Authors    int              `json:"authors"`
+	Databases  []databaseSize   `json:"databases"`
+	KVStore    []kvPrefixOutput `json:"kvstore"`
+}
+
+type kindCount struct {
+	Kind   nostr.Kind `json:"kind"`
+	Events int        `json:"events"`
+}
+
+type authorCount struct {
+	Author string `json:"author"`
+	Events int    `json:"events"`
+}
+
+type databaseSize struct {
+	Name  string `json:"name"`
+	Path  string `json:"path"`
+	Bytes int64  `json:"bytes"`
+}
+
+type kvPrefixOutput struct {
+	Prefix string `json:"prefix"`
+	Name   string `json:"name"`
+	Keys   int    `json:"keys"`
+	Bytes  int64  `json:"bytes"`
+}
+
+func newStoreStatsOutput(cfg config.Config, stats nostr_sdk.StoreStats, top int) (storeStatsOutput, error) {
+	out := storeStatsOutput{
+		Events:     stats.Events,
+		Bytes:      stats.Bytes,
+		TopKinds:   []kindCount{},
+		TopAuthors: []authorCount{},
+		Authors:    len(stats.Authors),
+		Databases:  []databaseSize{},
+		KVStore:    []kvPrefixOutput{},
+	}
+	for _, kind := range stats.TopKinds(top) {
+		out.TopKinds = append(out.TopKinds, kindCount{Kind: kind, Events: stats.Kinds[kind]})
+	}
+	for _, author := range stats.TopAuthors(top) {
+		out.TopAuthors = append(out.TopAuthors, authorCount{Author: utils.PubKeyToNpub(author), Events: stats.Authors[author]})
+	}
+
+	for _, db := range config.StorageDBs(cfg.DataDir, cfg.Storage) {
+		size, err := config.DirSize(db.Path)
+		if err != nil {
+			return out, newError("failed to measure "+db.Name, err)
+		}
+		out.Databases = append(out.Databases, databaseSize{Name: db.Name, Path: db.Path, Bytes: size})
+	}
+
+	for _, p := range stats.KV {
+		name := nostr_sdk.KVPrefixNames[p.Prefix]
+		if name == "" {
+			name = "unknown"
+		}
+		out.KVStore = append(out.KVStore, kvPrefixOutput{Prefix: string(p.Prefix), Name: name, Keys: p.Keys, Bytes: p.Bytes})
+	}
+	return out, nil
+}
+
+// storeStatsResult shows the statistics as one table row per kind, author, database and
+// kvstore prefix.
+func storeStatsResult(out storeStatsOutput) result {
+	rows := [][]string{{"events", "", strconv.Itoa(out.Events), utils.FormatByteSize(out.Bytes)}}
+	for _, k := range out.TopKinds {
+		rows = append(rows, []string{"kind", strconv.Itoa(int(k.Kind)), strconv.Itoa(k.Events), ""})
+	}
+	for _, a := range out.TopAuthors {
+		rows = append(rows, []string{"author", a.Author, strconv.Itoa(a.Events), ""})
+	}
+	for _, db := range out.Databases {
+		rows = append(rows, []string{"database", db.Name, "", utils.FormatByteSize(db.Bytes)})
+	}
+	for _, p := range out.KVStore {
+		rows = append(rows, []string{"kvstore", p.Name, strconv.Itoa(p.Keys), utils.FormatByteSize(p.Bytes)})
+	}
+
+	return result{
+		Data:   out,
+		Header: []string{"SECTION", "NAME", "COUNT", "SIZE"},
+		Rows:   rows,
+		Text: func(w io.Writer) error {
+			fmt.Fprintf(w, "Events: %d (%s of JSON)\n", out.Events, utils.FormatByteSize(out.Bytes))
+
+			if out.Events > 0 {
+				fmt.Fprintln(w, "\nTop kinds:")
+				for _, k := range out.TopKinds {
+					fmt.Fprintf(w, "  %-8d %d\n", k.Kind, k.Events)
+				}
+				fmt.Fprintf(w, "\nTop authors (%d total):\n", out.Authors)
+				for _, a := range out.TopAuthors {
+					fmt.Fprintf(w, "  %s %d\n", a.Author, a.Events)
+				}
+			}
+
+			fmt.Fprintln(w, "\nDatabases:")
+			for _, db := range out.Databases {
+				fmt.Fprintf(w, "  %-13s %10s  %s\n", db.Name, utils.FormatByteSize(db.Bytes), db.Path)
+			}
+
+			fmt.Fprintln(w, "\nKVStore:")
+			for _, p := range out.KVStore {
+				fmt.Fprintf(w, "  %q %-22s %8d keys %10s\n", p.Prefix, p.Name, p.Keys, utils.FormatByteSize(p.Bytes))
+			}
+			return nil
+		},
+	}
+}
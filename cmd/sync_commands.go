@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/jerry-harm/nosmec/nostr_sdk"
+	"github.com/jerry-harm/nosmec/utils"
+	"github.com/spf13/cobra"
+)
+
+func registerSyncCommands() {
+	syncCmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Reconcile the local store with relays",
+	}
+
+	syncEventsCmd := &cobra.Command{
+		Use:   "events [" + strings.Join(utils.EventSyncScopes, "|") + "]...",
+		Short: "Download the events missing from the local store",
+		Long: `Reconcile the local store with relays and download only the events we are missing.
+
+Scopes (all of them when none is given):
+  own      our own events, with our write relays
+  dms      DM gift wraps addressed to us, with our DM relays
+  follows  notes and reposts of followed users, with their outbox relays
+
+Relays supporting NIP-77 negentropy only send the events we lack. Other relays are paged
+backwards down to the last successful sync with them.`,
+		ValidArgs: utils.EventSyncScopes,
+		Args:      cobra.OnlyValidArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			app := getApp()
+			upload, _ := cmd.Flags().GetBool("upload")
+
+			scopes := args
+			if len(scopes) == 0 {
+				scopes = utils.EventSyncScopes
+			}
+
+			ctx := context.Background()
+			var outputs []scopeSyncOutput
+			failed := 0
+			for _, scope := range scopes {
+				targets, err := utils.EventSyncTargets(ctx, app, scope)
+				if err != nil {
+					outputs = append(outputs, scopeSyncOutput{Scope: scope, Skipped: err.Error(), Relays: []relaySyncOutput{}})
+					continue
+				}
+
+				results := app.System().SyncEvents(ctx, targets, upload && scope == "own")
+				outputs = append(outputs, newScopeSyncOutput(scope, results))
+				for _, r := range results {
+					if r.Err != nil {
+						failed++
+					}
+				}
+			}
+
+			if err := printResult(cmd, eventSyncResult(outputs)); err != nil {
+				return err
+			}
+			if failed > 0 {
+				return newError(fmt.Sprintf("%d relays could not be synced", failed), nil)
+			}
+			return nil
+		},
+	}
+	syncEventsCmd.Flags().Bool("upload", false, "Also send our own events to write relays that lack them (NIP-77 relays only)")
+
+	syncCmd.AddCommand(syncEventsCmd)
+
+	supportsOutput(syncEventsCmd)
+	RegisterCommandGroup("Sync", "Event reconciliation", syncCmd)
+}
+
+// scopeSyncOutput is the outcome of "sync events" for one scope.
+type scopeSyncOutput struct {
+	Scope   string            `json:"scope"`
+	Skipped string            `json:"skipped,omitempty"`
+	Relays  []relaySyncOutput `json:"relays"`
+
+	results []nostr_sdk.EventSyncResult
+}
+
+type relaySyncOutput struct {
+	Relay      string `json:"relay"`
+	Negentropy bool   `json:"negentropy"`
+	Downloaded int    `json:"downloaded"`
+	Uploaded   int    `json:"uploaded"`
+	Error      string `json:"error,omitempty"`
+}
+
+func newScopeSyncOutput(scope string, results []nostr_sdk.EventSyncResult) scopeSyncOutput {
+	out := scopeSyncOutput{Scope: scope, Relays: []relaySyncOutput{}, results: results}
+	for _, r := range results {
+		relay := relaySyncOutput{Relay: r.Relay, Negentropy: r.Negentropy, Downloaded: r.Downloaded, Uploaded: r.Uploaded}
+		if r.Err != nil {
+			relay.Error = r.Err.Error()
+		}
+		out.Relays = append(out.Relays, relay)
+	}
+	return out
+}
+
+// eventSyncResult shows one table row per scope and relay.
+func eventSyncResult(outputs []scopeSyncOutput) result {
+	var rows [][]string
+	for _, o := range outputs {
+		if o.Skipped != "" {
+			rows = append(rows, []string{o.Scope, "", "", "", "", "skipped, " + o.Skipped})
+		}
+		for _, r := range o.Relays {
+			method := "negentropy"
+			if !r.Negentropy {
+				method = "paging"
+			}
+			rows = append(rows, []string{o.Scope, r.Relay, method, strconv.Itoa(r.Downloaded), strconv.Itoa(r.Uploaded), r.Error})
+		}
+	}
+
+	return result{
+		Data:   outputs,
+		Header: []string{"SCOPE", "RELAY", "METHOD", "DOWNLOADED", "UPLOADED", "ERROR"},
+		Rows:   rows,
+		Text: func(w io.Writer) error {
+			for _, o := range outputs {
+				if o.Skipped != "" {
+					fmt.Fprintf(w, "%s: skipped, %s\n", o.Scope, o.Skipped)
+					continue
+				}
+				fmt.Fprint(w, utils.FormatEventSyncResults(o.Scope, o.results))
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,380 @@
+package config
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"fiatjaf.com/nostr"
+	"github.com/jerry-harm/nosmec/nostr_sdk"
+	cache_kv "github.com/jerry-harm/nosmec/nostr_sdk/cache/kv"
+	"github.com/jerry-harm/nosmec/nostr_sdk/hints"
+	"github.com/spf13/viper"
+)
+
+// BackupVersion is the version of the archive layout written by CreateBackup.
+const BackupVersion = 1
+
+// A backup starts with backupMagic and a mode byte, followed by a gzipped tar
+// archive that is encrypted when the mode says so.
+var backupMagic = []byte("NOSMECBK")
+
+const (
+	backupModePlain     = byte(0)
+	backupModeEncrypted = byte(1)
+)
+
+const (
+	backupManifestName = "manifest.json"
+	backupConfigName   = "nosmec.yaml"
+	backupHintsName    = "hints.json"
+	backupKVName       = "kvstore.jsonl"
+	backupEventsName   = "events.jsonl"
+)
+
+// BackupManifest describes the contents of a backup archive.
+type BackupManifest struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	PubKey    string    `json:"pubkey,omitempty"`
+	Config    bool      `json:"config"`
+	Hints     int       `json:"hints"`
+	KVEntries int       `json:"kv_entries"`
+	Events    int       `json:"events"`
+	Encrypted bool      `json:"-"`
+}
+
+// RestoreReport says what RestoreBackup put back.
+type RestoreReport struct {
+	Manifest   BackupManifest
+	ConfigPath string // empty when the config was not restored
+	Hints      int
+	KVEntries  int
+	Events     nostr_sdk.ImportReport
+}
+
+type backupKVEntry struct {
+	Key   []byte `json:"k"`
+	Value []byte `json:"v"`
+}
+
+// CreateBackup writes the config file, hints, KVStore records and stored events to w as
+// a single archive. With a non-empty passphrase the archive is encrypted. Persistent cache
+// entries are left out since they are refetched on demand.
+func (a *AppContext) CreateBackup(ctx context.Context, w io.Writer, passphrase string) (BackupManifest, error) {
+	manifest := BackupManifest{
+		Version:   BackupVersion,
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+		Encrypted: passphrase != "",
+	}
+	sys := a.System()
+	if sys == nil {
+		return manifest, fmt.Errorf("app context is closed")
+	}
+	if pk, err := a.GetMyPubKey(); err == nil {
+		manifest.PubKey = pk.Hex()
+	}
+
+	mode := backupModePlain
+	if manifest.Encrypted {
+		mode = backupModeEncrypted
+	}
+	if _, err := w.Write(append(bytes.Clone(backupMagic), mode)); err != nil {
+		return manifest, err
+	}
+
+	var out io.WriteCloser = nopWriteCloser{w}
+	if manifest.Encrypted {
+		enc, err := newEncryptWriter(w, passphrase)
+		if err != nil {
+			return manifest, err
+		}
+		out = enc
+	}
+	gz := gzip.NewWriter(out)
+	tw := tar.NewWriter(gz)
+
+	// the manifest goes first so restores can reject an archive before reading the rest,
+	// which means the other entries are spooled to disk to count them up front
+	spool, err := os.MkdirTemp("", "nosmec-backup-")
+	if err != nil {
+		return manifest, err
+	}
+	defer os.RemoveAll(spool)
+
+	var configData []byte
+	if path := a.configFilePath(); path != "" {
+		if configData, err = os.ReadFile(path); err != nil && !os.IsNotExist(err) {
+			return manifest, fmt.Errorf("failed to read config: %w", err)
+		}
+		manifest.Config = len(configData) > 0
+	}
+
+	entries := []struct {
+		name  string
+		count *int
+		write func(io.Writer) (int, error)
+	}{
+		{backupHintsName, &manifest.Hints, func(w io.Writer) (int, error) {
+			return hints.Export(sys.Hints, w)
+		}},
+		{backupKVName, &manifest.KVEntries, func(w io.Writer) (int, error) {
+			return exportKVStore(sys, w)
+		}},
+		{backupEventsName, &manifest.Events, func(w io.Writer) (int, error) {
+			return sys.ExportEvents(ctx, nostr.Filter{}, w)
+		}},
+	}
+	for _, entry := range entries {
+		f, err := os.Create(filepath.Join(spool, entry.name))
+		if err != nil {
+			return manifest, err
+		}
+		*entry.count, err = entry.write(f)
+		f.Close()
+		if err != nil {
+			return manifest, fmt.Errorf("failed to back up %s: %w", entry.name, err)
+		}
+	}
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return manifest, err
+	}
+	if err := writeTarBytes(tw, backupManifestName, manifestData); err != nil {
+		return manifest, err
+	}
+	if manifest.Config {
+		if err := writeTarBytes(tw, backupConfigName, configData); err != nil {
+			return manifest, err
+		}
+	}
+	for _, entry := range entries {
+		if err := writeTarFile(tw, entry.name, filepath.Join(spool, entry.name)); err != nil {
+			return manifest, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return manifest, err
+	}
+	if err := gz.Close(); err != nil {
+		return manifest, err
+	}
+	return manifest, out.Close()
+}
+
+// RestoreBackup validates an archive written by CreateBackup and restores its config file,
+// hints, KVStore records and events. passphrase is only called for encrypted archives.
+// Hints are merged and events already stored are skipped. The data_dir setting of the
+// current config is kept so the restored config points at the databases just filled;
+// the previous config file is saved next to it with a .bak suffix.
+func (a *AppContext) RestoreBackup(ctx context.Context, r io.Reader, passphrase func() (string, error), restoreConfig bool) (RestoreReport, error) {
+	var report RestoreReport
+	sys := a.System()
+	if sys == nil {
+		return report, fmt.Errorf("app context is closed")
+	}
+
+	header := make([]byte, len(backupMagic)+1)
+	if _, err := io.ReadFull(r, header); err != nil || !bytes.Equal(header[:len(backupMagic)], backupMagic) {
+		return report, fmt.Errorf("not a nosmec backup")
+	}
+
+	switch header[len(backupMagic)] {
+	case backupModePlain:
+	case backupModeEncrypted:
+		pass, err := passphrase()
+		if err != nil {
+			return report, err
+		}
+		if r, err = newDecryptReader(r, pass); err != nil {
+			return report, err
+		}
+		report.Manifest.Encrypted = true
+	default:
+		return report, fmt.Errorf("unsupported backup mode %d", header[len(backupMagic)])
+	}
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		if errors.Is(err, ErrBadPassphrase) {
+			return report, err
+		}
+		return report, fmt.Errorf("corrupted backup: %w", err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+
+	hdr, err := tr.Next()
+	if err != nil || hdr.Name != backupManifestName {
+		return report, fmt.Errorf("backup has no manifest")
+	}
+	if err := json.NewDecoder(tr).Decode(&report.Manifest); err != nil {
+		return report, fmt.Errorf("invalid backup manifest: %w", err)
+	}
+	if report.Manifest.Version != BackupVersion {
+		return report, fmt.Errorf("unsupported backup version %d (this nosmec reads version %d)",
+			report.Manifest.Version, BackupVersion)
+	}
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			// reading up to the end makes gzip check its trailer
+			if _, err := io.Copy(io.Discard, gz); err != nil {
+				return report, fmt.Errorf("corrupted backup: %w", err)
+			}
+			return report, nil
+		}
+		if err != nil {
+			return report, fmt.Errorf("corrupted backup: %w", err)
+		}
+
+		switch hdr.Name {
+		case backupConfigName:
+			if !restoreConfig {
+				continue
+			}
+			report.ConfigPath, err = a.restoreConfigFile(tr)
+		case backupHintsName:
+			report.Hints, err = hints.Import(sys.Hints, tr)
+		case backupKVName:
+			report.KVEntries, err = importKVStore(sys, tr)
+		case backupEventsName:
+			report.Events, err = sys.ImportEvents(ctx, tr)
+		default:
+			// written by a newer release of the same version, nothing we know how to restore
+			continue
+		}
+		if err != nil {
+			return report, fmt.Errorf("failed to restore %s: %w", hdr.Name, err)
+		}
+	}
+}
+
+func (a *AppContext) configFilePath() string {
+	if a.viper != nil {
+		if path := a.viper.ConfigFileUsed(); path != "" {
+			return path
+		}
+	}
+	if dir := a.Config().ConfigDir; dir != "" {
+		return filepath.Join(dir, "nosmec.yaml")
+	}
+	return ""
+}
+
+func (a *AppContext) restoreConfigFile(r io.Reader) (string, error) {
+	path := a.configFilePath()
+	if path == "" {
+		return "", fmt.Errorf("no config directory")
+	}
+
+	restored := viper.New()
+	restored.SetConfigType("yaml")
+	if err := restored.ReadConfig(r); err != nil {
+		return "", err
+	}
+	if dataDir := a.Config().DataDir; dataDir != "" {
+		restored.Set("data_dir", dataDir)
+	}
+
+	if previous, err := os.ReadFile(path); err == nil {
+		if err := os.WriteFile(path+".bak", previous, 0600); err != nil {
+			return "", err
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	return path, restored.WriteConfigAs(path)
+}
+
+func exportKVStore(sys *nostr_sdk.System, w io.Writer) (int, error) {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	count := 0
+	err := sys.KVStore.Iterate(func(key, value []byte) error {
+		if len(key) > 0 && key[0] == cache_kv.Prefix {
+			return nil
+		}
+		count++
+		return enc.Encode(backupKVEntry{Key: key, Value: value})
+	})
+	if err != nil {
+		return count, err
+	}
+	return count, bw.Flush()
+}
+
+func importKVStore(sys *nostr_sdk.System, r io.Reader) (int, error) {
+	dec := json.NewDecoder(r)
+	count := 0
+	for {
+		var entry backupKVEntry
+		if err := dec.Decode(&entry); err == io.EOF {
+			return count, nil
+		} else if err != nil {
+			return count, err
+		}
+		if len(entry.Key) == 0 {
+			continue
+		}
+		if err := sys.KVStore.Set(entry.Key, entry.Value); err != nil {
+			return count, err
+		}
+		count++
+	}
+}
+
+func writeTarBytes(tw *tar.Writer, name string, data []byte) error {
+	err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = tw.Write(data)
+	return err
+}
+
+func writeTarFile(tw *tar.Writer, name, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	err = tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    info.Size(),
+		ModTime: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
@@ -0,0 +1,189 @@
+package config
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Encrypted backups are a sequence of AES-256-GCM sealed chunks, each preceded by its
+// length. The nonce is a random prefix, the chunk counter and a flag marking the last
+// chunk, so chunks cannot be reordered and truncation is detected.
+const (
+	backupChunkSize   = 64 * 1024
+	backupSaltSize    = 16
+	backupNoncePrefix = 7
+	backupKDFRounds   = 600_000
+)
+
+// ErrBadPassphrase is returned when an encrypted backup cannot be decrypted.
+var ErrBadPassphrase = errors.New("wrong passphrase or corrupted backup")
+
+func backupAEAD(passphrase string, salt []byte, rounds int) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, rounds, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func backupNonce(prefix []byte, counter uint32, last bool) []byte {
+	nonce := make([]byte, 12)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[backupNoncePrefix:], counter)
+	if last {
+		nonce[11] = 1
+	}
+	return nonce
+}
+
+type encryptWriter struct {
+	w       io.Writer
+	aead    cipher.AEAD
+	prefix  []byte
+	counter uint32
+	buf     []byte
+}
+
+// newEncryptWriter writes the key derivation parameters to w and returns a writer
+// sealing everything written to it. Close must be called to write the last chunk.
+func newEncryptWriter(w io.Writer, passphrase string) (io.WriteCloser, error) {
+	header := make([]byte, backupSaltSize+4+backupNoncePrefix)
+	if _, err := rand.Read(header); err != nil {
+		return nil, err
+	}
+	binary.BigEndian.PutUint32(header[backupSaltSize:], backupKDFRounds)
+
+	aead, err := backupAEAD(passphrase, header[:backupSaltSize], backupKDFRounds)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+	return &encryptWriter{
+		w:      w,
+		aead:   aead,
+		prefix: header[backupSaltSize+4:],
+		buf:    make([]byte, 0, backupChunkSize),
+	}, nil
+}
+
+func (e *encryptWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		if len(e.buf) == backupChunkSize {
+			// only seal a full chunk once we know it is not the last one
+			if err := e.seal(false); err != nil {
+				return written, err
+			}
+		}
+		n := copy(e.buf[len(e.buf):backupChunkSize], p)
+		e.buf = e.buf[:len(e.buf)+n]
+		p = p[n:]
+		written += n
+	}
+	return written, nil
+}
+
+func (e *encryptWriter) Close() error {
+	return e.seal(true)
+}
+
+func (e *encryptWriter) seal(last bool) error {
+	sealed := e.aead.Seal(nil, backupNonce(e.prefix, e.counter, last), e.buf, nil)
+	e.counter++
+	e.buf = e.buf[:0]
+
+	var size [4]byte
+	binary.BigEndian.PutUint32(size[:], uint32(len(sealed)))
+	if _, err := e.w.Write(size[:]); err != nil {
+		return err
+	}
+	_, err := e.w.Write(sealed)
+	return err
+}
+
+type decryptReader struct {
+	r       *bufio.Reader
+	aead    cipher.AEAD
+	prefix  []byte
+	counter uint32
+	buf     []byte
+	done    bool
+}
+
+// newDecryptReader reads the key derivation parameters from r and returns a reader
+// yielding the plaintext.
+func newDecryptReader(r io.Reader, passphrase string) (io.Reader, error) {
+	header := make([]byte, backupSaltSize+4+backupNoncePrefix)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("truncated backup header: %w", err)
+	}
+	rounds := binary.BigEndian.Uint32(header[backupSaltSize:])
+	if rounds == 0 || rounds > 100*backupKDFRounds {
+		return nil, fmt.Errorf("invalid key derivation rounds %d", rounds)
+	}
+
+	aead, err := backupAEAD(passphrase, header[:backupSaltSize], int(rounds))
+	if err != nil {
+		return nil, err
+	}
+	return &decryptReader{
+		r:      bufio.NewReader(r),
+		aead:   aead,
+		prefix: header[backupSaltSize+4:],
+	}, nil
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.buf) == 0 {
+		if d.done {
+			return 0, io.EOF
+		}
+		if err := d.open(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, d.buf)
+	d.buf = d.buf[n:]
+	return n, nil
+}
+
+func (d *decryptReader) open() error {
+	var size [4]byte
+	if _, err := io.ReadFull(d.r, size[:]); err != nil {
+		return fmt.Errorf("truncated backup: %w", io.ErrUnexpectedEOF)
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n < uint32(d.aead.Overhead()) || n > backupChunkSize+uint32(d.aead.Overhead()) {
+		return ErrBadPassphrase
+	}
+	sealed := make([]byte, n)
+	if _, err := io.ReadFull(d.r, sealed); err != nil {
+		return fmt.Errorf("truncated backup: %w", io.ErrUnexpectedEOF)
+	}
+
+	_, err := d.r.Peek(1)
+	last := err == io.EOF
+
+	plain, err := d.aead.Open(nil, backupNonce(d.prefix, d.counter, last), sealed, nil)
+	if err != nil {
+		return ErrBadPassphrase
+	}
+	d.counter++
+	d.buf = plain
+	d.done = last
+	return nil
+}
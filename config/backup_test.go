@@ -0,0 +1,120 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"fiatjaf.com/nostr"
+	"github.com/jerry-harm/nosmec/nostr_sdk/hints"
+	"github.com/spf13/viper"
+)
+
+func newBackupTestApp(t *testing.T, configYAML string) *AppContext {
+	t.Helper()
+	configDir := t.TempDir()
+	if configYAML != "" {
+		if err := os.WriteFile(filepath.Join(configDir, "nosmec.yaml"), []byte(configYAML), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	app := NewAppContext(nil, Config{DataDir: t.TempDir(), ConfigDir: configDir}, viper.New())
+	t.Cleanup(func() { app.Close() })
+	return app
+}
+
+func TestBackupRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	src := newBackupTestApp(t, "data_dir: /elsewhere\nalias:\n  bob: npub1bob\n")
+	sys := src.System()
+
+	sk := nostr.Generate()
+	pk := nostr.GetPublicKey(sk)
+	evt := nostr.Event{Kind: 1, CreatedAt: nostr.Now(), Content: "backed up"}
+	if err := evt.Sign(sk); err != nil {
+		t.Fatal(err)
+	}
+	if err := sys.Store.SaveEvent(evt); err != nil {
+		t.Fatal(err)
+	}
+	sys.Hints.Save(pk, "wss://relay.example", hints.LastInRelayList, nostr.Now())
+	sys.KVStore.Set([]byte("qqueued"), []byte("payload"))
+
+	for _, passphrase := range []string{"", "correct horse"} {
+		var archive bytes.Buffer
+		manifest, err := src.CreateBackup(ctx, &archive, passphrase)
+		if err != nil {
+			t.Fatalf("CreateBackup(%q): %v", passphrase, err)
+		}
+		if manifest.Events != 1 || manifest.Hints != 1 || !manifest.Config || manifest.KVEntries == 0 {
+			t.Fatalf("unexpected manifest %+v", manifest)
+		}
+		if passphrase != "" && bytes.Contains(archive.Bytes(), []byte("npub1bob")) {
+			t.Fatal("encrypted archive contains plaintext config")
+		}
+
+		dst := newBackupTestApp(t, "alias: {}\n")
+		report, err := dst.RestoreBackup(ctx, bytes.NewReader(archive.Bytes()), func() (string, error) {
+			return passphrase, nil
+		}, true)
+		if err != nil {
+			t.Fatalf("RestoreBackup(%q): %v", passphrase, err)
+		}
+		if report.Events.Imported != 1 || report.Hints != 1 || report.ConfigPath == "" {
+			t.Fatalf("unexpected report %+v", report)
+		}
+
+		if relays := dst.System().Hints.TopN(pk, 1); len(relays) != 1 || relays[0] != "wss://relay.example" {
+			t.Errorf("hints not restored: %v", relays)
+		}
+		if v, _ := dst.System().KVStore.Get([]byte("qqueued")); string(v) != "payload" {
+			t.Errorf("kvstore not restored: %q", v)
+		}
+
+		restored, err := os.ReadFile(report.ConfigPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(restored), "npub1bob") || strings.Contains(string(restored), "/elsewhere") {
+			t.Errorf("unexpected restored config:\n%s", restored)
+		}
+		if _, err := os.Stat(report.ConfigPath + ".bak"); err != nil {
+			t.Errorf("previous config not kept: %v", err)
+		}
+	}
+}
+
+func TestRestoreBackupRejectsBadArchives(t *testing.T) {
+	ctx := context.Background()
+	src := newBackupTestApp(t, "")
+	dst := newBackupTestApp(t, "")
+
+	var archive bytes.Buffer
+	if _, err := src.CreateBackup(ctx, &archive, "secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := dst.RestoreBackup(ctx, bytes.NewReader(archive.Bytes()), func() (string, error) {
+		return "wrong", nil
+	}, true)
+	if !errors.Is(err, ErrBadPassphrase) {
+		t.Errorf("wrong passphrase: got %v", err)
+	}
+
+	truncated := archive.Bytes()[:archive.Len()-8]
+	_, err = dst.RestoreBackup(ctx, bytes.NewReader(truncated), func() (string, error) {
+		return "secret", nil
+	}, true)
+	if err == nil {
+		t.Error("truncated archive was accepted")
+	}
+
+	_, err = dst.RestoreBackup(ctx, strings.NewReader("hello world"), nil, true)
+	if err == nil {
+		t.Error("random data was accepted")
+	}
+}
@@ -14,9 +14,7 @@ import (
 	"github.com/jerry-harm/nosmec/logger"
 	"github.com/jerry-harm/nosmec/nostr_sdk"
 	"github.com/jerry-harm/nosmec/nostr_sdk/hints"
-	"github.com/jerry-harm/nosmec/nostr_sdk/hints/lmdbh"
 	"github.com/jerry-harm/nosmec/nostr_sdk/kvstore"
-	kvstorelmdb "github.com/jerry-harm/nosmec/nostr_sdk/kvstore/lmdb"
 	"github.com/spf13/viper"
 )
 
@@ -91,9 +89,23 @@ func loadConfig() *Config {
 	globalViper.SetDefault("relay_list", []Relay{})
 	globalViper.SetDefault("dm_relays", []string{})
 	globalViper.SetDefault("search_relays", []string{})
+	globalViper.SetDefault("count_relays", []string{})
 	globalViper.SetDefault("private_relays", []string{})
 
 	globalViper.SetDefault("subscriptions", []Subscription{})
+	globalViper.SetDefault("outbox.auto_retry", true)
+	globalViper.SetDefault("relay_limits.requests_per_second", nostr_sdk.DefaultRelayLimits.RequestsPerSecond)
+	globalViper.SetDefault("relay_limits.burst", nostr_sdk.DefaultRelayLimits.Burst)
+	globalViper.SetDefault("relay_limits.max_concurrent", nostr_sdk.DefaultRelayLimits.MaxConcurrent)
+	globalViper.SetDefault("storage.backend", StorageLMDB)
+	globalViper.SetDefault("storage.map_size_mb", 1024)
+	globalViper.SetDefault("storage.gc.auto", false)
+	globalViper.SetDefault("storage.gc.interval", "24h")
+	globalViper.SetDefault("storage.gc.max_age", "90d")
+	globalViper.SetDefault("storage.gc.max_size", "")
+	globalViper.SetDefault("storage.gc.keep_own", true)
+	globalViper.SetDefault("storage.gc.keep_follows", true)
+	globalViper.SetDefault("storage.gc.keep_bookmarks", true)
 
 	globalViper.SetDefault("theme.primary", "#25A065")
 	globalViper.SetDefault("theme.primary_dark", "#00875A")
@@ -166,19 +178,19 @@ func loadConfig() *Config {
 	return &config
 }
 
-func openHints(dataDir string) hints.HintsDB {
-	hintsPath := filepath.Join(dataDir, "hints")
-	bh, err := lmdbh.NewLMDBHints(hintsPath)
+func openHints(dataDir string, storage Storage) hints.HintsDB {
+	hintsPath := hintsDBPath(dataDir, storage.backend())
+	h, err := openHintsBackend(hintsPath, storage.backend(), storage.mapSize())
 	if err != nil {
 		logger.Error("failed to open hints db", "error", err.Error(), "path", hintsPath)
 		return nil
 	}
-	return bh
+	return h
 }
 
-func openKVStore(dataDir string) kvstore.KVStore {
-	kvPath := filepath.Join(dataDir, "kvstore")
-	store, err := kvstorelmdb.NewStore(kvPath)
+func openKVStore(dataDir string, storage Storage) kvstore.KVStore {
+	kvPath := kvStorePath(dataDir, storage.backend())
+	store, err := openKVStoreBackend(kvPath, storage.backend(), storage.mapSize())
 	if err != nil {
 		logger.Error("failed to open kvstore", "error", err.Error(), "path", kvPath)
 		return nil
@@ -186,15 +198,15 @@ func openKVStore(dataDir string) kvstore.KVStore {
 	return store
 }
 
-func openStore(dataDir string) eventstore.Store {
-	eventsPath := filepath.Join(dataDir, "events")
-	lmdbStore := &eventstorelmdb.LMDBBackend{Path: eventsPath}
+func openStore(dataDir string, storage Storage) eventstore.Store {
+	eventsPath := filepath.Join(dataDir, eventsDirName)
+	lmdbStore := &eventstorelmdb.LMDBBackend{Path: eventsPath, MapSize: storage.mapSize()}
 	if err := lmdbStore.Init(); err != nil {
 		logger.Warn("failed to create LMDB event store, local cache disabled", "error", err.Error(), "path", eventsPath)
 		return nil
 	}
 
-	searchIndexPath := filepath.Join(dataDir, "search_index")
+	searchIndexPath := filepath.Join(dataDir, searchIndexDirName)
 	bleveStore := &eventstorebleve.BleveBackend{Path: searchIndexPath, RawEventStore: lmdbStore}
 	if err := bleveStore.Init(); err != nil {
 		logger.Warn("failed to create Bleve search index, search disabled", "error", err.Error(), "path", searchIndexPath)
@@ -204,6 +216,22 @@ func openStore(dataDir string) eventstore.Store {
 	return bleveStore
 }
 
+func newRelayLimiter(limits RelayLimits) *nostr_sdk.RelayLimiter {
+	overrides := make(map[string]nostr_sdk.RelayLimits, len(limits.Relays))
+	for _, relay := range limits.Relays {
+		overrides[relay.URL] = nostr_sdk.RelayLimits{
+			RequestsPerSecond: relay.RequestsPerSecond,
+			Burst:             relay.Burst,
+			MaxConcurrent:     relay.MaxConcurrent,
+		}
+	}
+	return nostr_sdk.NewRelayLimiter(nostr_sdk.RelayLimits{
+		RequestsPerSecond: limits.RequestsPerSecond,
+		Burst:             limits.Burst,
+		MaxConcurrent:     limits.MaxConcurrent,
+	}, overrides)
+}
+
 func newPool(sys *nostr_sdk.System) *nostr.Pool {
 	opts := nostr.PoolOptions{
 		RelayOptions: nostr.RelayOptions{
@@ -24,15 +24,20 @@ type AppContext struct {
 
 func NewAppContext(pool *nostr.Pool, cfg Config, v *viper.Viper) *AppContext {
 	sys := nostr_sdk.NewSystem()
+	sys.RelayLimiter = newRelayLimiter(cfg.RelayLimits)
+	if len(cfg.CountRelays) > 0 {
+		sys.CountRelays = nostr_sdk.NewRelayStream(cfg.CountRelays...)
+	}
 
 	if cfg.DataDir != "" {
-		if h := openHints(cfg.DataDir); h != nil {
+		if h := openHints(cfg.DataDir, cfg.Storage); h != nil {
 			sys.Hints = h
 		}
-		if kv := openKVStore(cfg.DataDir); kv != nil {
+		if kv := openKVStore(cfg.DataDir, cfg.Storage); kv != nil {
 			sys.KVStore = kv
+			sys.UsePersistentCaches()
 		}
-		if store := openStore(cfg.DataDir); store != nil {
+		if store := openStore(cfg.DataDir, cfg.Storage); store != nil {
 			sys.Store = store
 		}
 	}
@@ -352,6 +357,75 @@ func (a *AppContext) RemoveSearchRelay(url string) error {
 	return a.viper.WriteConfig()
 }
 
+// ListSavedSearches returns the saved search queries by name.
+func (a *AppContext) ListSavedSearches() map[string]string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.cfg.SavedSearches == nil {
+		return make(map[string]string)
+	}
+	return a.cfg.SavedSearches
+}
+
+func (a *AppContext) GetSavedSearch(name string) (string, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	query, ok := a.cfg.SavedSearches[name]
+	return query, ok
+}
+
+// SaveSearch stores query under name, replacing any query saved with that name.
+func (a *AppContext) SaveSearch(name, query string) error {
+	if err := validateSavedSearchName(name); err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	searches := make(map[string]string, len(a.cfg.SavedSearches)+1)
+	for n, q := range a.cfg.SavedSearches {
+		searches[n] = q
+	}
+	searches[name] = query
+	a.cfg.SavedSearches = searches
+	a.viper.Set("saved_searches", a.cfg.SavedSearches)
+	return a.viper.WriteConfig()
+}
+
+func (a *AppContext) RemoveSavedSearch(name string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, ok := a.cfg.SavedSearches[name]; !ok {
+		return fmt.Errorf("saved search not found: %s", name)
+	}
+
+	searches := make(map[string]string, len(a.cfg.SavedSearches))
+	for n, q := range a.cfg.SavedSearches {
+		if n != name {
+			searches[n] = q
+		}
+	}
+	a.cfg.SavedSearches = searches
+	a.viper.Set("saved_searches", a.cfg.SavedSearches)
+	return a.viper.WriteConfig()
+}
+
+// validateSavedSearchName only accepts names that survive the config file round trip;
+// viper lower-cases map keys when reading them back.
+func validateSavedSearchName(name string) error {
+	if name == "" || len(name) > 64 {
+		return fmt.Errorf("saved search name must be 1 to 64 characters long")
+	}
+	for _, c := range name {
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+			return fmt.Errorf("invalid saved search name %q: use lowercase letters, digits, '-' and '_'", name)
+		}
+	}
+	return nil
+}
+
 func (a *AppContext) ListSubscriptions(subType string) []Subscription {
 	a.mu.RLock()
 	defer a.mu.RUnlock()
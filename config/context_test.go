@@ -36,4 +36,65 @@ func TestAppContextClose_DoesNotResetSharedPackageGlobals(t *testing.T) {
 	if app.System() != nil {
 		t.Fatal("expected AppContext to clear owned system reference on close")
 	}
-}
\ No newline at end of file
+}
+
+func TestSavedSearches(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "nosmec.yaml")
+	v := viper.New()
+	v.SetConfigFile(configFile)
+	app := NewAppContext(nil, Config{DataDir: t.TempDir()}, v)
+	t.Cleanup(func() { app.Close() })
+
+	if err := app.SaveSearch("Daily", "nostr"); err == nil {
+		t.Fatal("expected an error for an upper case name")
+	}
+	if err := app.SaveSearch("daily", "nostr kinds:1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := app.SaveSearch("ln", "lightning"); err != nil {
+		t.Fatal(err)
+	}
+	if query, ok := app.GetSavedSearch("daily"); !ok || query != "nostr kinds:1" {
+		t.Fatalf("GetSavedSearch(daily) = %q, %v", query, ok)
+	}
+
+	reread := viper.New()
+	reread.SetConfigFile(configFile)
+	if err := reread.ReadInConfig(); err != nil {
+		t.Fatal(err)
+	}
+	var cfg Config
+	if err := reread.Unmarshal(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.SavedSearches) != 2 || cfg.SavedSearches["ln"] != "lightning" {
+		t.Fatalf("unexpected saved searches in config file: %v", cfg.SavedSearches)
+	}
+
+	if err := app.RemoveSavedSearch("ln"); err != nil {
+		t.Fatal(err)
+	}
+	if err := app.RemoveSavedSearch("ln"); err == nil {
+		t.Fatal("expected an error removing a missing search")
+	}
+	if searches := app.ListSavedSearches(); len(searches) != 1 {
+		t.Fatalf("expected 1 saved search, got %v", searches)
+	}
+
+	sys := app.System()
+	if seen := sys.SavedSearchLastSeen("daily"); seen != 0 {
+		t.Fatalf("expected no last seen time, got %d", seen)
+	}
+	if err := sys.SetSavedSearchLastSeen("daily", 1700000000); err != nil {
+		t.Fatal(err)
+	}
+	if seen := sys.SavedSearchLastSeen("daily"); seen != 1700000000 {
+		t.Fatalf("expected last seen 1700000000, got %d", seen)
+	}
+	if err := sys.ForgetSavedSearch("daily"); err != nil {
+		t.Fatal(err)
+	}
+	if seen := sys.SavedSearchLastSeen("daily"); seen != 0 {
+		t.Fatalf("expected last seen to be forgotten, got %d", seen)
+	}
+}
@@ -0,0 +1,363 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	eventstorebleve "fiatjaf.com/nostr/eventstore/bleve"
+	eventstorelmdb "fiatjaf.com/nostr/eventstore/lmdb"
+	"github.com/jerry-harm/nosmec/nostr_sdk/hints"
+	"github.com/jerry-harm/nosmec/nostr_sdk/hints/bbolth"
+	"github.com/jerry-harm/nosmec/nostr_sdk/hints/lmdbh"
+	"github.com/jerry-harm/nosmec/nostr_sdk/hints/memoryh"
+	"github.com/jerry-harm/nosmec/nostr_sdk/kvstore"
+	kvstorebbolt "github.com/jerry-harm/nosmec/nostr_sdk/kvstore/bbolt"
+	kvstorelmdb "github.com/jerry-harm/nosmec/nostr_sdk/kvstore/lmdb"
+	kvstorememory "github.com/jerry-harm/nosmec/nostr_sdk/kvstore/memory"
+)
+
+const (
+	eventsDirName      = "events"
+	searchIndexDirName = "search_index"
+	kvStoreDirName     = "kvstore"
+	hintsDirName       = "hints"
+)
+
+// Backends for the kvstore and hints databases.
+const (
+	StorageLMDB   = "lmdb"
+	StorageBbolt  = "bbolt"
+	StorageMemory = "memory" // nothing is kept between runs
+)
+
+// StorageBackends lists the values accepted by storage.backend.
+var StorageBackends = []string{StorageLMDB, StorageBbolt, StorageMemory}
+
+func (s Storage) backend() string {
+	if s.Backend == "" {
+		return StorageLMDB
+	}
+	return s.Backend
+}
+
+func (s Storage) mapSize() int64 {
+	if s.MapSizeMB <= 0 {
+		return kvstorelmdb.DefaultMapSize
+	}
+	return int64(s.MapSizeMB) << 20
+}
+
+// LMDB keeps each database in a directory while bbolt uses a single file,
+// so they get different paths and can live side by side during a migration.
+func kvStorePath(dataDir, backend string) string {
+	if backend == StorageBbolt {
+		return filepath.Join(dataDir, kvStoreDirName+".bolt")
+	}
+	return filepath.Join(dataDir, kvStoreDirName)
+}
+
+func hintsDBPath(dataDir, backend string) string {
+	if backend == StorageBbolt {
+		return filepath.Join(dataDir, hintsDirName+".bolt")
+	}
+	return filepath.Join(dataDir, hintsDirName)
+}
+
+func openKVStoreBackend(path, backend string, mapSize int64) (kvstore.KVStore, error) {
+	switch backend {
+	case StorageLMDB:
+		return kvstorelmdb.NewStoreWithMapSize(path, mapSize)
+	case StorageBbolt:
+		return kvstorebbolt.NewStore(path)
+	case StorageMemory:
+		return kvstorememory.NewStore(), nil
+	}
+	return nil, fmt.Errorf("unknown storage backend %q", backend)
+}
+
+func openHintsBackend(path, backend string, mapSize int64) (hints.HintsDB, error) {
+	switch backend {
+	case StorageLMDB:
+		return lmdbh.NewLMDBHintsWithMapSize(path, mapSize)
+	case StorageBbolt:
+		return bbolth.NewBoltHints(path)
+	case StorageMemory:
+		return memoryh.NewHintDB(), nil
+	}
+	return nil, fmt.Errorf("unknown storage backend %q", backend)
+}
+
+// StorageDB is one of the databases kept under the data directory.
+type StorageDB struct {
+	Name string
+	Path string
+}
+
+// StorageDBs lists the databases under dataDir in a stable order.
+func StorageDBs(dataDir string, storage Storage) []StorageDB {
+	return []StorageDB{
+		{Name: "events", Path: filepath.Join(dataDir, eventsDirName)},
+		{Name: "search index", Path: filepath.Join(dataDir, searchIndexDirName)},
+		{Name: "kvstore", Path: kvStorePath(dataDir, storage.backend())},
+		{Name: "hints", Path: hintsDBPath(dataDir, storage.backend())},
+	}
+}
+
+// DirSize returns the total size of the regular files under path, or 0 if it does not exist.
+func DirSize(path string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
+
+// RebuildSearchIndex drops the Bleve search index and indexes every event from the raw
+// event store again. It returns how many events were indexed.
+func (a *AppContext) RebuildSearchIndex(ctx context.Context) (int, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.sys == nil {
+		return 0, fmt.Errorf("app context is closed")
+	}
+	index, ok := a.sys.Store.(*eventstorebleve.BleveBackend)
+	if !ok || index.RawEventStore == nil {
+		return 0, fmt.Errorf("search index is not enabled")
+	}
+
+	raw := index.RawEventStore
+	path := index.Path
+	index.Close()
+	if err := os.RemoveAll(path); err != nil {
+		return 0, fmt.Errorf("failed to remove old index: %w", err)
+	}
+
+	fresh := &eventstorebleve.BleveBackend{Path: path, RawEventStore: raw}
+	if err := fresh.Init(); err != nil {
+		// keep serving events without search rather than leaving a closed index around
+		a.sys.Store = raw
+		return 0, fmt.Errorf("failed to create search index: %w", err)
+	}
+	a.sys.Store = fresh
+
+	return a.sys.CopyStoredEvents(ctx, fresh)
+}
+
+// CompactResult reports the on-disk size of one database before and after compaction.
+type CompactResult struct {
+	Name   string `json:"name"`
+	Before int64  `json:"before"`
+	After  int64  `json:"after"`
+}
+
+type compactable interface {
+	CompactTo(dir string) error
+}
+
+// CompactStorage rewrites the LMDB databases without their free pages. Databases
+// are copied first and swapped in once every copy succeeded; the AppContext is
+// closed afterwards and cannot be used anymore.
+func (a *AppContext) CompactStorage(ctx context.Context) ([]CompactResult, error) {
+	dataDir := a.Config().DataDir
+	if dataDir == "" {
+		return nil, fmt.Errorf("no data directory configured")
+	}
+	tmpDir := filepath.Join(dataDir, ".compact")
+	if err := os.RemoveAll(tmpDir); err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	sys := a.System()
+	if sys == nil {
+		return nil, fmt.Errorf("app context is closed")
+	}
+
+	var compacted []string
+	if c, ok := sys.KVStore.(compactable); ok {
+		if err := c.CompactTo(filepath.Join(tmpDir, kvStoreDirName)); err != nil {
+			return nil, fmt.Errorf("failed to compact kvstore: %w", err)
+		}
+		compacted = append(compacted, kvStoreDirName)
+	}
+	if c, ok := sys.Hints.(compactable); ok {
+		if err := c.CompactTo(filepath.Join(tmpDir, hintsDirName)); err != nil {
+			return nil, fmt.Errorf("failed to compact hints: %w", err)
+		}
+		compacted = append(compacted, hintsDirName)
+	}
+
+	raw := sys.Store
+	if index, ok := raw.(*eventstorebleve.BleveBackend); ok {
+		raw = index.RawEventStore
+	}
+	if _, ok := raw.(*eventstorelmdb.LMDBBackend); ok {
+		// the event store does not expose its environment, so copy the events into a new one
+		dst := &eventstorelmdb.LMDBBackend{
+			Path:    filepath.Join(tmpDir, eventsDirName),
+			MapSize: a.Config().Storage.mapSize(),
+		}
+		if err := dst.Init(); err != nil {
+			return nil, fmt.Errorf("failed to create compacted event store: %w", err)
+		}
+		_, err := sys.CopyStoredEvents(ctx, dst)
+		dst.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to copy events: %w", err)
+		}
+		compacted = append(compacted, eventsDirName)
+	}
+
+	results := make([]CompactResult, 0, len(compacted))
+	for _, name := range compacted {
+		before, _ := DirSize(filepath.Join(dataDir, name))
+		results = append(results, CompactResult{Name: name, Before: before})
+	}
+
+	if err := a.Close(); err != nil {
+		return nil, err
+	}
+
+	for i, name := range compacted {
+		path := filepath.Join(dataDir, name)
+		if err := os.RemoveAll(path); err != nil {
+			return results, err
+		}
+		if err := os.Rename(filepath.Join(tmpDir, name), path); err != nil {
+			return results, fmt.Errorf("failed to move compacted %s into place: %w", name, err)
+		}
+		results[i].After, _ = DirSize(path)
+	}
+
+	return results, nil
+}
+
+// MigrateReport says how much MigrateStorage copied.
+type MigrateReport struct {
+	From      string `json:"from"`
+	To        string `json:"to"`
+	KVEntries int    `json:"kv_entries"`
+	Hints     int    `json:"hints"`
+}
+
+// MigrateStorage copies the kvstore and hints databases from one backend to another and
+// switches storage.backend in the config file to the new one. An empty from means the
+// configured backend. The source databases are left in place, so switching back only
+// needs the config changed again.
+func (a *AppContext) MigrateStorage(from, to string) (MigrateReport, error) {
+	cfg := a.Config()
+	if from == "" {
+		from = cfg.Storage.backend()
+	}
+	report := MigrateReport{From: from, To: to}
+	for _, backend := range []string{from, to} {
+		switch backend {
+		case StorageLMDB, StorageBbolt:
+		case StorageMemory:
+			return report, fmt.Errorf("the memory backend keeps nothing to migrate")
+		default:
+			return report, fmt.Errorf("unknown storage backend %q", backend)
+		}
+	}
+	if from == to {
+		return report, fmt.Errorf("source and destination are both %s", from)
+	}
+
+	if cfg.DataDir == "" {
+		return report, fmt.Errorf("no data directory configured")
+	}
+	sys := a.System()
+	if sys == nil {
+		return report, fmt.Errorf("app context is closed")
+	}
+
+	// the configured backend is already open, and LMDB environments must not be opened twice
+	current := cfg.Storage.backend()
+	openKV := func(backend string) (kvstore.KVStore, func(), error) {
+		if backend == current {
+			return sys.KVStore, func() {}, nil
+		}
+		kv, err := openKVStoreBackend(kvStorePath(cfg.DataDir, backend), backend, cfg.Storage.mapSize())
+		if err != nil {
+			return nil, nil, err
+		}
+		return kv, func() { kv.Close() }, nil
+	}
+	openHints := func(backend string) (hints.HintsDB, func(), error) {
+		if backend == current {
+			return sys.Hints, func() {}, nil
+		}
+		h, err := openHintsBackend(hintsDBPath(cfg.DataDir, backend), backend, cfg.Storage.mapSize())
+		if err != nil {
+			return nil, nil, err
+		}
+		return h, func() {
+			if c, ok := h.(interface{ Close() }); ok {
+				c.Close()
+			}
+		}, nil
+	}
+
+	srcKV, closeSrcKV, err := openKV(from)
+	if err != nil {
+		return report, fmt.Errorf("failed to open %s kvstore: %w", from, err)
+	}
+	defer closeSrcKV()
+	dstKV, closeDstKV, err := openKV(to)
+	if err != nil {
+		return report, fmt.Errorf("failed to open %s kvstore: %w", to, err)
+	}
+	defer closeDstKV()
+
+	err = srcKV.Iterate(func(key, value []byte) error {
+		report.KVEntries++
+		return dstKV.Set(key, value)
+	})
+	if err != nil {
+		return report, fmt.Errorf("failed to copy kvstore: %w", err)
+	}
+
+	srcHints, closeSrcHints, err := openHints(from)
+	if err != nil {
+		return report, fmt.Errorf("failed to open %s hints: %w", from, err)
+	}
+	defer closeSrcHints()
+	dstHints, closeDstHints, err := openHints(to)
+	if err != nil {
+		return report, fmt.Errorf("failed to open %s hints: %w", to, err)
+	}
+	defer closeDstHints()
+
+	if report.Hints, err = hints.Copy(dstHints, srcHints); err != nil {
+		return report, fmt.Errorf("failed to copy hints: %w", err)
+	}
+
+	path := a.configFilePath()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.cfg.Storage.Backend = to
+	a.viper.Set("storage.backend", to)
+	if path != "" {
+		if err := a.viper.WriteConfigAs(path); err != nil {
+			return report, fmt.Errorf("copied, but failed to update the config: %w", err)
+		}
+	}
+	return report, nil
+}
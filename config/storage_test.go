@@ -0,0 +1,64 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"fiatjaf.com/nostr"
+	"github.com/jerry-harm/nosmec/nostr_sdk/hints"
+	"github.com/spf13/viper"
+)
+
+func TestMigrateStorage(t *testing.T) {
+	app := newBackupTestApp(t, "")
+	sys := app.System()
+
+	pk := nostr.GetPublicKey(nostr.Generate())
+	ts := nostr.Now() - 60
+	sys.Hints.Save(pk, "wss://relay.example", hints.LastInRelayList, ts)
+	if err := sys.KVStore.Set([]byte("qqueued"), []byte("payload")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := app.MigrateStorage("", StorageLMDB); err == nil {
+		t.Fatal("expected an error migrating to the same backend")
+	}
+	if _, err := app.MigrateStorage("", StorageMemory); err == nil {
+		t.Fatal("expected an error migrating to memory")
+	}
+
+	report, err := app.MigrateStorage("", StorageBbolt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.From != StorageLMDB || report.KVEntries == 0 || report.Hints != 1 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	if app.Config().Storage.Backend != StorageBbolt {
+		t.Fatalf("backend = %q, want %q", app.Config().Storage.Backend, StorageBbolt)
+	}
+	data, err := os.ReadFile(filepath.Join(app.Config().ConfigDir, "nosmec.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "backend: bbolt") {
+		t.Fatalf("config was not updated:\n%s", data)
+	}
+
+	cfg := app.Config()
+	app.Close()
+	migrated := NewAppContext(nil, cfg, viper.New())
+	defer migrated.Close()
+
+	got, err := migrated.System().KVStore.Get([]byte("qqueued"))
+	if err != nil || !bytes.Equal(got, []byte("payload")) {
+		t.Fatalf("kvstore record = %q, %v", got, err)
+	}
+	scores := migrated.System().Hints.GetDetailedScores(pk, 1)
+	if len(scores) != 1 || scores[0].Scores[hints.LastInRelayList] != ts {
+		t.Fatalf("hints not migrated: %+v", scores)
+	}
+}
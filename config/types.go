@@ -1,9 +1,9 @@
 package config
 
 type Relay struct {
-	URL   string `mapstructure:"url"`
-	Read  *bool  `mapstructure:"read,omitempty"`
-	Write *bool  `mapstructure:"write,omitempty"`
+	URL   string `mapstructure:"url" json:"url"`
+	Read  *bool  `mapstructure:"read,omitempty" json:"read,omitempty"`
+	Write *bool  `mapstructure:"write,omitempty" json:"write,omitempty"`
 }
 
 type Config struct {
@@ -12,6 +12,7 @@ type Config struct {
 	RelayList    []Relay  `mapstructure:"relay_list"`
 	DMRelays     []string `mapstructure:"dm_relays"`
 	SearchRelays []string `mapstructure:"search_relays"`
+	CountRelays  []string `mapstructure:"count_relays"` // NIP-45 relays, the built-in ones when empty
 	PrivateKey   string   `mapstructure:"private_key"`
 
 	Proxy struct {
@@ -21,6 +22,8 @@ type Config struct {
 
 	Alias map[string]string `mapstructure:"alias"`
 
+	SavedSearches map[string]string `mapstructure:"saved_searches"` // name -> search query
+
 	Subscriptions []Subscription `mapstructure:"subscriptions"`
 
 	Profile ProfileConfig `mapstructure:"profile"`
@@ -28,6 +31,50 @@ type Config struct {
 	Query struct {
 		Timeout int `mapstructure:"timeout"`
 	} `mapstructure:"query"`
+
+	Outbox struct {
+		AutoRetry bool `mapstructure:"auto_retry"`
+	} `mapstructure:"outbox"`
+
+	RelayLimits RelayLimits `mapstructure:"relay_limits"`
+
+	Storage Storage `mapstructure:"storage"`
+}
+
+// Storage selects the databases under the data directory. Backend applies to the
+// kvstore and hints databases ("lmdb", "bbolt" or "memory"); MapSizeMB is the most
+// each LMDB database, including the event store, can grow to.
+type Storage struct {
+	Backend   string   `mapstructure:"backend"`
+	MapSizeMB int      `mapstructure:"map_size_mb"`
+	GC        GCConfig `mapstructure:"gc"`
+}
+
+// GCConfig is the policy for evicting events from the local store.
+// MaxAge and MaxSize use the same notation as the command line ("90d", "500MB").
+type GCConfig struct {
+	Auto          bool   `mapstructure:"auto"`
+	Interval      string `mapstructure:"interval"`
+	MaxAge        string `mapstructure:"max_age"`
+	MaxSize       string `mapstructure:"max_size"`
+	KeepOwn       bool   `mapstructure:"keep_own"`
+	KeepFollows   bool   `mapstructure:"keep_follows"`
+	KeepBookmarks bool   `mapstructure:"keep_bookmarks"`
+}
+
+// RelayLimits throttles requests per relay. Zero values disable the corresponding limit.
+type RelayLimits struct {
+	RequestsPerSecond float64              `mapstructure:"requests_per_second"`
+	Burst             int                  `mapstructure:"burst"`
+	MaxConcurrent     int                  `mapstructure:"max_concurrent"`
+	Relays            []RelayLimitOverride `mapstructure:"relays"` // per-relay overrides
+}
+
+type RelayLimitOverride struct {
+	URL               string  `mapstructure:"url"`
+	RequestsPerSecond float64 `mapstructure:"requests_per_second"`
+	Burst             int     `mapstructure:"burst"`
+	MaxConcurrent     int     `mapstructure:"max_concurrent"`
 }
 
 type ProfileConfig struct {
@@ -45,8 +92,8 @@ type ProfileConfig struct {
 }
 
 type Subscription struct {
-	Type    string `mapstructure:"type"`    // "community" | "user" | "hashtag"
-	ID      string `mapstructure:"id"`      // community addr, pubkey, or hashtag
-	Relay   string `mapstructure:"relay"`   // recommended relay URL (optional)
-	Petname string `mapstructure:"petname"` // petname/alias (only for user)
+	Type    string `mapstructure:"type" json:"type"`                 // "community" | "user" | "hashtag"
+	ID      string `mapstructure:"id" json:"id"`                     // community addr, pubkey, or hashtag
+	Relay   string `mapstructure:"relay" json:"relay,omitempty"`     // recommended relay URL (optional)
+	Petname string `mapstructure:"petname" json:"petname,omitempty"` // petname/alias (only for user)
 }
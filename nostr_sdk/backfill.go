@@ -0,0 +1,187 @@
+package nostr_sdk
+
+import (
+	"context"
+	"crypto/sha256"
+	"slices"
+	"strconv"
+	"sync"
+
+	"fiatjaf.com/nostr"
+)
+
+const backfillCheckpointPrefix = byte('B')
+
+const (
+	backfillPageSize    = 500
+	backfillConcurrency = 4
+)
+
+// BackfillProgress reports how far Backfill got with one relay.
+type BackfillProgress struct {
+	Relay    string
+	Pages    int
+	Received int
+	Stored   int             // events we did not have before
+	Until    nostr.Timestamp // the cursor, everything newer has been walked
+	Done     bool
+	Err      error
+}
+
+// Backfill walks the history of pubkey on each relay backwards with until cursors until
+// the relay runs out of events (or since is reached), storing everything locally. The cursor
+// is saved in the KVStore after every page, so an interrupted backfill resumes where it
+// stopped; a completed one only fetches what was published since it completed, plus what
+// is older than where it stopped when since now reaches further back.
+//
+// progress, if given, is called after every page, never concurrently.
+func (sys *System) Backfill(
+	ctx context.Context,
+	pubkey nostr.PubKey,
+	kinds []nostr.Kind,
+	since nostr.Timestamp,
+	relays []string,
+	progress func(BackfillProgress),
+) []BackfillProgress {
+	results := make([]BackfillProgress, len(relays))
+	sem := make(chan struct{}, backfillConcurrency)
+	var mu sync.Mutex
+	report := func(p BackfillProgress) {
+		if progress != nil {
+			mu.Lock()
+			progress(p)
+			mu.Unlock()
+		}
+	}
+
+	wg := sync.WaitGroup{}
+	for i, relay := range relays {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i] = sys.backfillRelay(ctx, relay, pubkey, kinds, since, report)
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
+
+func (sys *System) backfillRelay(
+	ctx context.Context,
+	relay string,
+	pubkey nostr.PubKey,
+	kinds []nostr.Kind,
+	since nostr.Timestamp,
+	report func(BackfillProgress),
+) BackfillProgress {
+	p := BackfillProgress{Relay: relay}
+	key := makeBackfillKey(relay, pubkey, kinds)
+
+	// the checkpoint is the until cursor, when the walk last completed, when the current
+	// walk started and how far back the completed walk went (older checkpoints lack the
+	// last two). An interrupted walk resumes at until; once done, the next walk only has
+	// to go back to when this one started, and below the floor when since asks for more
+	started := nostr.Now()
+	var until, completed, floor nostr.Timestamp
+	if data, _ := sys.KVStore.Get(key); len(data) >= 8 {
+		until, completed = decodeTimestamp(data[:4]), decodeTimestamp(data[4:8])
+		if until != 0 && len(data) >= 12 && decodeTimestamp(data[8:12]) != 0 {
+			started = decodeTimestamp(data[8:12])
+		}
+		if len(data) >= 16 {
+			floor = decodeTimestamp(data[12:16])
+		}
+	}
+
+	// the ranges to walk, newest first: down to the last completion, then from the floor
+	// of that walk down to since if it did not go back that far
+	type segment struct{ until, since nostr.Timestamp }
+	segments := []segment{{0, since}}
+	newFloor := since
+	if completed != 0 {
+		segments[0].since = max(since, completed)
+		if floor > since {
+			segments = append(segments, segment{floor, since})
+		} else {
+			newFloor = floor
+		}
+	}
+	if until != 0 {
+		if len(segments) == 2 && until <= floor {
+			segments = segments[1:]
+		}
+		segments[0].until = until
+	}
+
+	filter := nostr.Filter{
+		Authors: []nostr.PubKey{pubkey},
+		Kinds:   kinds,
+		Limit:   backfillPageSize,
+	}
+	for _, seg := range segments {
+		filter.Since, filter.Until = seg.since, seg.until
+		for {
+			if err := ctx.Err(); err != nil {
+				p.Err = err
+				return p
+			}
+
+			received, stored, oldest := sys.fetchAndStorePage(ctx, relay, filter, "backfill")
+			p.Pages++
+			p.Received += received
+			p.Stored += stored
+
+			if received == 0 || (filter.Since != 0 && oldest <= filter.Since) {
+				break
+			}
+
+			// until is inclusive, so the events at the boundary come again and are skipped as stored;
+			// stepping over them also keeps a relay ignoring until from looping forever
+			if filter.Until != 0 && oldest >= filter.Until {
+				oldest = filter.Until - 1
+			}
+			filter.Until = oldest
+			p.Until = oldest
+			sys.KVStore.Set(key, encodeBackfillCheckpoint(oldest, completed, started, floor))
+			report(p)
+		}
+	}
+
+	p.Done = true
+	sys.KVStore.Set(key, encodeBackfillCheckpoint(0, started, 0, newFloor))
+	report(p)
+	return p
+}
+
+func encodeBackfillCheckpoint(until, completed, started, floor nostr.Timestamp) []byte {
+	return slices.Concat(encodeTimestamp(until), encodeTimestamp(completed), encodeTimestamp(started), encodeTimestamp(floor))
+}
+
+// ResetBackfill forgets the checkpoints of pubkey's backfill with the given kinds,
+// so the next Backfill walks the whole history again.
+func (sys *System) ResetBackfill(pubkey nostr.PubKey, kinds []nostr.Kind, relays []string) error {
+	for _, relay := range relays {
+		if err := sys.KVStore.Delete(makeBackfillKey(relay, pubkey, kinds)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func makeBackfillKey(relay string, pubkey nostr.PubKey, kinds []nostr.Kind) []byte {
+	h := sha256.New()
+	h.Write([]byte(relay))
+	h.Write(pubkey[:])
+	for _, kind := range slices.Sorted(slices.Values(kinds)) {
+		h.Write([]byte(strconv.Itoa(int(kind))))
+		h.Write([]byte{','})
+	}
+
+	key := make([]byte, 1+8)
+	key[0] = backfillCheckpointPrefix
+	copy(key[1:], h.Sum(nil)[0:8])
+	return key
+}
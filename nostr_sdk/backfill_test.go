@@ -0,0 +1,143 @@
+package nostr_sdk
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"fiatjaf.com/nostr"
+	"fiatjaf.com/nostr/eventstore/slicestore"
+	"fiatjaf.com/nostr/khatru"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackfill(t *testing.T) {
+	const relayURL = "ws://localhost:48493"
+
+	relay := khatru.NewRelay()
+	db := &slicestore.SliceStore{}
+	db.Init()
+	defer db.Close()
+	relay.UseEventstore(db, 4000)
+
+	started := make(chan bool)
+	go func() {
+		err := relay.Start("127.0.0.1", 48493, started)
+		require.NoError(t, err)
+	}()
+	defer relay.Shutdown(context.Background())
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	sk := nostr.Generate()
+	pk := nostr.GetPublicKey(sk)
+
+	sys := newFetchEventsTestSystem(t)
+	defer sys.Close()
+
+	// enough events for several pages, with kinds we filter out mixed in
+	base := nostr.Now() - 10000
+	for i := range 1200 {
+		kind := nostr.Kind(1)
+		if i%4 == 0 {
+			kind = 7
+		}
+		evt := nostr.Event{Kind: kind, CreatedAt: base + nostr.Timestamp(i), Content: "note"}
+		require.NoError(t, evt.Sign(sk))
+		require.NoError(t, db.SaveEvent(evt))
+	}
+	kinds := []nostr.Kind{1}
+	relays := []string{relayURL}
+
+	// pretend an earlier run was interrupted after walking the newest half
+	mid := base + 600
+	for evt := range db.QueryEvents(nostr.Filter{Kinds: kinds, Since: mid}, 1000) {
+		require.NoError(t, sys.Store.SaveEvent(evt))
+	}
+	key := makeBackfillKey(relayURL, pk, kinds)
+	require.NoError(t, sys.KVStore.Set(key, append(encodeTimestamp(mid), encodeTimestamp(0)...)))
+
+	var pages []BackfillProgress
+	results := sys.Backfill(ctx, pk, kinds, 0, relays, func(p BackfillProgress) {
+		pages = append(pages, p)
+	})
+	require.Len(t, results, 1)
+	require.NoError(t, results[0].Err)
+	require.True(t, results[0].Done)
+	require.Equal(t, 450, results[0].Stored, "only the older half is fetched")
+	require.NotEmpty(t, pages)
+	require.True(t, pages[len(pages)-1].Done)
+
+	stored := 0
+	for evt := range sys.Store.QueryEvents(nostr.Filter{}, 2000) {
+		require.Equal(t, nostr.Kind(1), evt.Kind)
+		stored++
+	}
+	require.Equal(t, 900, stored)
+
+	// once complete, a rerun only looks for what is newer
+	results = sys.Backfill(ctx, pk, kinds, 0, relays, nil)
+	require.NoError(t, results[0].Err)
+	require.True(t, results[0].Done)
+	require.Zero(t, results[0].Stored)
+
+	// after a reset the whole history is walked in pages again, finding nothing new
+	require.NoError(t, sys.ResetBackfill(pk, kinds, relays))
+	results = sys.Backfill(ctx, pk, kinds, 0, relays, nil)
+	require.NoError(t, results[0].Err)
+	require.GreaterOrEqual(t, results[0].Received, 900)
+	require.Zero(t, results[0].Stored)
+	require.Greater(t, results[0].Pages, 2)
+
+	// an incremental walk interrupted half way resumes down to the previous completion,
+	// not to the beginning, and then records when it originally started
+	walkStarted := nostr.Now() - 5
+	require.NoError(t, sys.KVStore.Set(key, encodeBackfillCheckpoint(base+1000, base+800, walkStarted, 0)))
+	results = sys.Backfill(ctx, pk, kinds, 0, relays, nil)
+	require.NoError(t, results[0].Err)
+	require.True(t, results[0].Done)
+	require.Less(t, results[0].Received, 200)
+
+	data, err := sys.KVStore.Get(key)
+	require.NoError(t, err)
+	require.Equal(t, encodeBackfillCheckpoint(0, walkStarted, 0, 0), data)
+
+	// a walk limited by since does not count as covering the older history: a later run
+	// without since goes on below it
+	require.NoError(t, sys.ResetBackfill(pk, kinds, relays))
+	var older []nostr.ID
+	for evt := range sys.Store.QueryEvents(nostr.Filter{Until: mid}, 2000) {
+		older = append(older, evt.ID)
+	}
+	for _, id := range older {
+		require.NoError(t, sys.Store.DeleteEvent(id))
+	}
+	results = sys.Backfill(ctx, pk, kinds, mid, relays, nil)
+	require.NoError(t, results[0].Err)
+	require.True(t, results[0].Done)
+	require.Zero(t, results[0].Stored)
+
+	results = sys.Backfill(ctx, pk, kinds, mid, relays, nil)
+	require.NoError(t, results[0].Err)
+	require.Zero(t, results[0].Stored, "the same since is already covered")
+
+	results = sys.Backfill(ctx, pk, kinds, 0, relays, nil)
+	require.NoError(t, results[0].Err)
+	require.True(t, results[0].Done)
+	require.Equal(t, 450, results[0].Stored, "the history older than the first since is fetched")
+
+	data, err = sys.KVStore.Get(key)
+	require.NoError(t, err)
+	require.Equal(t, encodeTimestamp(0), data[12:16], "the floor goes down to the beginning")
+}
+
+func TestMakeBackfillKey(t *testing.T) {
+	pk := nostr.GetPublicKey(nostr.Generate())
+	a := makeBackfillKey("wss://a", pk, []nostr.Kind{1, 6})
+	require.Equal(t, a, makeBackfillKey("wss://a", pk, []nostr.Kind{6, 1}))
+	require.NotEqual(t, a, makeBackfillKey("wss://b", pk, []nostr.Kind{1, 6}))
+	require.NotEqual(t, a, makeBackfillKey("wss://a", pk, []nostr.Kind{1}))
+	require.Equal(t, backfillCheckpointPrefix, a[0])
+}
@@ -0,0 +1,126 @@
+package cache_kv
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"time"
+
+	cache_memory "github.com/jerry-harm/nosmec/nostr_sdk/cache/memory"
+	"github.com/jerry-harm/nosmec/nostr_sdk/kvstore"
+)
+
+// Prefix is the first byte of every KVStore key written by a KVCache.
+const Prefix = byte('c')
+
+// KVCache is a two-tier cache: a ristretto cache in front of a KVStore, so values
+// survive restarts. Values are gob-encoded, which means unexported fields are not persisted.
+//
+// Each stored value is preceded by its expiry as unix seconds, 0 meaning it never expires.
+type KVCache[V any] struct {
+	store     kvstore.KVStore
+	namespace byte
+	memory    *cache_memory.RistrettoCache[V]
+}
+
+// New returns a cache keeping up to max values in memory and all of them in store.
+// The namespace byte keeps caches sharing the same store apart.
+func New[V any](store kvstore.KVStore, namespace byte, max int64) *KVCache[V] {
+	return &KVCache[V]{
+		store:     store,
+		namespace: namespace,
+		memory:    cache_memory.New[V](max),
+	}
+}
+
+func (s *KVCache[V]) key(k [32]byte) []byte {
+	key := make([]byte, 2+32)
+	key[0] = Prefix
+	key[1] = s.namespace
+	copy(key[2:], k[:])
+	return key
+}
+
+func (s *KVCache[V]) Get(k [32]byte) (v V, ok bool) {
+	if v, ok := s.memory.Get(k); ok {
+		return v, true
+	}
+
+	data, err := s.store.Get(s.key(k))
+	if err != nil || len(data) < 8 {
+		return v, false
+	}
+
+	var ttl time.Duration
+	if expiry := binary.BigEndian.Uint64(data[0:8]); expiry != 0 {
+		ttl = time.Until(time.Unix(int64(expiry), 0))
+		if ttl <= 0 {
+			s.store.Delete(s.key(k))
+			return v, false
+		}
+	}
+
+	if err := gob.NewDecoder(bytes.NewReader(data[8:])).Decode(&v); err != nil {
+		// written by an incompatible version, treat as a miss
+		s.store.Delete(s.key(k))
+		return v, false
+	}
+
+	if ttl > 0 {
+		s.memory.SetWithTTL(k, v, ttl)
+	} else {
+		s.memory.Set(k, v)
+	}
+	return v, true
+}
+
+func (s *KVCache[V]) Delete(k [32]byte) {
+	s.memory.Delete(k)
+	s.store.Delete(s.key(k))
+}
+
+func (s *KVCache[V]) Set(k [32]byte, v V) bool {
+	s.memory.Set(k, v)
+	return s.persist(k, v, 0) == nil
+}
+
+func (s *KVCache[V]) SetWithTTL(k [32]byte, v V, d time.Duration) bool {
+	s.memory.SetWithTTL(k, v, d)
+	return s.persist(k, v, time.Now().Add(d).Unix()) == nil
+}
+
+func (s *KVCache[V]) persist(k [32]byte, v V, expiry int64) error {
+	buf := bytes.NewBuffer(make([]byte, 8, 256))
+	binary.BigEndian.PutUint64(buf.Bytes()[0:8], uint64(expiry))
+	if err := gob.NewEncoder(buf).Encode(v); err != nil {
+		return err
+	}
+	return s.store.Set(s.key(k), buf.Bytes())
+}
+
+// Prune deletes every expired cache entry from store, whatever cache wrote it,
+// and returns how many were removed.
+func Prune(store kvstore.KVStore) (int, error) {
+	now := uint64(time.Now().Unix())
+
+	var expired [][]byte
+	err := store.Iterate(func(key, value []byte) error {
+		if len(key) != 2+32 || key[0] != Prefix || len(value) < 8 {
+			return nil
+		}
+		if expiry := binary.BigEndian.Uint64(value[0:8]); expiry != 0 && expiry <= now {
+			expired = append(expired, bytes.Clone(key))
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, key := range expired {
+		if err := store.Delete(key); err != nil {
+			return 0, err
+		}
+	}
+	return len(expired), nil
+}
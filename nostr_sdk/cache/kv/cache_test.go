@@ -0,0 +1,71 @@
+package cache_kv
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+
+	kvstore_memory "github.com/jerry-harm/nosmec/nostr_sdk/kvstore/memory"
+	"github.com/stretchr/testify/require"
+)
+
+type profile struct {
+	Name  string
+	Tags  [][]string
+	Extra *string
+}
+
+func TestKVCacheSurvivesRestart(t *testing.T) {
+	store := kvstore_memory.NewStore()
+	key := [32]byte{1, 2, 3}
+
+	first := New[profile](store, 'p', 10)
+	require.True(t, first.SetWithTTL(key, profile{Name: "alice", Tags: [][]string{{"t", "go"}}}, time.Hour))
+
+	// a new cache over the same store starts with an empty memory tier
+	second := New[profile](store, 'p', 10)
+	v, ok := second.Get(key)
+	require.True(t, ok)
+	require.Equal(t, "alice", v.Name)
+	require.Equal(t, [][]string{{"t", "go"}}, v.Tags)
+
+	// namespaces keep caches apart
+	other := New[profile](store, 'q', 10)
+	_, ok = other.Get(key)
+	require.False(t, ok)
+
+	second.Delete(key)
+	_, ok = New[profile](store, 'p', 10).Get(key)
+	require.False(t, ok)
+}
+
+func TestKVCacheHonorsTTL(t *testing.T) {
+	store := kvstore_memory.NewStore()
+	cache := New[string](store, 'p', 10)
+
+	expired := [32]byte{1}
+	forever := [32]byte{2}
+	require.True(t, cache.SetWithTTL(expired, "old", time.Hour))
+	require.True(t, cache.Set(forever, "kept"))
+
+	// move the expiry of the first entry into the past
+	k := cache.key(expired)
+	data, err := store.Get(k)
+	require.NoError(t, err)
+	binary.BigEndian.PutUint64(data[0:8], uint64(time.Now().Add(-time.Minute).Unix()))
+	require.NoError(t, store.Set(k, data))
+
+	restarted := New[string](store, 'p', 10)
+	_, ok := restarted.Get(expired)
+	require.False(t, ok)
+	v, ok := restarted.Get(forever)
+	require.True(t, ok)
+	require.Equal(t, "kept", v)
+
+	require.NoError(t, store.Set(k, data))
+	removed, err := Prune(store)
+	require.NoError(t, err)
+	require.Equal(t, 1, removed)
+	data, _ = store.Get(cache.key(forever))
+	require.NotNil(t, data)
+}
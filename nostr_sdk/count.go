@@ -0,0 +1,159 @@
+package nostr_sdk
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"fiatjaf.com/nostr"
+	"fiatjaf.com/nostr/nip45/hyperloglog"
+)
+
+// RelayCount is what one relay answered to a NIP-45 COUNT request.
+type RelayCount struct {
+	Relay string `json:"relay"`
+	Count uint32 `json:"count"`
+	HLL   bool   `json:"hll,omitempty"`
+	Error string `json:"error,omitempty"`
+
+	registers []byte
+}
+
+// CountResult combines the answers of several relays to the same COUNT request.
+//
+// When relays send HyperLogLog registers they are merged, so that people counted by more
+// than one relay are only counted once, and Estimate is set. Plain counts cannot be
+// merged that way, so the largest one is used: the real total is at least that.
+type CountResult struct {
+	Count    uint64       `json:"count"`
+	Estimate bool         `json:"estimate,omitempty"`
+	Relays   []RelayCount `json:"relays"`
+}
+
+// Answered returns how many relays replied to the request.
+func (r CountResult) Answered() int {
+	n := 0
+	for _, rc := range r.Relays {
+		if rc.Error == "" {
+			n++
+		}
+	}
+	return n
+}
+
+// String formats the count, with a "~" in front of estimates.
+func (r CountResult) String() string {
+	if r.Estimate {
+		return "~" + strconv.FormatUint(r.Count, 10)
+	}
+	return strconv.FormatUint(r.Count, 10)
+}
+
+// Count sends a COUNT request for filter to each relay and combines their answers.
+// Relays that do not support NIP-45 usually close the request or never answer, so ctx
+// should have a deadline; such relays are listed with an error.
+func (sys *System) Count(ctx context.Context, relays []string, filter nostr.Filter) CountResult {
+	results := make([]RelayCount, len(relays))
+	wg := sync.WaitGroup{}
+	for i, url := range relays {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = sys.countOne(ctx, url, filter)
+		}()
+	}
+	wg.Wait()
+
+	slices.SortFunc(results, func(a, b RelayCount) int { return strings.Compare(a.Relay, b.Relay) })
+	return aggregateCounts(results)
+}
+
+func (sys *System) countOne(ctx context.Context, url string, filter nostr.Filter) RelayCount {
+	url = nostr.NormalizeURL(url)
+	release, err := sys.RelayLimiter.Acquire(ctx, url)
+	if err != nil {
+		return RelayCount{Relay: url, Error: err.Error()}
+	}
+	defer release()
+
+	relay, err := sys.Pool.EnsureRelay(url)
+	if err != nil {
+		return RelayCount{Relay: url, Error: err.Error()}
+	}
+	count, registers, err := relay.Count(ctx, filter, nostr.SubscriptionOptions{Label: "count"})
+	if err != nil {
+		sys.RelayLimiter.Observe(url, err.Error())
+		return RelayCount{Relay: url, Error: err.Error()}
+	}
+
+	rc := RelayCount{Relay: url, Count: count}
+	// NIP-45 registers are always 256 bytes, anything else is unusable
+	if len(registers) == 256 {
+		rc.HLL = true
+		rc.registers = registers
+	}
+	return rc
+}
+
+func aggregateCounts(relays []RelayCount) CountResult {
+	result := CountResult{Relays: relays}
+
+	var hll *hyperloglog.HyperLogLog
+	for _, rc := range relays {
+		if rc.Error != "" {
+			continue
+		}
+		result.Count = max(result.Count, uint64(rc.Count))
+		if rc.HLL {
+			if hll == nil {
+				// the offset only matters when adding pubkeys, not when merging
+				hll = hyperloglog.New(0)
+			}
+			hll.MergeRegisters(rc.registers)
+		}
+	}
+
+	if hll != nil {
+		result.Count = max(result.Count, hll.Count())
+		result.Estimate = true
+	}
+	return result
+}
+
+// CountRelaysForFilter returns the relays to send a COUNT for filter to: the count relays,
+// which are known to support NIP-45, followed by the ones RelaysForFilter picks.
+func (sys *System) CountRelaysForFilter(ctx context.Context, filter nostr.Filter) []string {
+	relays := append([]string{}, sys.CountRelays.URLs...)
+	return nostr.AppendUnique(relays, sys.defaultRelaysForFilter(ctx, filter)...)
+}
+
+// CountFollowers estimates how many people follow pubkey by counting the follow lists
+// that tag it. Only the count relays are asked: it is shown with every profile, and
+// other relays rarely support NIP-45, so they would only hold it up until ctx ends.
+func (sys *System) CountFollowers(ctx context.Context, pubkey nostr.PubKey) CountResult {
+	filter := nostr.Filter{
+		Kinds: []nostr.Kind{nostr.KindFollowList},
+		Tags:  nostr.TagMap{"p": []string{pubkey.Hex()}},
+	}
+	return sys.Count(ctx, sys.CountRelays.URLs, filter)
+}
+
+// CountReplies counts the notes and comments that reference the event id.
+func (sys *System) CountReplies(ctx context.Context, id nostr.ID) CountResult {
+	filter := nostr.Filter{
+		Kinds: []nostr.Kind{nostr.KindTextNote, nostr.KindComment},
+		Tags:  nostr.TagMap{"e": []string{id.Hex()}},
+	}
+	return sys.Count(ctx, sys.CountRelaysForFilter(ctx, filter), filter)
+}
+
+// CountReactions counts the reactions to the event id.
+func (sys *System) CountReactions(ctx context.Context, id nostr.ID) CountResult {
+	filter := nostr.Filter{
+		Kinds: []nostr.Kind{nostr.KindReaction},
+		Tags:  nostr.TagMap{"e": []string{id.Hex()}},
+	}
+	return sys.Count(ctx, sys.CountRelaysForFilter(ctx, filter), filter)
+}
@@ -0,0 +1,65 @@
+package nostr_sdk
+
+import (
+	"strconv"
+	"testing"
+
+	"fiatjaf.com/nostr"
+	"fiatjaf.com/nostr/nip45/hyperloglog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAggregateCounts_PlainCountsUseTheLargest(t *testing.T) {
+	result := aggregateCounts([]RelayCount{
+		{Relay: "wss://a.example.com", Count: 12},
+		{Relay: "wss://b.example.com", Count: 40},
+		{Relay: "wss://c.example.com", Error: "unsupported: COUNT"},
+	})
+
+	require.Equal(t, uint64(40), result.Count)
+	require.False(t, result.Estimate)
+	require.Equal(t, 2, result.Answered())
+	require.Len(t, result.Relays, 3)
+	require.Equal(t, "40", result.String())
+
+	result.Estimate = true
+	require.Equal(t, "~40", result.String())
+}
+
+func TestAggregateCounts_NoAnswers(t *testing.T) {
+	result := aggregateCounts([]RelayCount{
+		{Relay: "wss://a.example.com", Error: "context deadline exceeded"},
+	})
+
+	require.Zero(t, result.Count)
+	require.False(t, result.Estimate)
+	require.Zero(t, result.Answered())
+}
+
+func TestAggregateCounts_MergesHyperLogLog(t *testing.T) {
+	// two relays that each saw 2000 followers, 1000 of them in common
+	pubkeys := make([]nostr.PubKey, 3000)
+	for i := range pubkeys {
+		pubkeys[i] = nostr.GetPublicKey(nostr.Generate())
+	}
+	registers := func(pks []nostr.PubKey) []byte {
+		hll := hyperloglog.New(8)
+		for _, pk := range pks {
+			hll.Add(pk)
+		}
+		return hll.GetRegisters()
+	}
+
+	result := aggregateCounts([]RelayCount{
+		{Relay: "wss://a.example.com", Count: 2000, HLL: true, registers: registers(pubkeys[:2000])},
+		{Relay: "wss://b.example.com", Count: 2000, HLL: true, registers: registers(pubkeys[1000:])},
+		{Relay: "wss://c.example.com", Count: 150},
+	})
+
+	require.True(t, result.Estimate)
+	require.Equal(t, 3, result.Answered())
+	// the union is 3000; with 256 registers the standard error is about 6.5%, so allow
+	// for several of them, but the overlap must not be counted twice
+	require.InEpsilon(t, 3000, float64(result.Count), 0.25)
+	require.Equal(t, "~"+strconv.FormatUint(result.Count, 10), result.String())
+}
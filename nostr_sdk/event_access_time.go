@@ -1,9 +1,12 @@
 package nostr_sdk
 
 import (
+	"context"
 	"encoding/binary"
+	"errors"
 
 	"fiatjaf.com/nostr"
+	"fiatjaf.com/nostr/eventstore"
 )
 
 const eventAccessTimePrefix = byte('a')
@@ -54,3 +57,20 @@ func (sys *System) EraseAccessTime(id nostr.ID) error {
 	key := makeEventAccessTimeKey(id)
 	return sys.KVStore.Delete(key)
 }
+
+// accessTrackingPublisher records the access time of the events saved to the local store,
+// so events fetched from relays are not the first to go at the next garbage collection.
+// Events that are only seen on relays get none, which keeps the KVStore from growing
+// with every event passing by.
+type accessTrackingPublisher struct {
+	nostr.Publisher
+	sys *System
+}
+
+func (p accessTrackingPublisher) Publish(ctx context.Context, evt nostr.Event) error {
+	err := p.Publisher.Publish(ctx, evt)
+	if err == nil || errors.Is(err, eventstore.ErrDupEvent) {
+		p.sys.TrackEventAccessTime(evt.ID)
+	}
+	return err
+}
@@ -0,0 +1,226 @@
+package nostr_sdk
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"iter"
+	"net"
+	"sync"
+	"sync/atomic"
+
+	"fiatjaf.com/nostr"
+	"fiatjaf.com/nostr/nip77"
+)
+
+const eventSyncCheckpointPrefix = byte('n')
+
+const (
+	// eventSyncPageSize is the limit of each REQ when a relay has no NIP-77 support.
+	eventSyncPageSize = 500
+	// eventSyncSecondLimit is the limit when a full page falls within a single second.
+	eventSyncSecondLimit = 5000
+	// eventSyncMaxPages bounds the paging fallback on a first sync, when there is no checkpoint.
+	eventSyncMaxPages = 20
+	// eventSyncConcurrency bounds how many relays are synced at the same time.
+	eventSyncConcurrency = 4
+)
+
+// EventSyncResult describes the outcome of syncing one filter with one relay.
+type EventSyncResult struct {
+	Relay      string
+	Negentropy bool // false when the relay did not support NIP-77 and was paged instead
+	Downloaded int
+	Uploaded   int
+	Err        error
+}
+
+// SyncEvents reconciles the local store with each relay for its filter. Relays that
+// support NIP-77 exchange negentropy fingerprints so only the missing events are
+// transferred; the others are paged backwards with until, down to the time of the last
+// successful sync with that relay and filter. Events are only sent to relays with upload
+// set, and only on the negentropy path.
+func (sys *System) SyncEvents(ctx context.Context, targets []nostr.DirectedFilter, upload bool) []EventSyncResult {
+	results := make([]EventSyncResult, len(targets))
+	sem := make(chan struct{}, eventSyncConcurrency)
+	wg := sync.WaitGroup{}
+
+	for i, target := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			// both paths only fetch what changed since we started, so remember that time
+			started := nostr.Now()
+			result := sys.syncEventsNegentropy(ctx, target, upload)
+			if result.Err != nil && negentropyRefused(ctx, result.Err) {
+				negErr := result.Err
+				result = sys.syncEventsPaging(ctx, target)
+				if result.Err != nil {
+					result.Err = fmt.Errorf("%w (after NIP-77 failed: %v)", result.Err, negErr)
+				}
+			}
+			if result.Err == nil {
+				sys.KVStore.Set(makeEventSyncKey(target), encodeTimestamp(started))
+			}
+			results[i] = result
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
+
+// localSyncStore is what negentropy reconciles against: reads come from the local store
+// and writes go through the Publisher so the search index stays in sync.
+type localSyncStore struct {
+	sys *System
+}
+
+func (s *localSyncStore) QueryEvents(filter nostr.Filter) iter.Seq[nostr.Event] {
+	return s.sys.Store.QueryEvents(filter, 1_000_000)
+}
+
+func (s *localSyncStore) Publish(ctx context.Context, evt nostr.Event) error {
+	return s.sys.Publisher.Publish(ctx, evt)
+}
+
+// negentropyRefused tells whether a NIP-77 sync failed because the relay turned it down,
+// in which case paging may still work, rather than because the relay could not be
+// reached, timed out or ctx was cancelled.
+func negentropyRefused(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var netErr net.Error
+	return !errors.As(err, &netErr)
+}
+
+func (sys *System) syncEventsNegentropy(ctx context.Context, target nostr.DirectedFilter, upload bool) EventSyncResult {
+	result := EventSyncResult{Relay: target.Relay, Negentropy: true}
+
+	// connection problems are reported as they are, not taken for missing NIP-77 support
+	if _, err := sys.Pool.EnsureRelay(target.Relay); err != nil {
+		result.Err = err
+		return result
+	}
+	local := &localSyncStore{sys}
+	var downloaded, uploaded atomic.Int32
+
+	result.Err = nip77.NegentropySync(ctx, target.Relay, target.Filter, local, local,
+		func(ctx context.Context, dir nip77.Direction) {
+			down := dir.To == nostr.Publisher(local)
+			if !down && !upload {
+				for range dir.Items {
+				}
+				return
+			}
+
+			counter := &uploaded
+			if down {
+				counter = &downloaded
+			}
+			items := make(chan nostr.ID)
+			go func() {
+				defer close(items)
+				for id := range dir.Items {
+					counter.Add(1)
+					items <- id
+				}
+			}()
+			nip77.SyncEventsFromIDs(ctx, nip77.Direction{From: dir.From, To: dir.To, Items: items})
+		})
+
+	result.Downloaded = int(downloaded.Load())
+	result.Uploaded = int(uploaded.Load())
+	return result
+}
+
+func (sys *System) syncEventsPaging(ctx context.Context, target nostr.DirectedFilter) EventSyncResult {
+	result := EventSyncResult{Relay: target.Relay}
+
+	filter := target.Filter
+	filter.Limit = eventSyncPageSize
+	if data, _ := sys.KVStore.Get(makeEventSyncKey(target)); data != nil {
+		filter.Since = max(filter.Since, decodeTimestamp(data))
+	}
+
+	var incomplete []nostr.Timestamp
+	for page := 0; filter.Since != 0 || page < eventSyncMaxPages; page++ {
+		if err := ctx.Err(); err != nil {
+			result.Err = err
+			return result
+		}
+
+		received, stored, oldest := sys.fetchAndStorePage(ctx, target.Relay, filter, "syncpage")
+		result.Downloaded += stored
+
+		if received < eventSyncPageSize || oldest == 0 || oldest <= filter.Since {
+			break
+		}
+		// until is inclusive, so the events at the boundary come again and are skipped as
+		// stored; but a full page all within that second would never get past it, so that
+		// second is fetched on its own with a bigger limit before moving on
+		if oldest == filter.Until {
+			second := filter
+			second.Since, second.Until = oldest, oldest
+			second.Limit = eventSyncSecondLimit
+			received, stored, _ := sys.fetchAndStorePage(ctx, target.Relay, second, "syncpage")
+			result.Downloaded += stored
+			if received >= second.Limit {
+				incomplete = append(incomplete, oldest)
+			}
+			oldest--
+		}
+		filter.Until = oldest
+	}
+
+	if len(incomplete) > 0 && result.Err == nil {
+		result.Err = fmt.Errorf("relay returned %d or more events within one second at %v, some may be missing", eventSyncSecondLimit, incomplete)
+	}
+	return result
+}
+
+// fetchAndStorePage runs one REQ against relay and stores the events we did not have yet.
+// oldest is the created_at of the oldest event received, or filter.Until if there were none.
+func (sys *System) fetchAndStorePage(ctx context.Context, relay string, filter nostr.Filter, label string) (received, stored int, oldest nostr.Timestamp) {
+	oldest = filter.Until
+	for ie := range sys.FetchManyLimited(ctx, []string{relay}, filter, nostr.SubscriptionOptions{
+		Label: label,
+	}) {
+		received++
+		if oldest == 0 || ie.Event.CreatedAt < oldest {
+			oldest = ie.Event.CreatedAt
+		}
+		if sys.hasStoredEvent(ie.Event.ID) {
+			continue
+		}
+		sys.Publisher.Publish(ctx, ie.Event)
+		stored++
+	}
+	return received, stored, oldest
+}
+
+func (sys *System) hasStoredEvent(id nostr.ID) bool {
+	for range sys.Store.QueryEvents(nostr.Filter{IDs: []nostr.ID{id}}, 1) {
+		return true
+	}
+	return false
+}
+
+// makeEventSyncKey identifies a relay and filter pair, so changing the filter
+// (e.g. following someone new) starts a fresh sync instead of resuming.
+func makeEventSyncKey(target nostr.DirectedFilter) []byte {
+	h := sha256.New()
+	h.Write([]byte(target.Relay))
+	h.Write([]byte{0})
+	h.Write([]byte(target.Filter.String()))
+
+	key := make([]byte, 1+8)
+	key[0] = eventSyncCheckpointPrefix
+	copy(key[1:], h.Sum(nil)[0:8])
+	return key
+}
@@ -0,0 +1,135 @@
+package nostr_sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"fiatjaf.com/nostr"
+	"fiatjaf.com/nostr/eventstore/slicestore"
+	"fiatjaf.com/nostr/khatru"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncEvents(t *testing.T) {
+	const relayURL = "ws://localhost:48492"
+
+	relay := khatru.NewRelay()
+	db := &slicestore.SliceStore{}
+	db.Init()
+	defer db.Close()
+	relay.UseEventstore(db, 4000)
+
+	started := make(chan bool)
+	go func() {
+		err := relay.Start("127.0.0.1", 48492, started)
+		require.NoError(t, err)
+	}()
+	defer relay.Shutdown(context.Background())
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	sk := nostr.Generate()
+	pk := nostr.GetPublicKey(sk)
+	other := nostr.Generate()
+
+	sys := newFetchEventsTestSystem(t)
+	defer sys.Close()
+
+	base := nostr.Now() - 1000
+	for i := range 30 {
+		evt := nostr.Event{Kind: 1, CreatedAt: base + nostr.Timestamp(i), Content: "note"}
+		require.NoError(t, evt.Sign(sk))
+		require.NoError(t, db.SaveEvent(evt))
+		if i%3 == 0 {
+			// some of them we already have
+			require.NoError(t, sys.Store.SaveEvent(evt))
+		}
+	}
+	noise := nostr.Event{Kind: 1, CreatedAt: base, Content: "not ours"}
+	require.NoError(t, noise.Sign(other))
+	require.NoError(t, db.SaveEvent(noise))
+
+	targets := []nostr.DirectedFilter{{Relay: relayURL, Filter: nostr.Filter{Authors: []nostr.PubKey{pk}}}}
+
+	results := sys.SyncEvents(ctx, targets, false)
+	require.Len(t, results, 1)
+	require.NoError(t, results[0].Err)
+	require.Equal(t, 20, results[0].Downloaded)
+	require.Zero(t, results[0].Uploaded)
+
+	stored := 0
+	for evt := range sys.Store.QueryEvents(nostr.Filter{}, 100) {
+		require.Equal(t, pk, evt.PubKey)
+		stored++
+	}
+	require.Equal(t, 30, stored)
+
+	checkpoint, err := sys.KVStore.Get(makeEventSyncKey(targets[0]))
+	require.NoError(t, err)
+	require.NotNil(t, checkpoint)
+
+	results = sys.SyncEvents(ctx, targets, false)
+	require.NoError(t, results[0].Err)
+	require.Zero(t, results[0].Downloaded)
+}
+
+func TestSyncEventsPagingSameSecond(t *testing.T) {
+	const relayURL = "ws://localhost:48496"
+
+	relay := khatru.NewRelay()
+	db := &slicestore.SliceStore{}
+	db.Init()
+	defer db.Close()
+	relay.UseEventstore(db, eventSyncSecondLimit)
+
+	started := make(chan bool)
+	go func() {
+		err := relay.Start("127.0.0.1", 48496, started)
+		require.NoError(t, err)
+	}()
+	defer relay.Shutdown(context.Background())
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	sk := nostr.Generate()
+	pk := nostr.GetPublicKey(sk)
+	sys := newFetchEventsTestSystem(t)
+	defer sys.Close()
+
+	// more than a page within one second, with older events below it
+	crowded := nostr.Now() - 100
+	for i := range eventSyncPageSize + 20 {
+		evt := nostr.Event{Kind: 1, CreatedAt: crowded, Content: fmt.Sprintf("note %d", i)}
+		require.NoError(t, evt.Sign(sk))
+		require.NoError(t, db.SaveEvent(evt))
+	}
+	for i := range 5 {
+		evt := nostr.Event{Kind: 1, CreatedAt: crowded - 10 - nostr.Timestamp(i), Content: "older"}
+		require.NoError(t, evt.Sign(sk))
+		require.NoError(t, db.SaveEvent(evt))
+	}
+
+	target := nostr.DirectedFilter{Relay: relayURL, Filter: nostr.Filter{Authors: []nostr.PubKey{pk}}}
+	result := sys.syncEventsPaging(ctx, target)
+	require.NoError(t, result.Err)
+	require.Equal(t, eventSyncPageSize+25, result.Downloaded)
+}
+
+func TestNegentropyRefused(t *testing.T) {
+	ctx := context.Background()
+	require.True(t, negentropyRefused(ctx, errors.New("relay returned a NEG-ERR: unsupported")))
+	require.False(t, negentropyRefused(ctx, context.DeadlineExceeded))
+	require.False(t, negentropyRefused(ctx, &net.OpError{Op: "dial", Err: errors.New("connection refused")}))
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	require.False(t, negentropyRefused(cancelled, errors.New("relay returned a NEG-ERR: unsupported")))
+}
@@ -3,6 +3,7 @@ package nostr_sdk
 import (
 	"context"
 	"slices"
+	"sync"
 	"sync/atomic"
 
 	"fiatjaf.com/nostr"
@@ -92,12 +93,16 @@ func (sys *System) StreamLiveFeed(
 	return events, nil
 }
 
-// FetchFeedNextPage fetches historical events from the given pubkeys in descending order starting from the
+// feedPageWorkers bounds how many authors FetchFeedPage queries at the same time.
+const feedPageWorkers = 16
+
+// FetchFeedPage fetches historical events from the given pubkeys in descending order starting from the
 // given until timestamp. The limit argument is just a hint of how much content you want for the entire list,
 // it isn't guaranteed that this quantity of events will be returned -- it could be more or less.
 //
 // It relies on KVStore's latestKey and oldestKey in order to determine if we should go to relays to ask
-// for events or if we should just return what we have stored locally.
+// for events or if we should just return what we have stored locally. Authors are queried concurrently,
+// at most feedPageWorkers at a time.
 func (sys *System) FetchFeedPage(
 	ctx context.Context,
 	pubkeys []nostr.PubKey,
@@ -106,66 +111,198 @@ func (sys *System) FetchFeedPage(
 	totalLimit int,
 ) ([]nostr.Event, error) {
 	limitPerKey := PerQueryLimitInBatch(totalLimit, len(pubkeys))
-	events := make([]nostr.Event, 0, len(pubkeys)*limitPerKey)
-	results := make(chan nostr.Event)
-
-	for _, pubkey := range pubkeys {
-		oldestKey := makePubkeyStreamKey(pubkeyStreamOldestPrefix, pubkey)
-		var oldestTimestamp nostr.Timestamp
+	perAuthor := make([][]nostr.Event, len(pubkeys))
 
-		if data, _ := sys.KVStore.Get(oldestKey); data != nil {
-			oldestTimestamp = decodeTimestamp(data)
-			if oldestTimestamp == 0 {
-				oldestTimestamp = nostr.Now()
+	jobs := make(chan int)
+	wg := sync.WaitGroup{}
+	for range min(feedPageWorkers, len(pubkeys)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				// each worker writes only to its own index, no locking needed
+				perAuthor[i] = sys.fetchAuthorFeedPage(ctx, pubkeys[i], kinds, until, limitPerKey)
 			}
+		}()
+	}
+
+feed:
+	for i := range pubkeys {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
 		}
+	}
+	close(jobs)
+	wg.Wait()
 
-		filter := nostr.Filter{Authors: []nostr.PubKey{pubkey}, Kinds: kinds}
-		if until > oldestTimestamp {
-			filter.Until = until
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return mergeFeedPage(perAuthor, limitPerKey, totalLimit), nil
+}
 
-			count := 0
-			for evt := range sys.Store.QueryEvents(filter, limitPerKey) {
-				results <- evt
-				count++
-				if count >= limitPerKey {
-					break
-				}
-			}
+// fetchAuthorFeedPage returns up to limit events by pubkey older than until, reading the local
+// store for the range the KVStore cursors say we already have and going to the relays otherwise.
+func (sys *System) fetchAuthorFeedPage(
+	ctx context.Context,
+	pubkey nostr.PubKey,
+	kinds []nostr.Kind,
+	until nostr.Timestamp,
+	limit int,
+) []nostr.Event {
+	oldestKey := makePubkeyStreamKey(pubkeyStreamOldestPrefix, pubkey)
+	latestKey := makePubkeyStreamKey(pubkeyStreamLatestPrefix, pubkey)
+
+	var oldest, latest nostr.Timestamp
+	if data, _ := sys.KVStore.Get(oldestKey); data != nil {
+		oldest = decodeTimestamp(data)
+		if oldest == 0 {
+			oldest = nostr.Now()
 		}
+	}
+	if data, _ := sys.KVStore.Get(latestKey); data != nil {
+		latest = decodeTimestamp(data)
+	}
 
-		relays := sys.FetchOutboxRelays(ctx, pubkey, 2)
-		if len(relays) == 0 {
-			continue
+	events := make([]nostr.Event, 0, limit)
+	seen := make(map[nostr.ID]struct{}, limit)
+	add := func(evt nostr.Event) {
+		if _, ok := seen[evt.ID]; ok || (until != 0 && evt.CreatedAt >= until) {
+			return
 		}
-		filter.Until = oldestTimestamp + 1
-		filter.Since = 0
-		for ie := range sys.Pool.FetchMany(ctx, relays, filter, nostr.SubscriptionOptions{
+		seen[evt.ID] = struct{}{}
+		events = append(events, evt)
+	}
+
+	filter := nostr.Filter{Authors: []nostr.PubKey{pubkey}, Kinds: kinds}
+	relays := sys.FetchOutboxRelays(ctx, pubkey, 2)
+
+	// the head page asks the relays for what was published since the last fetch
+	// before relying on the store
+	if until == 0 && latest != 0 && len(relays) > 0 {
+		head := filter
+		head.Since = latest
+		head.Limit = limit
+		headOldest, headLatest, n := nostr.Timestamp(0), latest, 0
+		for ie := range sys.FetchManyLimited(ctx, relays, head, nostr.SubscriptionOptions{
 			Label: "feedpage",
 		}) {
 			sys.Publisher.Publish(ctx, ie.Event)
-
-			if ie.Event.CreatedAt < oldestTimestamp {
-				oldestTimestamp = ie.Event.CreatedAt
+			if headOldest == 0 || ie.Event.CreatedAt < headOldest {
+				headOldest = ie.Event.CreatedAt
 			}
+			headLatest = max(headLatest, ie.Event.CreatedAt)
+			add(ie.Event)
+			n++
+		}
+		if n >= limit {
+			// there may be more events between the stored range and this page, so
+			// only this page is known to be complete now
+			oldest = headOldest
+			sys.KVStore.Set(oldestKey, encodeTimestamp(oldest))
+		}
+		if headLatest != latest {
+			latest = headLatest
+			sys.KVStore.Set(latestKey, encodeTimestamp(latest))
+		}
+	}
 
-			if ie.Event.CreatedAt < until {
-				results <- ie.Event
+	// everything between oldest and latest was already fetched and stored
+	if oldest != 0 && (until == 0 || until > oldest) {
+		if until != 0 {
+			filter.Until = until
+		}
+		for evt := range sys.Store.QueryEvents(filter, limit) {
+			add(evt)
+			if len(events) >= limit {
+				return sortFeedPage(events, limit)
 			}
 		}
-		sys.KVStore.Set(oldestKey, encodeTimestamp(oldestTimestamp))
 	}
 
-	close(results)
-	seen := make(map[nostr.ID]bool)
-	for ev := range results {
-		if seen[ev.ID] {
-			continue
+	if len(relays) == 0 {
+		return sortFeedPage(events, limit)
+	}
+
+	filter.Limit = limit
+	filter.Until = until
+	if oldest != 0 && (until == 0 || oldest+1 < until) {
+		filter.Until = oldest + 1
+	}
+
+	newOldest, newLatest := oldest, latest
+	for ie := range sys.FetchManyLimited(ctx, relays, filter, nostr.SubscriptionOptions{
+		Label: "feedpage",
+	}) {
+		sys.Publisher.Publish(ctx, ie.Event)
+
+		if newOldest == 0 || ie.Event.CreatedAt < newOldest {
+			newOldest = ie.Event.CreatedAt
+		}
+		if until == 0 && ie.Event.CreatedAt > newLatest {
+			newLatest = ie.Event.CreatedAt
 		}
-		seen[ev.ID] = true
-		events = append(events, ev)
+		add(ie.Event)
+	}
+
+	if newOldest != oldest {
+		sys.KVStore.Set(oldestKey, encodeTimestamp(newOldest))
 	}
+	if newLatest != latest {
+		sys.KVStore.Set(latestKey, encodeTimestamp(newLatest))
+	}
+
+	return sortFeedPage(events, limit)
+}
+
+// sortFeedPage orders events newest first and keeps at most limit of them.
+func sortFeedPage(events []nostr.Event, limit int) []nostr.Event {
 	slices.SortFunc(events, nostr.CompareEventReverse)
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return events
+}
 
-	return events, nil
+// mergeFeedPage combines the per-author results into one page ordered newest first.
+//
+// An author that returned a full batch may have more events right below its oldest one,
+// so the page stops there: anything older could hide a gap that the next page would skip.
+// The page is then cut to totalLimit, keeping events that share the last timestamp together.
+func mergeFeedPage(perAuthor [][]nostr.Event, limitPerKey int, totalLimit int) []nostr.Event {
+	var cutoff nostr.Timestamp
+	for _, events := range perAuthor {
+		if len(events) >= limitPerKey && len(events) > 0 {
+			oldest := events[0].CreatedAt
+			for _, evt := range events[1:] {
+				oldest = min(oldest, evt.CreatedAt)
+			}
+			cutoff = max(cutoff, oldest)
+		}
+	}
+
+	seen := make(map[nostr.ID]struct{})
+	page := make([]nostr.Event, 0, totalLimit)
+	for _, events := range perAuthor {
+		for _, evt := range events {
+			if _, ok := seen[evt.ID]; ok || evt.CreatedAt < cutoff {
+				continue
+			}
+			seen[evt.ID] = struct{}{}
+			page = append(page, evt)
+		}
+	}
+	slices.SortFunc(page, nostr.CompareEventReverse)
+
+	if totalLimit > 0 && len(page) > totalLimit {
+		last := page[totalLimit-1].CreatedAt
+		end := totalLimit
+		for end < len(page) && page[end].CreatedAt == last {
+			end++
+		}
+		page = page[:end]
+	}
+	return page
 }
@@ -2,12 +2,16 @@ package nostr_sdk
 
 import (
 	"context"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"fiatjaf.com/nostr"
 	"fiatjaf.com/nostr/eventstore/slicestore"
 	"fiatjaf.com/nostr/khatru"
+	"github.com/jerry-harm/nosmec/nostr_sdk/hints"
 	"github.com/stretchr/testify/require"
 )
 
@@ -197,3 +201,170 @@ func TestStreamLiveFeed(t *testing.T) {
 		}
 	}
 }
+
+func TestFetchFeedPageManyAuthors(t *testing.T) {
+	const (
+		authors         = 300
+		eventsPerAuthor = 3
+		relayURL        = "ws://localhost:48491"
+	)
+
+	relay := khatru.NewRelay()
+	db := &slicestore.SliceStore{}
+	db.Init()
+	defer db.Close()
+	relay.UseEventstore(db, 4000)
+
+	started := make(chan bool)
+	go func() {
+		err := relay.Start("127.0.0.1", 48491, started)
+		require.NoError(t, err)
+	}()
+	defer relay.Shutdown(context.Background())
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	sys := newFetchEventsTestSystem(t)
+	defer sys.Close()
+	sys.RelayListRelays = NewRelayStream(relayURL)
+	sys.RelayLimiter = nil
+
+	base := nostr.Now() - 100_000
+	pubkeys := make([]nostr.PubKey, authors)
+	for i := range pubkeys {
+		sk := nostr.Generate()
+		pubkeys[i] = nostr.GetPublicKey(sk)
+		sys.Hints.Save(pubkeys[i], relayURL, hints.LastInRelayList, nostr.Now())
+
+		for j := range eventsPerAuthor {
+			evt := nostr.Event{
+				CreatedAt: base + nostr.Timestamp(j*authors+i),
+				Kind:      1,
+				Content:   "note",
+			}
+			require.NoError(t, evt.Sign(sk))
+			require.NoError(t, db.SaveEvent(evt))
+		}
+	}
+
+	// several readers paging at once must not race on the cursors or the store
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := sys.FetchFeedPage(ctx, pubkeys, []nostr.Kind{1}, 0, 60)
+			require.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	seen := make(map[nostr.ID]bool)
+	until := nostr.Timestamp(0)
+	for page := 0; len(seen) < authors*eventsPerAuthor; page++ {
+		require.Less(t, page, 100, "paging does not make progress")
+
+		events, err := sys.FetchFeedPage(ctx, pubkeys, []nostr.Kind{1}, until, 60)
+		require.NoError(t, err)
+		require.NotEmpty(t, events)
+		require.True(t, slices.IsSortedFunc(events, nostr.CompareEventReverse))
+
+		for _, evt := range events {
+			require.False(t, seen[evt.ID], "event %s returned twice", evt.ID)
+			if until != 0 {
+				require.Less(t, evt.CreatedAt, until)
+			}
+			seen[evt.ID] = true
+		}
+		until = events[len(events)-1].CreatedAt
+	}
+
+	for _, pk := range pubkeys {
+		data, err := sys.KVStore.Get(makePubkeyStreamKey(pubkeyStreamOldestPrefix, pk))
+		require.NoError(t, err)
+		require.NotNil(t, data)
+		require.GreaterOrEqual(t, decodeTimestamp(data), base)
+	}
+}
+
+func TestFetchFeedPageRefreshesHead(t *testing.T) {
+	const relayURL = "ws://localhost:48494"
+
+	relay := khatru.NewRelay()
+	db := &slicestore.SliceStore{}
+	db.Init()
+	defer db.Close()
+	relay.UseEventstore(db, 100)
+
+	started := make(chan bool)
+	go func() {
+		err := relay.Start("127.0.0.1", 48494, started)
+		require.NoError(t, err)
+	}()
+	defer relay.Shutdown(context.Background())
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	sys := newFetchEventsTestSystem(t)
+	defer sys.Close()
+	sys.RelayListRelays = NewRelayStream(relayURL)
+
+	sk := nostr.Generate()
+	pk := nostr.GetPublicKey(sk)
+	sys.Hints.Save(pk, relayURL, hints.LastInRelayList, nostr.Now())
+
+	publish := func(createdAt nostr.Timestamp) nostr.Event {
+		evt := nostr.Event{CreatedAt: createdAt, Kind: 1, Content: "note"}
+		require.NoError(t, evt.Sign(sk))
+		require.NoError(t, db.SaveEvent(evt))
+		return evt
+	}
+	base := nostr.Now() - 1000
+	for i := range 5 {
+		publish(base + nostr.Timestamp(i))
+	}
+
+	events, err := sys.FetchFeedPage(ctx, []nostr.PubKey{pk}, []nostr.Kind{1}, 0, 3)
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+
+	// a note published after the first run must show up on the next head page
+	newer := publish(base + 100)
+	events, err = sys.FetchFeedPage(ctx, []nostr.PubKey{pk}, []nostr.Kind{1}, 0, 3)
+	require.NoError(t, err)
+	require.NotEmpty(t, events)
+	require.Equal(t, newer.ID, events[0].ID)
+	require.True(t, slices.IsSortedFunc(events, nostr.CompareEventReverse))
+}
+
+func TestMergeFeedPage(t *testing.T) {
+	alice := mustPubKey(t, strings.Repeat("a", 64))
+	bob := mustPubKey(t, strings.Repeat("b", 64))
+	event := func(id string, pk nostr.PubKey, ts nostr.Timestamp) nostr.Event {
+		return nostr.Event{ID: mustID(t, strings.Repeat(id, 64)), PubKey: pk, CreatedAt: ts}
+	}
+
+	// alice returned a full batch, so nothing older than her oldest event can be trusted
+	perAuthor := [][]nostr.Event{
+		{event("1", alice, 50), event("2", alice, 40)},
+		{event("3", bob, 45), event("4", bob, 30), event("1", alice, 50)},
+	}
+	page := mergeFeedPage(perAuthor, 2, 10)
+	require.Len(t, page, 3)
+	require.Equal(t, nostr.Timestamp(50), page[0].CreatedAt)
+	require.Equal(t, nostr.Timestamp(45), page[1].CreatedAt)
+	require.Equal(t, nostr.Timestamp(40), page[2].CreatedAt)
+
+	// events sharing the last timestamp stay on the same page
+	perAuthor = [][]nostr.Event{
+		{event("1", alice, 50), event("2", alice, 40)},
+		{event("3", bob, 40), event("4", bob, 30)},
+	}
+	page = mergeFeedPage(perAuthor, 3, 2)
+	require.Len(t, page, 3)
+	require.Equal(t, nostr.Timestamp(40), page[2].CreatedAt)
+}
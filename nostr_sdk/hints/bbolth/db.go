@@ -210,6 +210,67 @@ func (bh *BoltHints) GetAllKnownRelays() ([]string, error) {
 	return relays, nil
 }
 
+func (bh *BoltHints) Iterate(visit func(pubkey nostr.PubKey, relay string, scores [4]nostr.Timestamp) error) error {
+	return bh.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(hintsBucket)
+		c := b.Cursor()
+
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			if len(k) <= 32 {
+				continue
+			}
+			pubkey, relay := parseKey(k)
+			if err := visit(pubkey, relay, parseValue(v)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (bh *BoltHints) Delete(pubkey nostr.PubKey) error {
+	return bh.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(hintsBucket)
+		c := b.Cursor()
+
+		prefix := pubkey[:]
+		for k, _ := c.Seek(prefix); k != nil && len(k) >= 32 && string(k[:32]) == string(prefix); k, _ = c.Seek(prefix) {
+			if err := c.Delete(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (bh *BoltHints) Prune(olderThan nostr.Timestamp) (int, error) {
+	removed := 0
+	err := bh.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(hintsBucket)
+		c := b.Cursor()
+
+		// collect first, deleting while walking the cursor would skip entries
+		var stale [][]byte
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			if hints.Latest(parseValue(v)) < olderThan {
+				stale = append(stale, append([]byte(nil), k...))
+			}
+		}
+
+		for _, k := range stale {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		removed = len(stale)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return removed, nil
+}
+
 type timestamps [4]nostr.Timestamp
 
 func (tss timestamps) sum() int64 {
@@ -0,0 +1,105 @@
+package hints
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"fiatjaf.com/nostr"
+)
+
+const exportVersion = 1
+
+type exportFile struct {
+	Version int           `json:"version"`
+	Entries []exportEntry `json:"entries"`
+}
+
+type exportEntry struct {
+	PubKey string                     `json:"pubkey"`
+	Relay  string                     `json:"relay"`
+	Scores map[string]nostr.Timestamp `json:"scores"`
+}
+
+// Export writes every entry in db to w as a single JSON document.
+func Export(db HintsDB, w io.Writer) (int, error) {
+	file := exportFile{Version: exportVersion, Entries: make([]exportEntry, 0, 64)}
+
+	err := db.Iterate(func(pubkey nostr.PubKey, relay string, scores [4]nostr.Timestamp) error {
+		entry := exportEntry{
+			PubKey: pubkey.Hex(),
+			Relay:  relay,
+			Scores: make(map[string]nostr.Timestamp, len(scores)),
+		}
+		for i, ts := range scores {
+			if ts == 0 {
+				continue
+			}
+			entry.Scores[HintKey(i).String()] = ts
+		}
+		file.Entries = append(file.Entries, entry)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(file); err != nil {
+		return 0, err
+	}
+	return len(file.Entries), nil
+}
+
+// Import reads a document produced by Export and saves its entries into db.
+// Existing entries are merged: for each key the most recent timestamp wins.
+func Import(db HintsDB, r io.Reader) (int, error) {
+	var file exportFile
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return 0, fmt.Errorf("failed to decode hints export: %w", err)
+	}
+	if file.Version != exportVersion {
+		return 0, fmt.Errorf("unsupported hints export version %d", file.Version)
+	}
+
+	keys := make(map[string]HintKey, len(KeyBasePoints))
+	for i := range KeyBasePoints {
+		keys[HintKey(i).String()] = HintKey(i)
+	}
+
+	imported := 0
+	for _, entry := range file.Entries {
+		pubkey, err := nostr.PubKeyFromHex(entry.PubKey)
+		if err != nil {
+			return imported, fmt.Errorf("invalid pubkey %q: %w", entry.PubKey, err)
+		}
+		if entry.Relay == "" {
+			continue
+		}
+		for name, ts := range entry.Scores {
+			key, ok := keys[name]
+			if !ok {
+				return imported, fmt.Errorf("unknown hint key %q", name)
+			}
+			db.Save(pubkey, entry.Relay, key, ts)
+		}
+		imported++
+	}
+	return imported, nil
+}
+
+// Copy saves every entry of src into dst, merging with what dst already has.
+func Copy(dst, src HintsDB) (int, error) {
+	copied := 0
+	err := src.Iterate(func(pubkey nostr.PubKey, relay string, scores [4]nostr.Timestamp) error {
+		for i, ts := range scores {
+			if ts != 0 {
+				dst.Save(pubkey, relay, HintKey(i), ts)
+			}
+		}
+		copied++
+		return nil
+	})
+	return copied, err
+}
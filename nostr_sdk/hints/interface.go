@@ -16,4 +16,25 @@ type HintsDB interface {
 	PrintScores()
 	GetDetailedScores(pubkey nostr.PubKey, n int) []RelayScores
 	GetAllKnownRelays() ([]string, error)
+
+	// Iterate visits every (pubkey, relay) entry along with its raw timestamps.
+	Iterate(visit func(pubkey nostr.PubKey, relay string, scores [4]nostr.Timestamp) error) error
+
+	// Delete removes all entries for the given pubkey.
+	Delete(pubkey nostr.PubKey) error
+
+	// Prune removes entries whose most recent timestamp is older than the cutoff
+	// and returns how many entries were removed.
+	Prune(olderThan nostr.Timestamp) (int, error)
+}
+
+// Latest returns the most recent of the given timestamps.
+func Latest(scores [4]nostr.Timestamp) nostr.Timestamp {
+	var latest nostr.Timestamp
+	for _, ts := range scores {
+		if ts > latest {
+			latest = ts
+		}
+	}
+	return latest
 }
@@ -20,7 +20,16 @@ type LMDBHints struct {
 	dbi lmdb.DBI
 }
 
+// DefaultMapSize is the map size used by NewLMDBHints.
+const DefaultMapSize = 1 << 30 // 1GB
+
 func NewLMDBHints(path string) (*LMDBHints, error) {
+	return NewLMDBHintsWithMapSize(path, DefaultMapSize)
+}
+
+// NewLMDBHintsWithMapSize opens the database with a map size of mapSize bytes, the most
+// it can grow to.
+func NewLMDBHintsWithMapSize(path string, mapSize int64) (*LMDBHints, error) {
 	// create directory if it doesn't exist
 	if err := os.MkdirAll(path, 0755); err != nil {
 		return nil, err
@@ -34,7 +43,7 @@ func NewLMDBHints(path string) (*LMDBHints, error) {
 
 	// set max DBs and map size
 	env.SetMaxDBs(1)
-	env.SetMapSize(1 << 30) // 1GB
+	env.SetMapSize(mapSize)
 
 	// open the environment
 	if err := env.Open(path, lmdb.NoTLS|lmdb.WriteMap, 0644); err != nil {
@@ -273,6 +282,94 @@ func (lh *LMDBHints) GetDetailedScores(pubkey nostr.PubKey, n int) []hints.Relay
 	return result
 }
 
+func (lh *LMDBHints) Iterate(visit func(pubkey nostr.PubKey, relay string, scores [4]nostr.Timestamp) error) error {
+	return lh.env.View(func(txn *lmdb.Txn) error {
+		cursor, err := txn.OpenCursor(lh.dbi)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close()
+
+		k, v, err := cursor.Get(nil, nil, lmdb.First)
+		for ; err == nil; k, v, err = cursor.Get(nil, nil, lmdb.Next) {
+			if len(k) <= 32 {
+				continue
+			}
+			pubkey, relay := parseKey(k)
+			if err := visit(pubkey, relay, parseValue(v)); err != nil {
+				return err
+			}
+		}
+		if !lmdb.IsNotFound(err) {
+			return err
+		}
+		return nil
+	})
+}
+
+func (lh *LMDBHints) Delete(pubkey nostr.PubKey) error {
+	return lh.env.Update(func(txn *lmdb.Txn) error {
+		cursor, err := txn.OpenCursor(lh.dbi)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close()
+
+		var keys [][]byte
+		k, _, err := cursor.Get(pubkey[:], nil, lmdb.SetRange)
+		for ; err == nil; k, _, err = cursor.Get(nil, nil, lmdb.Next) {
+			if len(k) < 32 || !bytes.Equal(k[:32], pubkey[:]) {
+				break
+			}
+			keys = append(keys, k)
+		}
+		if err != nil && !lmdb.IsNotFound(err) {
+			return err
+		}
+
+		for _, k := range keys {
+			if err := txn.Del(lh.dbi, k, nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (lh *LMDBHints) Prune(olderThan nostr.Timestamp) (int, error) {
+	removed := 0
+	err := lh.env.Update(func(txn *lmdb.Txn) error {
+		cursor, err := txn.OpenCursor(lh.dbi)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close()
+
+		var stale [][]byte
+		k, v, err := cursor.Get(nil, nil, lmdb.First)
+		for ; err == nil; k, v, err = cursor.Get(nil, nil, lmdb.Next) {
+			if hints.Latest(parseValue(v)) < olderThan {
+				stale = append(stale, k)
+			}
+		}
+		if !lmdb.IsNotFound(err) {
+			return err
+		}
+
+		for _, k := range stale {
+			if err := txn.Del(lh.dbi, k, nil); err != nil {
+				return err
+			}
+		}
+		removed = len(stale)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return removed, nil
+}
+
 type timestamps [4]nostr.Timestamp
 
 func (tss timestamps) sum() int64 {
@@ -287,3 +384,11 @@ func (tss timestamps) sum() int64 {
 	}
 	return sum
 }
+
+// CompactTo writes a compacted copy of the database into dir, which must not contain one yet.
+func (lh *LMDBHints) CompactTo(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	return lh.env.CopyFlag(dir, lmdb.CopyCompact)
+}
@@ -140,6 +140,98 @@ func (db *HintDB) GetAllKnownRelays() ([]string, error) {
 	return relays, nil
 }
 
+func (db *HintDB) Iterate(visit func(pubkey nostr.PubKey, relay string, scores [4]nostr.Timestamp) error) error {
+	type snapshot struct {
+		pubkey nostr.PubKey
+		relay  string
+		scores [4]nostr.Timestamp
+	}
+
+	// copy everything first so visit can call back into the db without deadlocking
+	db.Lock()
+	all := make([]snapshot, 0, len(db.OrderedRelaysByPubKey))
+	for pubkey, entries := range db.OrderedRelaysByPubKey {
+		for _, re := range entries {
+			all = append(all, snapshot{pubkey, db.RelayBySerial[re.Relay], re.Timestamps})
+		}
+	}
+	db.Unlock()
+
+	for _, s := range all {
+		if err := visit(s.pubkey, s.relay, s.scores); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (db *HintDB) Delete(pubkey nostr.PubKey) error {
+	db.Lock()
+	defer db.Unlock()
+
+	delete(db.OrderedRelaysByPubKey, pubkey)
+	db.compact()
+	return nil
+}
+
+func (db *HintDB) Prune(olderThan nostr.Timestamp) (int, error) {
+	db.Lock()
+	defer db.Unlock()
+
+	removed := 0
+	for pubkey, entries := range db.OrderedRelaysByPubKey {
+		kept := entries[:0]
+		for _, re := range entries {
+			if hints.Latest(re.Timestamps) < olderThan {
+				removed++
+				continue
+			}
+			kept = append(kept, re)
+		}
+		if len(kept) == 0 {
+			delete(db.OrderedRelaysByPubKey, pubkey)
+		} else {
+			db.OrderedRelaysByPubKey[pubkey] = kept
+		}
+	}
+
+	if removed > 0 {
+		db.compact()
+	}
+	return removed, nil
+}
+
+// compact drops relays from RelayBySerial that are no longer referenced by any entry.
+// it must be called with the lock held.
+func (db *HintDB) compact() {
+	used := make([]bool, len(db.RelayBySerial))
+	for _, entries := range db.OrderedRelaysByPubKey {
+		for _, re := range entries {
+			used[re.Relay] = true
+		}
+	}
+
+	remap := make([]int, len(db.RelayBySerial))
+	relays := make([]string, 0, len(db.RelayBySerial))
+	for i, relay := range db.RelayBySerial {
+		if !used[i] {
+			continue
+		}
+		remap[i] = len(relays)
+		relays = append(relays, relay)
+	}
+	if len(relays) == len(db.RelayBySerial) {
+		return
+	}
+
+	for _, entries := range db.OrderedRelaysByPubKey {
+		for i := range entries {
+			entries[i].Relay = remap[entries[i].Relay]
+		}
+	}
+	db.RelayBySerial = relays
+}
+
 type RelayEntry struct {
 	Relay      int
 	Timestamps [4]nostr.Timestamp
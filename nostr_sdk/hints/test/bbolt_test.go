@@ -19,3 +19,16 @@ func TestBoltHints(t *testing.T) {
 
 	runTestWith(t, hdb)
 }
+
+func TestBoltHintsMaintenance(t *testing.T) {
+	path := "/tmp/tmpsdkhintsbboltmaintenance"
+	os.RemoveAll(path)
+
+	hdb, err := bbolth.NewBoltHints(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hdb.Close()
+
+	runMaintenanceTestWith(t, hdb)
+}
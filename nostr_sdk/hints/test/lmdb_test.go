@@ -19,3 +19,16 @@ func TestLMDBHints(t *testing.T) {
 
 	runTestWith(t, hdb)
 }
+
+func TestLMDBHintsMaintenance(t *testing.T) {
+	path := "/tmp/tmpsdkhintslmdbmaintenance"
+	os.RemoveAll(path)
+
+	hdb, err := lmdbh.NewLMDBHints(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hdb.Close()
+
+	runMaintenanceTestWith(t, hdb)
+}
@@ -9,3 +9,7 @@ import (
 func TestMemoryHints(t *testing.T) {
 	runTestWith(t, memoryh.NewHintDB())
 }
+
+func TestMemoryHintsMaintenance(t *testing.T) {
+	runMaintenanceTestWith(t, memoryh.NewHintDB())
+}
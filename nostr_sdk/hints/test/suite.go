@@ -1,6 +1,7 @@
 package test
 
 import (
+	"bytes"
 	"testing"
 	"time"
 
@@ -130,3 +131,56 @@ func runTestWith(t *testing.T, hdb hints.HintsDB) {
 	require.Equal(t, []string{relayB, relayA, relayC}, hdb.TopN(key1, 3))
 	require.Equal(t, []string{relayA, relayB}, hdb.TopN(key3, 3))
 }
+
+func runMaintenanceTestWith(t *testing.T, hdb hints.HintsDB) {
+	key1 := nostr.MustPubKeyFromHex("0000000000000000000000000000000000000000000000000000000000000011")
+	key2 := nostr.MustPubKeyFromHex("0000000000000000000000000000000000000000000000000000000000000012")
+	const relayA = "wss://aaa.com"
+	const relayB = "wss://bbb.net"
+	const relayC = "wss://ccc.org"
+
+	day := nostr.Timestamp((24 * time.Hour).Seconds())
+	now := nostr.Now()
+
+	hdb.Save(key1, relayA, hints.LastInRelayList, now-day)
+	hdb.Save(key1, relayB, hints.LastInHint, now-day*90)
+	hdb.Save(key2, relayB, hints.LastInHint, now-day*2)
+	hdb.Save(key2, relayC, hints.MostRecentEventFetched, now-day*120)
+
+	count := 0
+	require.NoError(t, hdb.Iterate(func(pubkey nostr.PubKey, relay string, scores [4]nostr.Timestamp) error {
+		count++
+		require.NotZero(t, hints.Latest(scores))
+		return nil
+	}))
+	require.Equal(t, 4, count)
+
+	// only entries not touched in the last 30 days go away
+	removed, err := hdb.Prune(now - day*30)
+	require.NoError(t, err)
+	require.Equal(t, 2, removed)
+	require.Equal(t, []string{relayA}, hdb.TopN(key1, 3))
+	require.Equal(t, []string{relayB}, hdb.TopN(key2, 3))
+
+	relays, err := hdb.GetAllKnownRelays()
+	require.NoError(t, err)
+	require.Equal(t, []string{relayA, relayB}, relays)
+
+	require.NoError(t, hdb.Delete(key1))
+	require.Empty(t, hdb.TopN(key1, 3))
+	require.Equal(t, []string{relayB}, hdb.TopN(key2, 3))
+
+	// exporting and importing into the same db is a no-op merge
+	var buf bytes.Buffer
+	exported, err := hints.Export(hdb, &buf)
+	require.NoError(t, err)
+	require.Equal(t, 1, exported)
+
+	require.NoError(t, hdb.Delete(key2))
+	require.Empty(t, hdb.TopN(key2, 3))
+
+	imported, err := hints.Import(hdb, &buf)
+	require.NoError(t, err)
+	require.Equal(t, 1, imported)
+	require.Equal(t, []string{relayB}, hdb.TopN(key2, 3))
+}
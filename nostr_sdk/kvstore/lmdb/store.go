@@ -14,7 +14,16 @@ type Store struct {
 	dbi lmdb.DBI
 }
 
+// DefaultMapSize is the map size used by NewStore.
+const DefaultMapSize = 1 << 30 // 1GB
+
 func NewStore(path string) (*Store, error) {
+	return NewStoreWithMapSize(path, DefaultMapSize)
+}
+
+// NewStoreWithMapSize opens the store with a map size of mapSize bytes, the most
+// the database can grow to.
+func NewStoreWithMapSize(path string, mapSize int64) (*Store, error) {
 	// create directory if it doesn't exist
 	if err := os.MkdirAll(path, 0o755); err != nil {
 		return nil, err
@@ -28,7 +37,7 @@ func NewStore(path string) (*Store, error) {
 
 	// set max DBs and map size
 	env.SetMaxDBs(1)
-	env.SetMapSize(1 << 30) // 1GB
+	env.SetMapSize(mapSize)
 
 	// open the environment
 	if err := env.Open(path, lmdb.NoTLS|lmdb.WriteMap, 0o644); err != nil {
@@ -146,3 +155,11 @@ func (s *Store) Iterate(visit func(key, value []byte) error) error {
 		return nil
 	})
 }
+
+// CompactTo writes a compacted copy of the database into dir, which must not contain one yet.
+func (s *Store) CompactTo(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	return s.env.CopyFlag(dir, lmdb.CopyCompact)
+}
@@ -0,0 +1,46 @@
+package nostr_sdk
+
+import (
+	"encoding/binary"
+	"encoding/json"
+
+	"fiatjaf.com/nostr"
+)
+
+const listSnapshotPrefix = byte('s')
+
+// makeListSnapshotKey creates a key for the last synced tags of one of our own lists.
+func makeListSnapshotKey(kind nostr.Kind) []byte {
+	// format: 's' + 2 bytes of kind
+	key := make([]byte, 3)
+	key[0] = listSnapshotPrefix
+	binary.BigEndian.PutUint16(key[1:], uint16(kind))
+	return key
+}
+
+// LoadListSnapshot returns the tags of a list kind (10002, 10050, 3, ...) as they were
+// the last time our local copy and the network agreed. It returns nil if nothing was recorded yet.
+func (sys *System) LoadListSnapshot(kind nostr.Kind) (nostr.Tags, error) {
+	data, err := sys.KVStore.Get(makeListSnapshotKey(kind))
+	if err != nil || data == nil {
+		return nil, err
+	}
+
+	var tags nostr.Tags
+	if err := json.Unmarshal(data, &tags); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// SaveListSnapshot records the tags of a list kind as the new common base for three-way merges.
+func (sys *System) SaveListSnapshot(kind nostr.Kind, tags nostr.Tags) error {
+	if tags == nil {
+		tags = nostr.Tags{}
+	}
+	data, err := json.Marshal(tags)
+	if err != nil {
+		return err
+	}
+	return sys.KVStore.Set(makeListSnapshotKey(kind), data)
+}
@@ -0,0 +1,34 @@
+package nostr_sdk
+
+import (
+	"context"
+
+	"fiatjaf.com/nostr"
+	"fiatjaf.com/nostr/khatru"
+)
+
+// localRelayMaxLimit caps how many events a single REQ can get from the local relay.
+const localRelayMaxLimit = 1000
+
+// NewLocalRelay returns a NIP-01 relay serving the local event store. Anyone can read,
+// including COUNT and, when the store has a search index, NIP-50 search; only events
+// signed by owner are accepted, so other clients cannot fill or delete from our store.
+func (sys *System) NewLocalRelay(owner nostr.PubKey) *khatru.Relay {
+	relay := khatru.NewRelay()
+	relay.Info.Name = "nosmec"
+	relay.Info.Description = "local nosmec event store"
+
+	relay.UseEventstore(sys.Store, localRelayMaxLimit)
+	if sys.HasSearchIndex() {
+		relay.Info.AddSupportedNIP(50)
+	}
+
+	relay.OnEvent = func(ctx context.Context, evt nostr.Event) (reject bool, msg string) {
+		if evt.PubKey != owner {
+			return true, "restricted: this relay only accepts events from its owner"
+		}
+		return false, ""
+	}
+
+	return relay
+}
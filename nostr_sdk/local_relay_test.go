@@ -0,0 +1,81 @@
+package nostr_sdk
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"fiatjaf.com/nostr"
+	eventstorebleve "fiatjaf.com/nostr/eventstore/bleve"
+	"fiatjaf.com/nostr/eventstore/slicestore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalRelay(t *testing.T) {
+	const relayURL = "ws://localhost:48495"
+
+	raw := &slicestore.SliceStore{}
+	require.NoError(t, raw.Init())
+	index := &eventstorebleve.BleveBackend{Path: filepath.Join(t.TempDir(), "index"), RawEventStore: raw}
+	require.NoError(t, index.Init())
+
+	served := NewSystem()
+	served.Store = index
+	defer served.Close()
+	require.True(t, served.HasLocalStore())
+
+	ownerSK := nostr.Generate()
+	owner := nostr.GetPublicKey(ownerSK)
+	relay := served.NewLocalRelay(owner)
+
+	started := make(chan bool)
+	go func() {
+		err := relay.Start("127.0.0.1", 48495, started)
+		require.NoError(t, err)
+	}()
+	defer relay.Shutdown(context.Background())
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client := newFetchEventsTestSystem(t)
+	defer client.Close()
+
+	sign := func(sk nostr.SecretKey, content string) nostr.Event {
+		evt := nostr.Event{Kind: 1, CreatedAt: nostr.Now(), Content: content}
+		require.NoError(t, evt.Sign(sk))
+		return evt
+	}
+
+	// events from anyone else are refused
+	foreign := sign(nostr.Generate(), "let me in")
+	report := client.Publish(ctx, []string{relayURL}, foreign)
+	require.Len(t, report.Results, 1)
+	require.False(t, report.Results[0].OK)
+	require.Contains(t, report.Results[0].Reason, "restricted")
+
+	own := sign(ownerSK, "hello from the local relay")
+	report = client.Publish(ctx, []string{relayURL}, own)
+	require.Len(t, report.Results, 1)
+	require.True(t, report.Results[0].OK, report.Results[0].Reason)
+
+	var got []nostr.ID
+	for re := range client.Pool.FetchMany(ctx, []string{relayURL}, nostr.Filter{Kinds: []nostr.Kind{1}}, nostr.SubscriptionOptions{}) {
+		got = append(got, re.Event.ID)
+	}
+	require.Equal(t, []nostr.ID{own.ID}, got)
+
+	got = nil
+	for re := range client.Pool.FetchMany(ctx, []string{relayURL}, nostr.Filter{Search: "local relay"}, nostr.SubscriptionOptions{}) {
+		got = append(got, re.Event.ID)
+	}
+	require.Equal(t, []nostr.ID{own.ID}, got)
+}
+
+func TestHasLocalStore(t *testing.T) {
+	sys := NewSystem()
+	defer sys.Close()
+	require.False(t, sys.HasLocalStore())
+}
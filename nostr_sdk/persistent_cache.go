@@ -0,0 +1,28 @@
+package nostr_sdk
+
+import (
+	"fiatjaf.com/nostr"
+	cache_kv "github.com/jerry-harm/nosmec/nostr_sdk/cache/kv"
+)
+
+// UsePersistentCaches replaces the profile metadata, list, set and zap provider caches
+// with caches backed by the KVStore, so a new process does not start cold. Bookmarks,
+// pins, mint keys and nutzap info hold values that cannot be gob-encoded and stay in memory.
+//
+// It must be called after the KVStore is set and before the caches are first used.
+func (sys *System) UsePersistentCaches() {
+	kv := sys.KVStore
+
+	sys.MetadataCache = cache_kv.New[ProfileMetadata](kv, 'm', 8000)
+	sys.RelayListCache = cache_kv.New[GenericList[string, Relay]](kv, 'r', 8000)
+	sys.ZapProviderCache = cache_kv.New[nostr.PubKey](kv, 'z', 8000)
+
+	sys.FollowListCache = cache_kv.New[GenericList[nostr.PubKey, ProfileRef]](kv, 'f', 1000)
+	sys.MuteListCache = cache_kv.New[GenericList[nostr.PubKey, ProfileRef]](kv, 'u', 1000)
+	sys.BlockedRelayListCache = cache_kv.New[GenericList[string, RelayURL]](kv, 'b', 1000)
+	sys.SearchRelayListCache = cache_kv.New[GenericList[string, RelayURL]](kv, 's', 1000)
+	sys.TopicListCache = cache_kv.New[GenericList[string, Topic]](kv, 't', 1000)
+	sys.RelaySetsCache = cache_kv.New[GenericSets[string, RelayURL]](kv, 'R', 1000)
+	sys.FollowSetsCache = cache_kv.New[GenericSets[nostr.PubKey, ProfileRef]](kv, 'F', 1000)
+	sys.TopicSetsCache = cache_kv.New[GenericSets[string, Topic]](kv, 'T', 1000)
+}
@@ -0,0 +1,135 @@
+package nostr_sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"fiatjaf.com/nostr"
+)
+
+// RelayPublishResult is the outcome of publishing one event to one relay.
+type RelayPublishResult struct {
+	Relay    string `json:"relay"`
+	OK       bool   `json:"ok"`
+	TimedOut bool   `json:"timed_out,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// Retryable reports whether a failed publish is worth attempting again later.
+// Timeouts, connection problems and rate limits are transient; explicit rejections
+// such as "blocked:" or "invalid:" are not.
+func (r RelayPublishResult) Retryable() bool {
+	if r.OK {
+		return false
+	}
+	if r.TimedOut {
+		return true
+	}
+	for _, prefix := range []string{"blocked:", "invalid:", "pow:", "restricted:", "auth-required:"} {
+		if strings.Contains(r.Reason, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// PublishReport collects the per-relay results of publishing an event.
+// Queued lists the relays the event is still waiting for in the outgoing queue.
+type PublishReport struct {
+	Event   *nostr.Event         `json:"event"`
+	Results []RelayPublishResult `json:"results"`
+	Queued  []string             `json:"queued,omitempty"`
+}
+
+// Accepted returns the relays that acknowledged the event.
+func (r *PublishReport) Accepted() []string {
+	var relays []string
+	for _, res := range r.Results {
+		if res.OK {
+			relays = append(relays, res.Relay)
+		}
+	}
+	return relays
+}
+
+// Failed returns the results of every relay that did not accept the event.
+func (r *PublishReport) Failed() []RelayPublishResult {
+	var failed []RelayPublishResult
+	for _, res := range r.Results {
+		if !res.OK {
+			failed = append(failed, res)
+		}
+	}
+	return failed
+}
+
+// Err returns an error only when there were relays to publish to, none of them accepted
+// the event and it was not kept in the outgoing queue either.
+func (r *PublishReport) Err() error {
+	if r == nil || len(r.Results) == 0 || len(r.Accepted()) > 0 || len(r.Queued) > 0 {
+		return nil
+	}
+	reasons := make([]string, 0, len(r.Results))
+	for _, res := range r.Results {
+		reasons = append(reasons, fmt.Sprintf("%s (%s)", res.Relay, res.Reason))
+	}
+	return fmt.Errorf("no relay accepted the event: %s", strings.Join(reasons, ", "))
+}
+
+// Publish sends the event to the given relays and reports what each of them answered.
+// Relays that never produced a result before ctx ended are reported as timed out.
+func (sys *System) Publish(ctx context.Context, relays []string, event nostr.Event) *PublishReport {
+	report := &PublishReport{Event: &event}
+	if len(relays) == 0 {
+		return report
+	}
+
+	pending := make(map[string]struct{}, len(relays))
+	for _, url := range relays {
+		pending[nostr.NormalizeURL(url)] = struct{}{}
+	}
+
+	for result := range sys.Pool.PublishMany(ctx, relays, event) {
+		url := nostr.NormalizeURL(result.RelayURL)
+		if _, ok := pending[url]; !ok {
+			continue
+		}
+		delete(pending, url)
+		res := relayResultFromError(url, result.Error)
+		if !res.OK {
+			sys.RelayLimiter.Observe(url, res.Reason)
+		}
+		report.Results = append(report.Results, res)
+	}
+
+	for _, url := range slices.Sorted(maps.Keys(pending)) {
+		report.Results = append(report.Results, RelayPublishResult{
+			Relay:    url,
+			TimedOut: true,
+			Reason:   "no response before timeout",
+		})
+	}
+
+	return report
+}
+
+func relayResultFromError(url string, err error) RelayPublishResult {
+	if err == nil {
+		return RelayPublishResult{Relay: url, OK: true}
+	}
+
+	reason := err.Error()
+	// a duplicate means the relay already has the event, which is what we wanted
+	if strings.Contains(reason, "duplicate:") {
+		return RelayPublishResult{Relay: url, OK: true, Reason: reason}
+	}
+	return RelayPublishResult{
+		Relay:    url,
+		TimedOut: errors.Is(err, context.DeadlineExceeded),
+		Reason:   reason,
+	}
+}
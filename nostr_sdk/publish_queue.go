@@ -0,0 +1,156 @@
+package nostr_sdk
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+
+	"fiatjaf.com/nostr"
+	"github.com/jerry-harm/nosmec/nostr_sdk/kvstore"
+)
+
+const publishQueuePrefix = byte('q')
+
+// PendingPublish is a signed event that still has to reach some relays.
+type PendingPublish struct {
+	Event     nostr.Event     `json:"event"`
+	Relays    []string        `json:"relays"`
+	Attempts  int             `json:"attempts"`
+	LastError string          `json:"last_error,omitempty"`
+	QueuedAt  nostr.Timestamp `json:"queued_at"`
+}
+
+// makePublishQueueKey creates a key for a pending publish.
+// The full event ID is used since entries are listed and removed individually.
+func makePublishQueueKey(id nostr.ID) []byte {
+	// format: 'q' + 32 bytes of event ID
+	key := make([]byte, 33)
+	key[0] = publishQueuePrefix
+	copy(key[1:], id[:])
+	return key
+}
+
+// QueuePublish stores a signed event in the outgoing queue before anything is sent,
+// so it survives a crash or a missing connection. Relays are merged with whatever
+// was already pending for the same event.
+func (sys *System) QueuePublish(event nostr.Event, relays []string) error {
+	if len(relays) == 0 {
+		return nil
+	}
+
+	return sys.KVStore.Update(makePublishQueueKey(event.ID), func(data []byte) ([]byte, error) {
+		pending := PendingPublish{Event: event, QueuedAt: nostr.Now()}
+		if data != nil {
+			if err := json.Unmarshal(data, &pending); err != nil {
+				return nil, err
+			}
+		}
+		for _, relay := range relays {
+			relay = nostr.NormalizeURL(relay)
+			if !slices.Contains(pending.Relays, relay) {
+				pending.Relays = append(pending.Relays, relay)
+			}
+		}
+		return json.Marshal(pending)
+	})
+}
+
+// PublishQueued queues the event, publishes it and then keeps in the queue only the
+// relays that failed for a transient reason. The returned error is about the queue
+// itself; publishing problems are described by the report.
+func (sys *System) PublishQueued(ctx context.Context, relays []string, event nostr.Event) (*PublishReport, error) {
+	if err := sys.QueuePublish(event, relays); err != nil {
+		return sys.Publish(ctx, relays, event), err
+	}
+	report := sys.Publish(ctx, relays, event)
+	return report, sys.settlePendingPublish(report)
+}
+
+// settlePendingPublish updates the queue entry of a published event from its report.
+// Relays that accepted the event or rejected it for good are removed, and the entry
+// is deleted once nothing is left to retry. report.Queued is set to what remains.
+func (sys *System) settlePendingPublish(report *PublishReport) error {
+	results := make(map[string]RelayPublishResult, len(report.Results))
+	for _, res := range report.Results {
+		results[res.Relay] = res
+	}
+
+	return sys.KVStore.Update(makePublishQueueKey(report.Event.ID), func(data []byte) ([]byte, error) {
+		if data == nil {
+			return nil, kvstore.NoOp
+		}
+		var pending PendingPublish
+		if err := json.Unmarshal(data, &pending); err != nil {
+			return nil, err
+		}
+
+		var remaining []string
+		for _, relay := range pending.Relays {
+			res, ok := results[relay]
+			if !ok {
+				remaining = append(remaining, relay)
+				continue
+			}
+			if res.Retryable() {
+				remaining = append(remaining, relay)
+				pending.LastError = res.Reason
+			}
+		}
+
+		report.Queued = remaining
+		if len(remaining) == 0 {
+			return nil, nil
+		}
+		pending.Relays = remaining
+		pending.Attempts++
+		return json.Marshal(pending)
+	})
+}
+
+// ListPendingPublishes returns every event waiting in the outgoing queue, oldest first.
+func (sys *System) ListPendingPublishes() ([]PendingPublish, error) {
+	var pending []PendingPublish
+	err := sys.KVStore.Iterate(func(key, value []byte) error {
+		if len(key) != 33 || key[0] != publishQueuePrefix {
+			return nil
+		}
+		var p PendingPublish
+		if err := json.Unmarshal(value, &p); err != nil {
+			return nil // skip corrupt entries rather than blocking the whole queue
+		}
+		pending = append(pending, p)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(pending, func(a, b PendingPublish) int {
+		return int(a.QueuedAt) - int(b.QueuedAt)
+	})
+	return pending, nil
+}
+
+// RemovePendingPublish drops an event from the outgoing queue without publishing it.
+func (sys *System) RemovePendingPublish(id nostr.ID) error {
+	return sys.KVStore.Delete(makePublishQueueKey(id))
+}
+
+// FlushPendingPublishes publishes every queued event again to the relays it is still pending on.
+func (sys *System) FlushPendingPublishes(ctx context.Context) ([]*PublishReport, error) {
+	pending, err := sys.ListPendingPublishes()
+	if err != nil {
+		return nil, err
+	}
+
+	reports := make([]*PublishReport, 0, len(pending))
+	for _, p := range pending {
+		report := sys.Publish(ctx, p.Relays, p.Event)
+		reports = append(reports, report)
+		if err := sys.settlePendingPublish(report); err != nil {
+			return reports, err
+		}
+	}
+
+	return reports, nil
+}
@@ -0,0 +1,117 @@
+package nostr_sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"fiatjaf.com/nostr"
+	"github.com/jerry-harm/nosmec/nostr_sdk/kvstore/memory"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelayResultFromError(t *testing.T) {
+	ok := relayResultFromError("wss://a.example", nil)
+	require.True(t, ok.OK)
+
+	dup := relayResultFromError("wss://a.example", errors.New("msg: duplicate: already have this event"))
+	require.True(t, dup.OK)
+
+	timeout := relayResultFromError("wss://a.example", fmt.Errorf("publish: %w", context.DeadlineExceeded))
+	require.False(t, timeout.OK)
+	require.True(t, timeout.TimedOut)
+	require.True(t, timeout.Retryable())
+
+	blocked := relayResultFromError("wss://a.example", errors.New("msg: blocked: you are banned"))
+	require.False(t, blocked.OK)
+	require.False(t, blocked.Retryable())
+
+	limited := relayResultFromError("wss://a.example", errors.New("msg: rate-limited: slow down"))
+	require.True(t, limited.Retryable())
+}
+
+func TestPublishReportErr(t *testing.T) {
+	var empty PublishReport
+	require.NoError(t, empty.Err())
+
+	partial := PublishReport{Results: []RelayPublishResult{
+		{Relay: "wss://a.example", OK: true},
+		{Relay: "wss://b.example", Reason: "connection refused"},
+	}}
+	require.NoError(t, partial.Err())
+	require.Equal(t, []string{"wss://a.example"}, partial.Accepted())
+	require.Len(t, partial.Failed(), 1)
+
+	none := PublishReport{Results: []RelayPublishResult{
+		{Relay: "wss://b.example", Reason: "connection refused"},
+	}}
+	err := none.Err()
+	require.Error(t, err)
+	require.True(t, strings.Contains(err.Error(), "wss://b.example"))
+}
+
+func TestPublishQueue(t *testing.T) {
+	sys := NewSystem()
+	sys.KVStore = memory.NewStore()
+
+	event := nostr.Event{ID: mustEventID(t, strings.Repeat("c", 64)), Kind: 1, CreatedAt: 1700000000}
+	relays := []string{"wss://ok.example", "wss://down.example", "wss://banned.example"}
+
+	require.NoError(t, sys.QueuePublish(event, relays))
+
+	pending, err := sys.ListPendingPublishes()
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	require.Equal(t, event.ID, pending[0].Event.ID)
+	require.Equal(t, relays, pending[0].Relays)
+
+	// queueing again merges relays instead of duplicating the entry
+	require.NoError(t, sys.QueuePublish(event, []string{"wss://down.example", "wss://flaky.example"}))
+	pending, err = sys.ListPendingPublishes()
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	require.Len(t, pending[0].Relays, 4)
+
+	report := &PublishReport{Event: &event, Results: []RelayPublishResult{
+		{Relay: "wss://ok.example", OK: true},
+		{Relay: "wss://down.example", TimedOut: true, Reason: "no response before timeout"},
+		{Relay: "wss://banned.example", Reason: "msg: blocked: no"},
+		{Relay: "wss://flaky.example", Reason: "connection refused"},
+	}}
+	require.NoError(t, sys.settlePendingPublish(report))
+	require.Equal(t, []string{"wss://down.example", "wss://flaky.example"}, report.Queued)
+	require.NoError(t, report.Err())
+
+	pending, err = sys.ListPendingPublishes()
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	require.Equal(t, report.Queued, pending[0].Relays)
+	require.Equal(t, 1, pending[0].Attempts)
+	require.Equal(t, "connection refused", pending[0].LastError)
+
+	require.NoError(t, sys.RemovePendingPublish(event.ID))
+	pending, err = sys.ListPendingPublishes()
+	require.NoError(t, err)
+	require.Empty(t, pending)
+}
+
+func TestSettlePendingPublish_DropsWhenDone(t *testing.T) {
+	sys := NewSystem()
+	sys.KVStore = memory.NewStore()
+
+	event := nostr.Event{ID: mustEventID(t, strings.Repeat("d", 64))}
+	require.NoError(t, sys.QueuePublish(event, []string{"wss://a.example", "wss://b.example"}))
+
+	report := &PublishReport{Event: &event, Results: []RelayPublishResult{
+		{Relay: "wss://a.example", OK: true},
+		{Relay: "wss://b.example", Reason: "msg: invalid: bad signature"},
+	}}
+	require.NoError(t, sys.settlePendingPublish(report))
+	require.Empty(t, report.Queued)
+
+	pending, err := sys.ListPendingPublishes()
+	require.NoError(t, err)
+	require.Empty(t, pending)
+}
@@ -0,0 +1,319 @@
+package nostr_sdk
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"fiatjaf.com/nostr"
+)
+
+// RelayLimits bounds how hard we talk to a single relay.
+// A zero RequestsPerSecond disables the token bucket and a zero MaxConcurrent
+// allows any number of simultaneous subscriptions.
+type RelayLimits struct {
+	RequestsPerSecond float64
+	Burst             int
+	MaxConcurrent     int
+}
+
+// DefaultRelayLimits is used for relays without an explicit override.
+var DefaultRelayLimits = RelayLimits{RequestsPerSecond: 2, Burst: 5, MaxConcurrent: 4}
+
+const (
+	minRateLimitBackoff = 2 * time.Second
+	maxRateLimitBackoff = 5 * time.Minute
+)
+
+// RelayLimitStats are the counters kept for one relay.
+type RelayLimitStats struct {
+	Relay        string
+	Requests     int
+	Delayed      int           // requests that had to wait for a token, a slot or a backoff
+	Waited       time.Duration // total time spent waiting
+	RateLimited  int           // rate-limit messages received from the relay
+	BackoffUntil time.Time
+}
+
+// RelayLimiter throttles requests per relay with a token bucket and a cap on
+// concurrent subscriptions, and backs off from relays that tell us to slow down.
+type RelayLimiter struct {
+	mu        sync.Mutex
+	defaults  RelayLimits
+	overrides map[string]RelayLimits
+	relays    map[string]*relayGate
+}
+
+type relayGate struct {
+	limits  RelayLimits
+	tokens  float64
+	refill  time.Time
+	slots   chan struct{}
+	backoff time.Duration
+	until   time.Time
+	stats   RelayLimitStats
+}
+
+// NewRelayLimiter creates a limiter applying defaults to every relay not listed in overrides.
+func NewRelayLimiter(defaults RelayLimits, overrides map[string]RelayLimits) *RelayLimiter {
+	normalized := make(map[string]RelayLimits, len(overrides))
+	for url, limits := range overrides {
+		normalized[nostr.NormalizeURL(url)] = limits
+	}
+	return &RelayLimiter{
+		defaults:  defaults,
+		overrides: normalized,
+		relays:    make(map[string]*relayGate),
+	}
+}
+
+func (rl *RelayLimiter) gate(url string) *relayGate {
+	g, ok := rl.relays[url]
+	if ok {
+		return g
+	}
+
+	limits, ok := rl.overrides[url]
+	if !ok {
+		limits = rl.defaults
+	}
+	g = &relayGate{
+		limits: limits,
+		tokens: float64(max(limits.Burst, 1)),
+		refill: time.Now(),
+		stats:  RelayLimitStats{Relay: url},
+	}
+	if limits.MaxConcurrent > 0 {
+		g.slots = make(chan struct{}, limits.MaxConcurrent)
+	}
+	rl.relays[url] = g
+	return g
+}
+
+// reserve takes a token from the bucket and returns how long the caller has to wait
+// before using it, including any backoff the relay asked for.
+func (g *relayGate) reserve(now time.Time) time.Duration {
+	var wait time.Duration
+	if g.until.After(now) {
+		wait = g.until.Sub(now)
+	}
+
+	rate := g.limits.RequestsPerSecond
+	if rate <= 0 {
+		return wait
+	}
+
+	burst := float64(max(g.limits.Burst, 1))
+	g.tokens = min(burst, g.tokens+now.Sub(g.refill).Seconds()*rate)
+	g.refill = now
+	g.tokens--
+	if g.tokens < 0 {
+		wait = max(wait, time.Duration(-g.tokens/rate*float64(time.Second)))
+	}
+	return wait
+}
+
+// Acquire blocks until a request to url is allowed and returns a function that must be
+// called once the request (or subscription) is finished.
+func (rl *RelayLimiter) Acquire(ctx context.Context, url string) (release func(), err error) {
+	if rl == nil {
+		return func() {}, nil
+	}
+	url = nostr.NormalizeURL(url)
+	start := time.Now()
+
+	rl.mu.Lock()
+	g := rl.gate(url)
+	g.stats.Requests++
+	wait := g.reserve(start)
+	slots := g.slots
+	rl.mu.Unlock()
+
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+	}
+
+	release = func() {}
+	if slots != nil {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		release = sync.OnceFunc(func() { <-slots })
+	}
+
+	if waited := time.Since(start); wait > 0 || waited > time.Millisecond {
+		rl.mu.Lock()
+		g.stats.Delayed++
+		g.stats.Waited += waited
+		rl.mu.Unlock()
+	}
+	return release, nil
+}
+
+// Backoff makes further requests to url wait. Repeated calls double the delay
+// up to a few minutes; a relay that stays quiet is forgiven after its backoff expires.
+func (rl *RelayLimiter) Backoff(url string) {
+	if rl == nil {
+		return
+	}
+	url = nostr.NormalizeURL(url)
+	now := time.Now()
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	g := rl.gate(url)
+	switch {
+	case g.backoff == 0 || now.After(g.until.Add(g.backoff)):
+		g.backoff = minRateLimitBackoff
+	default:
+		g.backoff = min(g.backoff*2, maxRateLimitBackoff)
+	}
+	g.until = now.Add(g.backoff)
+	g.stats.RateLimited++
+	g.stats.BackoffUntil = g.until
+}
+
+// Observe inspects a NOTICE, CLOSED or OK message from a relay and backs off when it
+// says we are sending too much. It reports whether the message was a rate limit.
+func (rl *RelayLimiter) Observe(url string, message string) bool {
+	if !IsRateLimitMessage(message) {
+		return false
+	}
+	rl.Backoff(url)
+	return true
+}
+
+// IsRateLimitMessage reports whether a relay message asks us to slow down, either with
+// the NIP-01 "rate-limited:" prefix or in the free-form wording some relays use in NOTICEs.
+func IsRateLimitMessage(message string) bool {
+	message = strings.ToLower(message)
+	for _, marker := range []string{"rate-limited:", "rate limit", "too many", "slow down"} {
+		if strings.Contains(message, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// Stats returns the counters of every relay the limiter has seen, sorted by URL.
+func (rl *RelayLimiter) Stats() []RelayLimitStats {
+	if rl == nil {
+		return nil
+	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	stats := make([]RelayLimitStats, 0, len(rl.relays))
+	for _, url := range slices.Sorted(maps.Keys(rl.relays)) {
+		stats = append(stats, rl.relays[url].stats)
+	}
+	return stats
+}
+
+// FetchManyLimited is like Pool.FetchMany but queries each relay on its own subscription,
+// going through sys.RelayLimiter and feeding CLOSED reasons back into it.
+func (sys *System) FetchManyLimited(
+	ctx context.Context,
+	relays []string,
+	filter nostr.Filter,
+	opts nostr.SubscriptionOptions,
+) chan nostr.RelayEvent {
+	directed := make([]nostr.DirectedFilter, 0, len(relays))
+	for _, url := range relays {
+		directed = append(directed, nostr.DirectedFilter{Relay: url, Filter: filter})
+	}
+	return sys.BatchedQueryLimited(ctx, directed, opts)
+}
+
+// BatchedQueryLimited is like Pool.BatchedQueryMany but throttled per relay.
+// The returned channel is closed once every relay has sent EOSE, closed the
+// subscription or timed out.
+func (sys *System) BatchedQueryLimited(
+	ctx context.Context,
+	filters []nostr.DirectedFilter,
+	opts nostr.SubscriptionOptions,
+) chan nostr.RelayEvent {
+	out := make(chan nostr.RelayEvent)
+	wg := sync.WaitGroup{}
+	wg.Add(len(filters))
+
+	for _, df := range filters {
+		go func(df nostr.DirectedFilter) {
+			defer wg.Done()
+			sys.queryRelayLimited(ctx, df, opts, out)
+		}(df)
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+
+	return out
+}
+
+func (sys *System) queryRelayLimited(
+	ctx context.Context,
+	df nostr.DirectedFilter,
+	opts nostr.SubscriptionOptions,
+	out chan<- nostr.RelayEvent,
+) {
+	release, err := sys.RelayLimiter.Acquire(ctx, df.Relay)
+	if err != nil {
+		return
+	}
+	defer release()
+
+	relay, err := sys.Pool.EnsureRelay(df.Relay)
+	if err != nil {
+		return
+	}
+
+	if opts.MaxWaitForEOSE > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.MaxWaitForEOSE)
+		defer cancel()
+	}
+
+	sub, err := relay.Subscribe(ctx, df.Filter, opts)
+	if err != nil {
+		sys.RelayLimiter.Observe(df.Relay, err.Error())
+		return
+	}
+	defer sub.Unsub()
+
+	for {
+		select {
+		case evt, more := <-sub.Events:
+			if !more {
+				return
+			}
+			ie := nostr.RelayEvent{Event: evt, Relay: relay}
+			sys.TrackEventHintsAndRelays(ie)
+			select {
+			case out <- ie:
+			case <-ctx.Done():
+				return
+			}
+		case <-sub.EndOfStoredEvents:
+			return
+		case reason := <-sub.ClosedReason:
+			sys.RelayLimiter.Observe(df.Relay, reason)
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
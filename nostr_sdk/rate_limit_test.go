@@ -0,0 +1,101 @@
+package nostr_sdk
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsRateLimitMessage(t *testing.T) {
+	require.True(t, IsRateLimitMessage("rate-limited: slow down there chief"))
+	require.True(t, IsRateLimitMessage("Too many concurrent REQs"))
+	require.True(t, IsRateLimitMessage("you are being rate limited"))
+	require.False(t, IsRateLimitMessage("blocked: you are banned"))
+	require.False(t, IsRateLimitMessage("auth-required: please auth"))
+}
+
+func TestRelayLimiter_TokenBucket(t *testing.T) {
+	rl := NewRelayLimiter(RelayLimits{RequestsPerSecond: 20, Burst: 2}, nil)
+	ctx := context.Background()
+
+	start := time.Now()
+	for range 3 {
+		release, err := rl.Acquire(ctx, "wss://a.example")
+		require.NoError(t, err)
+		release()
+	}
+	// the third request has to wait for a token at 20/s
+	require.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+
+	stats := rl.Stats()
+	require.Len(t, stats, 1)
+	require.Equal(t, "wss://a.example", stats[0].Relay)
+	require.Equal(t, 3, stats[0].Requests)
+	require.Equal(t, 1, stats[0].Delayed)
+}
+
+func TestRelayLimiter_Overrides(t *testing.T) {
+	rl := NewRelayLimiter(RelayLimits{RequestsPerSecond: 1, Burst: 1}, map[string]RelayLimits{
+		"wss://fast.example/": {},
+	})
+	ctx := context.Background()
+
+	start := time.Now()
+	for range 5 {
+		release, err := rl.Acquire(ctx, "wss://fast.example")
+		require.NoError(t, err)
+		release()
+	}
+	require.Less(t, time.Since(start), 500*time.Millisecond)
+}
+
+func TestRelayLimiter_MaxConcurrent(t *testing.T) {
+	rl := NewRelayLimiter(RelayLimits{MaxConcurrent: 1}, nil)
+
+	release, err := rl.Acquire(context.Background(), "wss://a.example")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = rl.Acquire(ctx, "wss://a.example")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	release()
+	release() // releasing twice must not free a second slot
+	release2, err := rl.Acquire(context.Background(), "wss://a.example")
+	require.NoError(t, err)
+	release2()
+}
+
+func TestRelayLimiter_Backoff(t *testing.T) {
+	rl := NewRelayLimiter(RelayLimits{}, nil)
+
+	require.False(t, rl.Observe("wss://a.example", "hello"))
+	require.True(t, rl.Observe("wss://a.example", "rate-limited: slow down"))
+
+	stats := rl.Stats()
+	require.Len(t, stats, 1)
+	require.Equal(t, 1, stats[0].RateLimited)
+	require.WithinDuration(t, time.Now().Add(minRateLimitBackoff), stats[0].BackoffUntil, time.Second)
+
+	// a second warning while still backing off doubles the delay
+	rl.Backoff("wss://a.example")
+	stats = rl.Stats()
+	require.WithinDuration(t, time.Now().Add(2*minRateLimitBackoff), stats[0].BackoffUntil, time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := rl.Acquire(ctx, "wss://a.example")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestRelayLimiter_Nil(t *testing.T) {
+	var rl *RelayLimiter
+	release, err := rl.Acquire(context.Background(), "wss://a.example")
+	require.NoError(t, err)
+	release()
+	require.True(t, rl.Observe("wss://a.example", "rate-limited: no"))
+	require.Empty(t, rl.Stats())
+}
@@ -0,0 +1,31 @@
+package nostr_sdk
+
+import (
+	"fiatjaf.com/nostr"
+)
+
+const savedSearchSeenPrefix = byte('S')
+
+// SavedSearchLastSeen returns the creation time of the newest hit of the saved search
+// name that was shown, or 0 if it was never looked at.
+func (sys *System) SavedSearchLastSeen(name string) nostr.Timestamp {
+	data, _ := sys.KVStore.Get(makeSavedSearchKey(name))
+	if len(data) != 4 {
+		return 0
+	}
+	return decodeTimestamp(data)
+}
+
+// SetSavedSearchLastSeen records ts as the newest hit of the saved search name that was shown.
+func (sys *System) SetSavedSearchLastSeen(name string, ts nostr.Timestamp) error {
+	return sys.KVStore.Set(makeSavedSearchKey(name), encodeTimestamp(ts))
+}
+
+// ForgetSavedSearch drops what is known about the saved search name.
+func (sys *System) ForgetSavedSearch(name string) error {
+	return sys.KVStore.Delete(makeSavedSearchKey(name))
+}
+
+func makeSavedSearchKey(name string) []byte {
+	return append([]byte{savedSearchSeenPrefix}, name...)
+}
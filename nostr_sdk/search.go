@@ -2,10 +2,17 @@ package nostr_sdk
 
 import (
 	"context"
+	"errors"
+	"slices"
+	"strings"
 
 	"fiatjaf.com/nostr"
+	eventstorebleve "fiatjaf.com/nostr/eventstore/bleve"
 )
 
+// localSearchLimit is used by SearchLocal when the filter has no limit.
+const localSearchLimit = 100
+
 func (sys *System) SearchUsers(ctx context.Context, query string) []ProfileMetadata {
 	limit := 10
 	profiles := make([]ProfileMetadata, 0, limit*len(sys.UserSearchRelays.URLs))
@@ -22,3 +29,52 @@ func (sys *System) SearchUsers(ctx context.Context, query string) []ProfileMetad
 
 	return profiles
 }
+
+var errEnoughProfiles = errors.New("enough profiles")
+
+// SearchLocalProfiles finds stored profiles whose name, display name or NIP-05 address
+// contains query, ignoring case, newest first, stopping after limit matches unless it
+// is 0. Unparseable metadata is skipped.
+func (sys *System) SearchLocalProfiles(ctx context.Context, query string, limit int) []ProfileMetadata {
+	if sys.Store == nil {
+		return nil
+	}
+	q := strings.ToLower(query)
+	var profiles []ProfileMetadata
+	scanMatchingEvents(ctx, rawEventStore(sys.Store), nostr.Filter{Kinds: []nostr.Kind{nostr.KindProfileMetadata}}, func(evt nostr.Event) error {
+		pm, err := ParseMetadata(evt)
+		if err != nil {
+			return nil
+		}
+		if strings.Contains(strings.ToLower(pm.Name), q) ||
+			strings.Contains(strings.ToLower(pm.DisplayName), q) ||
+			strings.Contains(strings.ToLower(pm.NIP05), q) {
+			profiles = append(profiles, pm)
+			if limit > 0 && len(profiles) >= limit {
+				return errEnoughProfiles
+			}
+		}
+		return nil
+	})
+	return profiles
+}
+
+// HasSearchIndex reports whether the local store keeps a full-text index,
+// so NIP-50 filters can be answered without a relay.
+func (sys *System) HasSearchIndex() bool {
+	_, ok := sys.Store.(*eventstorebleve.BleveBackend)
+	return ok
+}
+
+// SearchLocal runs a NIP-50 filter against the local search index. It returns
+// nothing when the store has no index.
+func (sys *System) SearchLocal(filter nostr.Filter) []nostr.Event {
+	if !sys.HasSearchIndex() {
+		return nil
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = localSearchLimit
+	}
+	return slices.Collect(sys.Store.QueryEvents(filter, limit))
+}
@@ -98,6 +98,7 @@ func (sys *System) FetchSpecificEvent(
 	// try to fetch in our internal eventstore first
 	if !params.SkipLocalStore {
 		for evt := range sys.Store.QueryEvents(filter, 1) {
+			sys.TrackEventAccessTime(evt.ID)
 			return &evt, nil, nil
 		}
 	}
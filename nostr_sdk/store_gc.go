@@ -0,0 +1,215 @@
+package nostr_sdk
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"slices"
+	"time"
+
+	"fiatjaf.com/nostr"
+	"fiatjaf.com/nostr/eventstore"
+	eventstorebleve "fiatjaf.com/nostr/eventstore/bleve"
+	cache_kv "github.com/jerry-harm/nosmec/nostr_sdk/cache/kv"
+)
+
+const gcPageSize = 500
+
+var gcLastRunKey = []byte{'g'}
+
+// GCPolicy decides which locally stored events are evicted by CollectGarbage.
+// Events that were never accessed count as accessed when they were created.
+type GCPolicy struct {
+	MaxAge  time.Duration // evict events not accessed for longer than this; 0 disables
+	MaxSize int64         // approximate bytes of event JSON to keep; 0 disables
+	Keep    func(evt nostr.Event) bool
+	DryRun  bool
+}
+
+// GCReport summarizes a garbage collection run.
+type GCReport struct {
+	Scanned    int   `json:"scanned"`
+	Protected  int   `json:"protected"`
+	Evicted    int   `json:"evicted"`
+	FreedBytes int64 `json:"freed_bytes"`
+	KeptBytes  int64 `json:"kept_bytes"`
+
+	ExpiredCache int `json:"expired_cache"` // expired persistent cache entries removed
+}
+
+type gcCandidate struct {
+	id       nostr.ID
+	accessed nostr.Timestamp
+	size     int64
+}
+
+// CollectGarbage evicts the least recently accessed events from the local store and
+// the search index, together with their event relay and access time records.
+// Events for which policy.Keep returns true are never evicted.
+func (sys *System) CollectGarbage(ctx context.Context, policy GCPolicy) (GCReport, error) {
+	var report GCReport
+	var candidates []gcCandidate
+
+	raw := rawEventStore(sys.Store)
+	err := scanStoredEvents(ctx, raw, func(evt nostr.Event) error {
+		report.Scanned++
+		size := storedEventSize(evt)
+		report.KeptBytes += size
+
+		if policy.Keep != nil && policy.Keep(evt) {
+			report.Protected++
+			return nil
+		}
+
+		accessed := sys.GetEventAccessTime(evt.ID)
+		if accessed == 0 {
+			accessed = evt.CreatedAt
+		}
+		candidates = append(candidates, gcCandidate{id: evt.ID, accessed: accessed, size: size})
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+
+	slices.SortFunc(candidates, func(a, b gcCandidate) int {
+		return cmp.Compare(a.accessed, b.accessed)
+	})
+
+	var cutoff nostr.Timestamp
+	if policy.MaxAge > 0 {
+		cutoff = nostr.Now() - nostr.Timestamp(policy.MaxAge.Seconds())
+	}
+
+	for _, c := range candidates {
+		expired := cutoff > 0 && c.accessed < cutoff
+		oversized := policy.MaxSize > 0 && report.KeptBytes > policy.MaxSize
+		if !expired && !oversized {
+			// candidates are sorted by access time, nothing after this one qualifies either
+			break
+		}
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		if !policy.DryRun {
+			if err := sys.deleteStoredEvent(c.id); err != nil {
+				return report, err
+			}
+		}
+		report.Evicted++
+		report.FreedBytes += c.size
+		report.KeptBytes -= c.size
+	}
+
+	if !policy.DryRun {
+		if report.ExpiredCache, err = cache_kv.Prune(sys.KVStore); err != nil {
+			return report, err
+		}
+		if err := sys.KVStore.Set(gcLastRunKey, encodeTimestamp(nostr.Now())); err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+// LastGarbageCollection returns when CollectGarbage last completed, or 0 if it never ran.
+func (sys *System) LastGarbageCollection() nostr.Timestamp {
+	data, _ := sys.KVStore.Get(gcLastRunKey)
+	if data == nil {
+		return 0
+	}
+	return decodeTimestamp(data)
+}
+
+// deleteStoredEvent removes an event from the raw store, the search index and the KVStore.
+func (sys *System) deleteStoredEvent(id nostr.ID) error {
+	if bleveStore, ok := sys.Store.(*eventstorebleve.BleveBackend); ok {
+		if err := bleveStore.DeleteEvent(id); err != nil {
+			return err
+		}
+		if bleveStore.RawEventStore != nil {
+			if err := bleveStore.RawEventStore.DeleteEvent(id); err != nil {
+				return err
+			}
+		}
+	} else if err := sys.Store.DeleteEvent(id); err != nil {
+		return err
+	}
+
+	if err := sys.KVStore.Delete(makeEventRelayKey(id)); err != nil {
+		return err
+	}
+	return sys.EraseAccessTime(id)
+}
+
+// rawEventStore returns the store holding the events themselves, skipping the search index.
+func rawEventStore(store eventstore.Store) eventstore.Store {
+	if bleveStore, ok := store.(*eventstorebleve.BleveBackend); ok && bleveStore.RawEventStore != nil {
+		return bleveStore.RawEventStore
+	}
+	return store
+}
+
+// scanStoredEvents calls fn for every event in the store, newest first, paging by created_at.
+// It stops at the first error returned by fn.
+func scanStoredEvents(ctx context.Context, store eventstore.Store, fn func(evt nostr.Event) error) error {
+	return scanMatchingEvents(ctx, store, nostr.Filter{}, fn)
+}
+
+// scanMatchingEvents is scanStoredEvents restricted to the events matching base.
+// The Limit of base is ignored.
+func scanMatchingEvents(ctx context.Context, store eventstore.Store, base nostr.Filter, fn func(evt nostr.Event) error) error {
+	until := base.Until
+	seen := make(map[nostr.ID]struct{})
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		filter := base
+		filter.Limit = gcPageSize
+		filter.Until = until
+
+		page, fresh := 0, 0
+		oldest := until
+		for evt := range store.QueryEvents(filter, gcPageSize) {
+			page++
+			if _, ok := seen[evt.ID]; ok {
+				continue
+			}
+			fresh++
+			if evt.CreatedAt != oldest {
+				// only events sharing the page boundary timestamp can show up twice
+				clear(seen)
+				oldest = evt.CreatedAt
+			}
+			seen[evt.ID] = struct{}{}
+			if err := fn(evt); err != nil {
+				return err
+			}
+		}
+
+		switch {
+		case page < gcPageSize:
+			return nil
+		case fresh == 0:
+			// a full page of events with the same timestamp, step over it
+			if oldest == 0 {
+				return nil
+			}
+			until = oldest - 1
+		default:
+			until = oldest
+		}
+	}
+}
+
+func storedEventSize(evt nostr.Event) int64 {
+	data, err := json.Marshal(evt)
+	if err != nil {
+		return 0
+	}
+	return int64(len(data))
+}
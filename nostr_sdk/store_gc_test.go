@@ -0,0 +1,123 @@
+package nostr_sdk
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"fiatjaf.com/nostr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectGarbage_MaxAge(t *testing.T) {
+	sys := newFetchEventsTestSystem(t)
+	ctx := context.Background()
+
+	stranger := mustPubKey(t, strings.Repeat("a", 64))
+	friend := mustPubKey(t, strings.Repeat("b", 64))
+
+	stale := nostr.Event{ID: mustID(t, strings.Repeat("1", 64)), PubKey: stranger, Kind: 1, CreatedAt: 1000}
+	recent := nostr.Event{ID: mustID(t, strings.Repeat("2", 64)), PubKey: stranger, Kind: 1, CreatedAt: 1000}
+	kept := nostr.Event{ID: mustID(t, strings.Repeat("3", 64)), PubKey: friend, Kind: 1, CreatedAt: 1000}
+	for _, evt := range []nostr.Event{stale, recent, kept} {
+		require.NoError(t, sys.Store.SaveEvent(evt))
+	}
+	sys.trackEventRelay(stale.ID, "wss://a.example", false)
+	sys.TrackEventAccessTime(recent.ID)
+
+	policy := GCPolicy{
+		MaxAge: time.Hour,
+		Keep:   func(evt nostr.Event) bool { return evt.PubKey == friend },
+		DryRun: true,
+	}
+
+	report, err := sys.CollectGarbage(ctx, policy)
+	require.NoError(t, err)
+	require.Equal(t, 3, report.Scanned)
+	require.Equal(t, 1, report.Protected)
+	require.Equal(t, 1, report.Evicted)
+	require.Zero(t, sys.LastGarbageCollection())
+
+	policy.DryRun = false
+	report, err = sys.CollectGarbage(ctx, policy)
+	require.NoError(t, err)
+	require.Equal(t, 1, report.Evicted)
+	require.NotZero(t, sys.LastGarbageCollection())
+
+	var left []nostr.ID
+	for evt := range sys.Store.QueryEvents(nostr.Filter{}, 10) {
+		left = append(left, evt.ID)
+	}
+	require.ElementsMatch(t, []nostr.ID{recent.ID, kept.ID}, left)
+
+	data, err := sys.KVStore.Get(makeEventRelayKey(stale.ID))
+	require.NoError(t, err)
+	require.Nil(t, data)
+}
+
+func TestCollectGarbage_MaxSize(t *testing.T) {
+	sys := newFetchEventsTestSystem(t)
+
+	var size int64
+	for i := range 10 {
+		evt := nostr.Event{
+			ID:        mustID(t, fmt.Sprintf("%064x", i+1)),
+			Kind:      1,
+			CreatedAt: nostr.Timestamp(1000 + i),
+			Content:   strings.Repeat("x", 100),
+		}
+		require.NoError(t, sys.Store.SaveEvent(evt))
+		size = storedEventSize(evt)
+	}
+
+	report, err := sys.CollectGarbage(context.Background(), GCPolicy{MaxSize: size * 4})
+	require.NoError(t, err)
+	require.Equal(t, 6, report.Evicted)
+	require.LessOrEqual(t, report.KeptBytes, size*4)
+
+	// the oldest events go first
+	for evt := range sys.Store.QueryEvents(nostr.Filter{}, 10) {
+		require.GreaterOrEqual(t, evt.CreatedAt, nostr.Timestamp(1006))
+	}
+}
+
+func TestScanStoredEvents_PagesAcrossEqualTimestamps(t *testing.T) {
+	sys := newFetchEventsTestSystem(t)
+
+	const total = gcPageSize*2 + 37
+	for i := range total {
+		evt := nostr.Event{
+			ID:        mustID(t, fmt.Sprintf("%064x", i+1)),
+			Kind:      1,
+			CreatedAt: nostr.Timestamp(1000 + i/7),
+		}
+		require.NoError(t, sys.Store.SaveEvent(evt))
+	}
+
+	seen := make(map[nostr.ID]struct{})
+	err := scanStoredEvents(context.Background(), sys.Store, func(evt nostr.Event) error {
+		seen[evt.ID] = struct{}{}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, seen, total)
+}
+
+func TestAccessTimeOnlyForStoredEvents(t *testing.T) {
+	sys := newFetchEventsTestSystem(t)
+	defer sys.Close()
+	sk := nostr.Generate()
+
+	seen := nostr.Event{Kind: 1, CreatedAt: nostr.Now(), Content: "passing by"}
+	require.NoError(t, seen.Sign(sk))
+	sys.TrackEventHintsAndRelays(nostr.RelayEvent{Event: seen, Relay: &nostr.Relay{URL: "wss://a.example"}})
+	require.Equal(t, []string{"wss://a.example"}, sys.GetEventRelays(seen.ID))
+	require.Zero(t, sys.GetEventAccessTime(seen.ID), "events only seen on a relay get no access time")
+
+	stored := nostr.Event{Kind: 1, CreatedAt: nostr.Now(), Content: "kept"}
+	require.NoError(t, stored.Sign(sk))
+	require.NoError(t, sys.Publisher.Publish(context.Background(), stored))
+	require.NotZero(t, sys.GetEventAccessTime(stored.ID))
+}
@@ -0,0 +1,252 @@
+package nostr_sdk
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"fiatjaf.com/nostr"
+	"fiatjaf.com/nostr/eventstore"
+	eventstorebleve "fiatjaf.com/nostr/eventstore/bleve"
+	"github.com/blevesearch/bleve/v2"
+	cache_kv "github.com/jerry-harm/nosmec/nostr_sdk/cache/kv"
+)
+
+// KVPrefixNames describes the kinds of records kept in the KVStore, by key prefix.
+var KVPrefixNames = map[byte]string{
+	eventAccessTimePrefix:     "event access times",
+	eventRelayPrefix:          "event relays",
+	pubkeyStreamLatestPrefix:  "pubkey stream latest",
+	pubkeyStreamOldestPrefix:  "pubkey stream oldest",
+	kvStoreLastFetchPrefix:    "list last fetch",
+	publishQueuePrefix:        "outgoing queue",
+	listSnapshotPrefix:        "list snapshots",
+	gcLastRunKey[0]:           "gc state",
+	cache_kv.Prefix:           "persistent cache",
+	eventSyncCheckpointPrefix: "event sync checkpoints",
+	backfillCheckpointPrefix:  "backfill checkpoints",
+	savedSearchSeenPrefix:     "saved search last seen",
+}
+
+// KVPrefixStats counts the KVStore records sharing a key prefix.
+type KVPrefixStats struct {
+	Prefix byte
+	Keys   int
+	Bytes  int64
+}
+
+// StoreStats describes what the local stores have accumulated.
+type StoreStats struct {
+	Events  int
+	Bytes   int64 // approximate size of the stored event JSON
+	Kinds   map[nostr.Kind]int
+	Authors map[nostr.PubKey]int
+	KV      []KVPrefixStats
+}
+
+// CollectStoreStats scans the local event store and the KVStore.
+func (sys *System) CollectStoreStats(ctx context.Context) (StoreStats, error) {
+	stats := StoreStats{
+		Kinds:   make(map[nostr.Kind]int),
+		Authors: make(map[nostr.PubKey]int),
+	}
+
+	err := scanStoredEvents(ctx, rawEventStore(sys.Store), func(evt nostr.Event) error {
+		stats.Events++
+		stats.Bytes += storedEventSize(evt)
+		stats.Kinds[evt.Kind]++
+		stats.Authors[evt.PubKey]++
+		return nil
+	})
+	if err != nil {
+		return stats, err
+	}
+
+	prefixes := make(map[byte]*KVPrefixStats)
+	err = sys.KVStore.Iterate(func(key, value []byte) error {
+		if len(key) == 0 {
+			return nil
+		}
+		p, ok := prefixes[key[0]]
+		if !ok {
+			p = &KVPrefixStats{Prefix: key[0]}
+			prefixes[key[0]] = p
+		}
+		p.Keys++
+		p.Bytes += int64(len(key) + len(value))
+		return nil
+	})
+	if err != nil {
+		return stats, err
+	}
+	for _, prefix := range slices.Sorted(maps.Keys(prefixes)) {
+		stats.KV = append(stats.KV, *prefixes[prefix])
+	}
+
+	return stats, nil
+}
+
+// VerifyReport lists the problems found by VerifyStore.
+type VerifyReport struct {
+	Checked   int        `json:"checked"`
+	BadEvents []nostr.ID `json:"bad_events"` // wrong id or invalid signature
+
+	// only filled in when the store keeps a search index
+	Indexed       int        `json:"indexed"`        // search index entries
+	OrphanedIndex []nostr.ID `json:"orphaned_index"` // index entries without a stored event
+	Unindexed     []nostr.ID `json:"unindexed"`      // stored events missing from the index
+
+	Repaired bool `json:"repaired"`
+}
+
+// VerifyStore re-checks the id and signature of every stored event and compares the
+// search index with the raw event store. With repair set, bad events are deleted,
+// orphaned index entries are dropped and missing events are indexed again.
+//
+// The event relay and access time records in the KVStore are left alone: they are kept
+// for events seen on relays whether or not we store them.
+func (sys *System) VerifyStore(ctx context.Context, repair bool) (VerifyReport, error) {
+	report := VerifyReport{BadEvents: []nostr.ID{}, OrphanedIndex: []nostr.ID{}, Unindexed: []nostr.ID{}}
+	stored := make(map[nostr.ID]bool) // false for events failing the checks
+
+	err := scanStoredEvents(ctx, rawEventStore(sys.Store), func(evt nostr.Event) error {
+		report.Checked++
+		valid := evt.CheckID() && evt.VerifySignature()
+		if !valid {
+			report.BadEvents = append(report.BadEvents, evt.ID)
+		}
+		stored[evt.ID] = valid
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+
+	index, ok := sys.Store.(*eventstorebleve.BleveBackend)
+	if ok && index.RawEventStore != nil {
+		indexed, err := indexedEventIDs(ctx, index)
+		if err != nil {
+			return report, fmt.Errorf("failed to read search index: %w", err)
+		}
+		report.Indexed = len(indexed)
+		for id := range indexed {
+			if _, ok := stored[id]; !ok {
+				report.OrphanedIndex = append(report.OrphanedIndex, id)
+			}
+		}
+		for id, valid := range stored {
+			if _, ok := indexed[id]; valid && !ok {
+				report.Unindexed = append(report.Unindexed, id)
+			}
+		}
+		slices.SortFunc(report.OrphanedIndex, compareIDs)
+		slices.SortFunc(report.Unindexed, compareIDs)
+	}
+
+	if !repair {
+		return report, nil
+	}
+
+	for _, id := range report.BadEvents {
+		if err := sys.deleteStoredEvent(id); err != nil {
+			return report, err
+		}
+	}
+	for _, id := range report.OrphanedIndex {
+		if err := index.DeleteEvent(id); err != nil {
+			return report, err
+		}
+	}
+	for start := 0; start < len(report.Unindexed); start += gcPageSize {
+		ids := report.Unindexed[start:min(start+gcPageSize, len(report.Unindexed))]
+		for evt := range index.RawEventStore.QueryEvents(nostr.Filter{IDs: ids}, len(ids)) {
+			if err := index.SaveEvent(evt); err != nil && !errors.Is(err, eventstore.ErrDupEvent) {
+				return report, err
+			}
+		}
+	}
+	report.Repaired = true
+	return report, nil
+}
+
+// indexedEventIDs lists the events in the search index. Bleve only lets one handle open
+// the index, so the backend is closed while the index is read and opened again after;
+// it cannot be used in the meantime.
+func indexedEventIDs(ctx context.Context, index *eventstorebleve.BleveBackend) (ids map[nostr.ID]struct{}, err error) {
+	index.Close()
+	defer func() {
+		if ierr := index.Init(); ierr != nil && err == nil {
+			err = fmt.Errorf("failed to reopen search index: %w", ierr)
+		}
+	}()
+
+	idx, err := bleve.Open(index.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer idx.Close()
+
+	ids = make(map[nostr.ID]struct{})
+	var after []string
+	for {
+		req := bleve.NewSearchRequestOptions(bleve.NewMatchAllQuery(), gcPageSize, 0, false)
+		req.SortBy([]string{"_id"})
+		req.SearchAfter = after
+		res, err := idx.SearchInContext(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		for _, hit := range res.Hits {
+			// documents are keyed by the hex event id
+			if id, err := nostr.IDFromHex(hit.ID); err == nil {
+				ids[id] = struct{}{}
+			}
+		}
+		if len(res.Hits) < gcPageSize {
+			return ids, nil
+		}
+		after = []string{res.Hits[len(res.Hits)-1].ID}
+	}
+}
+
+func compareIDs(a, b nostr.ID) int {
+	return bytes.Compare(a[:], b[:])
+}
+
+// CopyStoredEvents saves every event from the local raw store into dst, which can be
+// a fresh search index or a new event database. It returns how many events were copied.
+func (sys *System) CopyStoredEvents(ctx context.Context, dst eventstore.Store) (int, error) {
+	copied := 0
+	err := scanStoredEvents(ctx, rawEventStore(sys.Store), func(evt nostr.Event) error {
+		if err := dst.SaveEvent(evt); err != nil && !errors.Is(err, eventstore.ErrDupEvent) {
+			return err
+		}
+		copied++
+		return nil
+	})
+	return copied, err
+}
+
+// TopKinds returns the n most common kinds in stats, most common first.
+func (s StoreStats) TopKinds(n int) []nostr.Kind {
+	return topKeys(s.Kinds, n)
+}
+
+// TopAuthors returns the n authors with the most stored events, most prolific first.
+func (s StoreStats) TopAuthors(n int) []nostr.PubKey {
+	return topKeys(s.Authors, n)
+}
+
+func topKeys[K comparable](counts map[K]int, n int) []K {
+	keys := slices.Collect(maps.Keys(counts))
+	slices.SortFunc(keys, func(a, b K) int {
+		return counts[b] - counts[a]
+	})
+	if n > 0 && len(keys) > n {
+		keys = keys[:n]
+	}
+	return keys
+}
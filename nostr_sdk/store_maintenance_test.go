@@ -0,0 +1,154 @@
+package nostr_sdk
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"fiatjaf.com/nostr"
+	"fiatjaf.com/nostr/eventstore"
+	eventstorebleve "fiatjaf.com/nostr/eventstore/bleve"
+	"fiatjaf.com/nostr/eventstore/slicestore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectStoreStats(t *testing.T) {
+	sys := newFetchEventsTestSystem(t)
+	alice := mustPubKey(t, strings.Repeat("a", 64))
+	bob := mustPubKey(t, strings.Repeat("b", 64))
+
+	events := []nostr.Event{
+		{ID: mustID(t, strings.Repeat("1", 64)), PubKey: alice, Kind: 1, CreatedAt: 10},
+		{ID: mustID(t, strings.Repeat("2", 64)), PubKey: alice, Kind: 1, CreatedAt: 11},
+		{ID: mustID(t, strings.Repeat("3", 64)), PubKey: bob, Kind: 7, CreatedAt: 12},
+	}
+	for _, evt := range events {
+		require.NoError(t, sys.Store.SaveEvent(evt))
+	}
+	sys.TrackEventAccessTime(events[0].ID)
+	sys.trackEventRelay(events[0].ID, "wss://a.example", false)
+	sys.trackEventRelay(events[1].ID, "wss://a.example", false)
+
+	stats, err := sys.CollectStoreStats(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 3, stats.Events)
+	require.Equal(t, []nostr.Kind{1, 7}, stats.TopKinds(0))
+	require.Equal(t, []nostr.PubKey{alice}, stats.TopAuthors(1))
+
+	require.Len(t, stats.KV, 2)
+	require.Equal(t, eventAccessTimePrefix, stats.KV[0].Prefix)
+	require.Equal(t, 1, stats.KV[0].Keys)
+	require.Equal(t, eventRelayPrefix, stats.KV[1].Prefix)
+	require.Equal(t, 2, stats.KV[1].Keys)
+}
+
+func TestVerifyStore(t *testing.T) {
+	sys := newFetchEventsTestSystem(t)
+	sk := nostr.Generate()
+
+	good := nostr.Event{Kind: 1, CreatedAt: 10, Content: "hello"}
+	require.NoError(t, good.Sign(sk))
+	tampered := nostr.Event{Kind: 1, CreatedAt: 11, Content: "original"}
+	require.NoError(t, tampered.Sign(sk))
+	tampered.Content = "changed"
+
+	require.NoError(t, sys.Store.SaveEvent(good))
+	require.NoError(t, sys.Store.SaveEvent(tampered))
+
+	// relays and access times are also kept for events we never stored
+	seen := mustID(t, strings.Repeat("9", 64))
+	sys.TrackEventAccessTime(good.ID)
+	sys.TrackEventAccessTime(seen)
+	sys.trackEventRelay(seen, "wss://a.example", false)
+
+	report, err := sys.VerifyStore(context.Background(), false)
+	require.NoError(t, err)
+	require.Equal(t, 2, report.Checked)
+	require.Equal(t, []nostr.ID{tampered.ID}, report.BadEvents)
+	require.False(t, report.Repaired)
+
+	report, err = sys.VerifyStore(context.Background(), true)
+	require.NoError(t, err)
+	require.True(t, report.Repaired)
+
+	report, err = sys.VerifyStore(context.Background(), false)
+	require.NoError(t, err)
+	require.Equal(t, 1, report.Checked)
+	require.Empty(t, report.BadEvents)
+	require.NotZero(t, sys.GetEventAccessTime(good.ID))
+	require.NotZero(t, sys.GetEventAccessTime(seen))
+	require.Equal(t, []string{"wss://a.example"}, sys.GetEventRelays(seen))
+}
+
+func TestVerifyStore_SearchIndex(t *testing.T) {
+	raw := &slicestore.SliceStore{}
+	require.NoError(t, raw.Init())
+	index := &eventstorebleve.BleveBackend{Path: filepath.Join(t.TempDir(), "index"), RawEventStore: raw}
+	require.NoError(t, index.Init())
+
+	sys := NewSystem()
+	sys.Store = index
+	defer sys.Close()
+
+	sk := nostr.Generate()
+	sign := func(content string) nostr.Event {
+		evt := nostr.Event{Kind: 1, CreatedAt: nostr.Now(), Content: content}
+		require.NoError(t, evt.Sign(sk))
+		return evt
+	}
+	indexed, unindexed, orphan := sign("indexed lighthouse"), sign("unindexed lighthouse"), sign("orphan lighthouse")
+
+	saveRaw := func(evt nostr.Event) {
+		if err := raw.SaveEvent(evt); err != nil {
+			require.ErrorIs(t, err, eventstore.ErrDupEvent)
+		}
+	}
+	require.NoError(t, index.SaveEvent(indexed))
+	require.NoError(t, index.SaveEvent(orphan))
+	for _, evt := range []nostr.Event{indexed, unindexed, orphan} {
+		saveRaw(evt)
+	}
+	require.NoError(t, raw.DeleteEvent(orphan.ID))
+
+	report, err := sys.VerifyStore(context.Background(), false)
+	require.NoError(t, err)
+	require.Equal(t, 2, report.Checked)
+	require.Equal(t, 2, report.Indexed)
+	require.Equal(t, []nostr.ID{orphan.ID}, report.OrphanedIndex)
+	require.Equal(t, []nostr.ID{unindexed.ID}, report.Unindexed)
+
+	_, err = sys.VerifyStore(context.Background(), true)
+	require.NoError(t, err)
+
+	report, err = sys.VerifyStore(context.Background(), false)
+	require.NoError(t, err)
+	require.Equal(t, 2, report.Indexed)
+	require.Empty(t, report.OrphanedIndex)
+	require.Empty(t, report.Unindexed)
+
+	// the index was reopened and finds the repaired event
+	found := sys.SearchLocal(nostr.Filter{Search: "unindexed"})
+	require.Len(t, found, 1)
+	require.Equal(t, unindexed.ID, found[0].ID)
+}
+
+func TestCopyStoredEvents(t *testing.T) {
+	sys := newFetchEventsTestSystem(t)
+	for _, id := range []string{strings.Repeat("1", 64), strings.Repeat("2", 64)} {
+		require.NoError(t, sys.Store.SaveEvent(nostr.Event{ID: mustID(t, id), Kind: 1, CreatedAt: 10}))
+	}
+
+	dst := &slicestore.SliceStore{}
+	require.NoError(t, dst.Init())
+
+	copied, err := sys.CopyStoredEvents(context.Background(), dst)
+	require.NoError(t, err)
+	require.Equal(t, 2, copied)
+
+	count := 0
+	for range dst.QueryEvents(nostr.Filter{}, 10) {
+		count++
+	}
+	require.Equal(t, 2, count)
+}
@@ -0,0 +1,104 @@
+package nostr_sdk
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+
+	"fiatjaf.com/nostr"
+	"fiatjaf.com/nostr/eventstore"
+)
+
+// ExportEvents writes the stored events matching filter to w, one NIP-01 JSON object
+// per line, newest first. It returns how many events were written.
+func (sys *System) ExportEvents(ctx context.Context, filter nostr.Filter, w io.Writer) (int, error) {
+	bw := bufio.NewWriter(w)
+	exported := 0
+
+	err := scanMatchingEvents(ctx, rawEventStore(sys.Store), filter, func(evt nostr.Event) error {
+		line, err := json.Marshal(evt)
+		if err != nil {
+			return err
+		}
+		bw.Write(line)
+		if err := bw.WriteByte('\n'); err != nil {
+			return err
+		}
+		exported++
+		return nil
+	})
+	if err != nil {
+		return exported, err
+	}
+	return exported, bw.Flush()
+}
+
+// ImportReport counts what ImportEvents did with each line of its input.
+type ImportReport struct {
+	Read       int `json:"read"`
+	Imported   int `json:"imported"`
+	Duplicates int `json:"duplicates"` // already stored or repeated in the input
+	Invalid    int `json:"invalid"`    // not an event, or wrong id or signature
+}
+
+// ImportEvents reads events written by ExportEvents (or any NIP-01 JSON lines) from r and
+// saves the valid ones through the Publisher, so the search index is updated as well.
+// Blank lines are skipped.
+func (sys *System) ImportEvents(ctx context.Context, r io.Reader) (ImportReport, error) {
+	var report ImportReport
+	seen := make(map[nostr.ID]struct{})
+	br := bufio.NewReader(r)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		// lines can be much longer than bufio.Scanner allows, e.g. big follow lists
+		line, err := br.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return report, err
+		}
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			report.Read++
+			if ierr := sys.importEventLine(ctx, line, seen, &report); ierr != nil {
+				return report, ierr
+			}
+		}
+		if err == io.EOF {
+			return report, nil
+		}
+	}
+}
+
+func (sys *System) importEventLine(ctx context.Context, line []byte, seen map[nostr.ID]struct{}, report *ImportReport) error {
+	var evt nostr.Event
+	if err := json.Unmarshal(line, &evt); err != nil || !evt.CheckID() || !evt.VerifySignature() {
+		report.Invalid++
+		return nil
+	}
+
+	if _, ok := seen[evt.ID]; ok {
+		report.Duplicates++
+		return nil
+	}
+	seen[evt.ID] = struct{}{}
+
+	for range sys.Store.QueryEvents(nostr.Filter{IDs: []nostr.ID{evt.ID}}, 1) {
+		report.Duplicates++
+		return nil
+	}
+
+	if err := sys.Publisher.Publish(ctx, evt); err != nil {
+		if errors.Is(err, eventstore.ErrDupEvent) {
+			report.Duplicates++
+			return nil
+		}
+		return err
+	}
+	report.Imported++
+	return nil
+}
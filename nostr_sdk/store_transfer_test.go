@@ -0,0 +1,56 @@
+package nostr_sdk
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"fiatjaf.com/nostr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportImportEvents(t *testing.T) {
+	ctx := context.Background()
+	src := newFetchEventsTestSystem(t)
+	sk := nostr.Generate()
+
+	for i, kind := range []nostr.Kind{1, 1, 7} {
+		evt := nostr.Event{Kind: kind, CreatedAt: nostr.Timestamp(100 + i), Content: "hello", Tags: nostr.Tags{{"t", "go"}}}
+		require.NoError(t, evt.Sign(sk))
+		require.NoError(t, src.Store.SaveEvent(evt))
+	}
+
+	var out bytes.Buffer
+	exported, err := src.ExportEvents(ctx, nostr.Filter{Kinds: []nostr.Kind{1}, Since: 101}, &out)
+	require.NoError(t, err)
+	require.Equal(t, 1, exported)
+
+	out.Reset()
+	exported, err = src.ExportEvents(ctx, nostr.Filter{}, &out)
+	require.NoError(t, err)
+	require.Equal(t, 3, exported)
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 3)
+
+	// a repeated line, a tampered event and garbage on top of the export
+	input := out.String() + lines[0] + "\n\n" +
+		strings.Replace(lines[1], `"hello"`, `"changed"`, 1) + "\n" +
+		"not json\n"
+
+	dst := newFetchEventsTestSystem(t)
+	report, err := dst.ImportEvents(ctx, strings.NewReader(input))
+	require.NoError(t, err)
+	require.Equal(t, ImportReport{Read: 6, Imported: 3, Duplicates: 1, Invalid: 2}, report)
+
+	// importing again only finds duplicates
+	report, err = dst.ImportEvents(ctx, strings.NewReader(out.String()))
+	require.NoError(t, err)
+	require.Equal(t, ImportReport{Read: 3, Duplicates: 3}, report)
+
+	count := 0
+	for range dst.Store.QueryEvents(nostr.Filter{}, 10) {
+		count++
+	}
+	require.Equal(t, 3, count)
+}
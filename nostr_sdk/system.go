@@ -75,10 +75,14 @@ type System struct {
 	JustIDRelays              *RelayStream
 	UserSearchRelays          *RelayStream
 	NoteSearchRelays          *RelayStream
+	CountRelays               *RelayStream
 	Store                     eventstore.Store
 
 	Publisher nostr.Publisher
 
+	// RelayLimiter throttles the batch fetch paths per relay. A nil limiter disables throttling.
+	RelayLimiter *RelayLimiter
+
 	replaceableLoaders map[nostr.Kind]*dataloader.Loader[nostr.PubKey, nostr.Event]
 	addressableLoaders map[nostr.Kind]*dataloader.Loader[nostr.PubKey, []nostr.Event]
 }
@@ -151,7 +155,13 @@ func NewSystem() *System {
 			"wss://relay.nostr.band",
 			"wss://search.nos.today",
 		),
-		Hints: memoryh.NewHintDB(),
+		CountRelays: NewRelayStream(
+			"wss://relay.nostr.band",
+			"wss://antiprimal.net",
+			"wss://relay.ditto.pub",
+		),
+		Hints:        memoryh.NewHintDB(),
+		RelayLimiter: NewRelayLimiter(DefaultRelayLimits, nil),
 	}
 
 	sys.Pool = nostr.NewPool(nostr.PoolOptions{
@@ -159,6 +169,11 @@ func NewSystem() *System {
 		EventMiddleware:           sys.TrackEventHintsAndRelays,
 		DuplicateMiddleware:       sys.TrackEventRelaysD,
 		PenaltyBox:                true,
+		RelayOptions: nostr.RelayOptions{
+			NoticeHandler: func(relay *nostr.Relay, notice string) {
+				sys.RelayLimiter.Observe(relay.URL, notice)
+			},
+		},
 	})
 
 	sys.metadataCacheOnce.Do(func() {
@@ -191,7 +206,10 @@ func NewSystem() *System {
 		sys.Store = &nullstore.NullStore{}
 		sys.Store.Init()
 	}
-	sys.Publisher = wrappers.DynamicPublisher{GetStore: func() eventstore.Store { return sys.Store }, MaxLimit: 1000}
+	sys.Publisher = accessTrackingPublisher{
+		Publisher: wrappers.DynamicPublisher{GetStore: func() eventstore.Store { return sys.Store }, MaxLimit: 1000},
+		sys:       sys,
+	}
 
 	sys.initializeReplaceableDataloaders()
 	sys.initializeAddressableDataloaders()
@@ -199,6 +217,13 @@ func NewSystem() *System {
 	return sys
 }
 
+// HasLocalStore reports whether events are kept in a real store, rather than in the
+// NullStore used when there is no data directory.
+func (sys *System) HasLocalStore() bool {
+	_, null := sys.Store.(*nullstore.NullStore)
+	return sys.Store != nil && !null
+}
+
 // Close releases resources held by the System.
 func (sys *System) Close() error {
 	if sys == nil {
@@ -507,18 +532,47 @@ func (sys *System) FetchFollowedTimelinePage(
 	return events, nil
 }
 
+// profileBatchWorkers bounds how many profiles FetchProfilesBatch looks up at the same time.
+const profileBatchWorkers = 32
+
 // FetchProfilesBatch fetches profile metadata for multiple pubkeys in batch.
 func (sys *System) FetchProfilesBatch(
 	ctx context.Context,
 	pubkeys []nostr.PubKey,
 ) map[nostr.PubKey]*nostr.Event {
 	results := make(map[nostr.PubKey]*nostr.Event)
+	mu := sync.Mutex{}
+	wg := sync.WaitGroup{}
+
+	// run the lookups together so the metadata dataloader can batch them, but at most
+	// profileBatchWorkers at a time; the relays themselves are throttled by sys.RelayLimiter
+	jobs := make(chan nostr.PubKey)
+	for range min(profileBatchWorkers, len(pubkeys)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for pk := range jobs {
+				pm := sys.FetchProfileMetadata(ctx, pk)
+				if pm.Event != nil {
+					mu.Lock()
+					results[pk] = pm.Event
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+feed:
 	for _, pk := range pubkeys {
-		pm := sys.FetchProfileMetadata(ctx, pk)
-		if pm.Event != nil {
-			results[pk] = pm.Event
+		select {
+		case jobs <- pk:
+		case <-ctx.Done():
+			break feed
 		}
 	}
+	close(jobs)
+	wg.Wait()
+
 	return results
 }
 
@@ -573,6 +627,7 @@ func (sys *System) FetchEventsByFilter(
 
 	if !opts.SkipLocalStore {
 		for evt := range sys.Store.QueryEvents(filter, limit) {
+			sys.TrackEventAccessTime(evt.ID)
 			if appendEvent(evt) {
 				slices.SortFunc(results, nostr.CompareEventReverse)
 				return results, nil
@@ -608,6 +663,13 @@ func (sys *System) FetchEventsByFilter(
 	return results, nil
 }
 
+// RelaysForFilter returns the relays to query for filter when none are given: the ID
+// relays for event IDs, the outbox relays of a single author, the relay list relays
+// for community definitions, and the fallback relays otherwise.
+func (sys *System) RelaysForFilter(ctx context.Context, filter nostr.Filter) []string {
+	return sys.defaultRelaysForFilter(ctx, filter)
+}
+
 func (sys *System) defaultRelaysForFilter(ctx context.Context, filter nostr.Filter) []string {
 	if len(filter.IDs) > 0 {
 		relays := append([]string{}, sys.JustIDRelays.URLs...)
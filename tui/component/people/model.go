@@ -0,0 +1,262 @@
+// Package people is a profile picker that views embed to let the user choose someone,
+// e.g. to mention in a note or to start a DM with.
+package people
+
+import (
+	"context"
+	"strings"
+
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/list"
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/jerry-harm/nosmec/config"
+	"github.com/jerry-harm/nosmec/utils"
+)
+
+// SelectedMsg is sent when a person was picked; the picker has closed itself.
+type SelectedMsg struct {
+	Person utils.Person
+}
+
+// CancelledMsg is sent when the picker was closed without picking anyone.
+type CancelledMsg struct{}
+
+type item struct {
+	person utils.Person
+}
+
+func (i item) Title() string {
+	title := i.person.Profile.ShortName()
+	if i.person.Alias != "" && i.person.Alias != title {
+		title += " (" + i.person.Alias + ")"
+	}
+	switch {
+	case i.person.Followed:
+		title += " ✓ following"
+	case i.person.InWoT:
+		title += " · web of trust"
+	}
+	return title
+}
+
+func (i item) Description() string {
+	if i.person.Profile.NIP05 != "" {
+		return i.person.Profile.NIP05 + "  " + i.person.Profile.NpubShort()
+	}
+	return i.person.Profile.NpubShort()
+}
+
+func (i item) FilterValue() string { return i.person.Profile.ShortName() }
+
+type resultsMsg struct {
+	gen    int
+	people []utils.Person
+	err    error
+}
+
+type keyMap struct {
+	search key.Binding
+	pick   key.Binding
+	focus  key.Binding
+	cancel key.Binding
+}
+
+func newKeyMap() keyMap {
+	return keyMap{
+		search: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "search"),
+		),
+		pick: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "pick"),
+		),
+		focus: key.NewBinding(
+			key.WithKeys("tab", "down"),
+			key.WithHelp("tab", "results"),
+		),
+		cancel: key.NewBinding(
+			key.WithKeys("esc"),
+			key.WithHelp("esc", "cancel"),
+		),
+	}
+}
+
+// Model is the picker. It does nothing until Open is called; while it is active the
+// embedding view should hand it every message and render its View instead of its own.
+// Create it with New; the zero Model is never active.
+type Model struct {
+	app    *config.AppContext
+	keys   keyMap
+	input  textinput.Model
+	list   list.Model
+	prompt lipgloss.Style
+	status lipgloss.Style
+
+	active    bool
+	gen       int // identifies the latest search, older results are dropped
+	searching bool
+	errMsg    string
+}
+
+// New returns an inactive picker.
+func New(app *config.AppContext) Model {
+	t := app.Theme()
+	m := Model{
+		app:    app,
+		keys:   newKeyMap(),
+		prompt: lipgloss.NewStyle().Foreground(t.Primary).Bold(true),
+		status: lipgloss.NewStyle().Foreground(t.StatusText),
+	}
+
+	m.input = textinput.New()
+	m.input.Prompt = "person> "
+	m.input.Placeholder = "name, NIP-05 address or alias"
+
+	m.list = list.New(nil, list.NewDefaultDelegate(), 0, 0)
+	m.list.Title = "People"
+	m.list.Styles.Title = lipgloss.NewStyle().
+		Foreground(t.TitleText).
+		Background(t.TitleBg).
+		Padding(0, 1)
+	m.list.SetFilteringEnabled(false)
+	m.list.SetShowHelp(false)
+	return m
+}
+
+// Open activates the picker, searching for query right away unless it is empty.
+func (m *Model) Open(query string) tea.Cmd {
+	m.active = true
+	m.errMsg = ""
+	m.input.SetValue(query)
+	m.list.SetItems(nil)
+	if strings.TrimSpace(query) != "" {
+		return m.search()
+	}
+	return m.input.Focus()
+}
+
+// Active reports whether the picker is open.
+func (m Model) Active() bool {
+	return m.active
+}
+
+// SetSize sets the area the picker renders in.
+func (m *Model) SetSize(width, height int) {
+	if m.app == nil {
+		// the zero Model, never opened
+		return
+	}
+	m.input.SetWidth(width - len(m.input.Prompt) - 1)
+	// the input line, a blank line and the status line
+	m.list.SetSize(width, height-3)
+}
+
+func (m *Model) search() tea.Cmd {
+	m.gen++
+	m.searching = true
+	m.errMsg = ""
+	m.input.Blur()
+
+	gen, query := m.gen, m.input.Value()
+	return tea.Batch(m.list.StartSpinner(), func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), m.app.QueryTimeout())
+		defer cancel()
+		people, err := utils.SearchPeople(ctx, m.app, query, utils.PeopleSearchOptions{})
+		return resultsMsg{gen: gen, people: people, err: err}
+	})
+}
+
+func (m *Model) close(msg tea.Msg) tea.Cmd {
+	m.active = false
+	m.gen++
+	m.searching = false
+	m.list.StopSpinner()
+	return func() tea.Msg { return msg }
+}
+
+// Update handles a message while the picker is active.
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	if !m.active {
+		return m, nil
+	}
+
+	switch msg := msg.(type) {
+	case resultsMsg:
+		if msg.gen != m.gen {
+			return m, nil
+		}
+		m.searching = false
+		m.list.StopSpinner()
+		if msg.err != nil {
+			m.errMsg = msg.err.Error()
+			return m, m.input.Focus()
+		}
+		items := make([]list.Item, len(msg.people))
+		for i, p := range msg.people {
+			items[i] = item{person: p}
+		}
+		if len(items) == 0 {
+			m.errMsg = "nobody found"
+			return m, tea.Batch(m.list.SetItems(nil), m.input.Focus())
+		}
+		return m, m.list.SetItems(items)
+
+	case tea.KeyPressMsg:
+		if key.Matches(msg, m.keys.cancel) {
+			return m, m.close(CancelledMsg{})
+		}
+
+		if m.input.Focused() {
+			switch {
+			case key.Matches(msg, m.keys.search):
+				if strings.TrimSpace(m.input.Value()) == "" {
+					return m, nil
+				}
+				return m, m.search()
+			case key.Matches(msg, m.keys.focus):
+				if len(m.list.Items()) > 0 {
+					m.input.Blur()
+				}
+				return m, nil
+			}
+			var cmd tea.Cmd
+			m.input, cmd = m.input.Update(msg)
+			return m, cmd
+		}
+
+		switch {
+		case key.Matches(msg, m.keys.pick):
+			if it, ok := m.list.SelectedItem().(item); ok {
+				return m, m.close(SelectedMsg{Person: it.person})
+			}
+			return m, nil
+		case msg.String() == "/" || msg.String() == "tab":
+			return m, m.input.Focus()
+		}
+	}
+
+	var cmd tea.Cmd
+	m.list, cmd = m.list.Update(msg)
+	return m, cmd
+}
+
+// View renders the picker.
+func (m Model) View() string {
+	var b strings.Builder
+	b.WriteString(m.prompt.Render(m.input.View()))
+	b.WriteString("\n\n")
+	b.WriteString(m.list.View())
+	b.WriteString("\n")
+	switch {
+	case m.errMsg != "":
+		b.WriteString(m.status.Render(m.errMsg))
+	case m.input.Focused():
+		b.WriteString(m.status.Render("enter: search | tab: results | esc: cancel"))
+	default:
+		b.WriteString(m.status.Render("enter: pick | /: edit search | esc: cancel"))
+	}
+	return b.String()
+}
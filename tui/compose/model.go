@@ -15,11 +15,13 @@ import (
 	"charm.land/bubbles/v2/textarea"
 	"charm.land/bubbles/v2/textinput"
 	"github.com/jerry-harm/nosmec/tui/component/bubblon"
+	"github.com/jerry-harm/nosmec/tui/component/people"
 	"charm.land/lipgloss/v2"
 	tea "charm.land/bubbletea/v2"
 	"fiatjaf.com/nostr"
 	"fiatjaf.com/nostr/nip10"
 	"github.com/jerry-harm/nosmec/config"
+	"github.com/jerry-harm/nosmec/nostr_sdk"
 	"github.com/jerry-harm/nosmec/utils"
 )
 
@@ -55,6 +57,7 @@ type model struct {
 	contentInput textarea.Model
 	tagInput     textinput.Model
 	spinner      spinner.Model
+	picker       people.Model // chooses whom to mention
 
 	tags         []Tag
 	editingIndex int // -2=not in tag mode, -1=empty slot, >=0=editing tags[editingIndex]
@@ -63,6 +66,8 @@ type model struct {
 	success   bool
 	sending   bool
 	statusMsg string
+	report    *nostr_sdk.PublishReport // per-relay outcome of the last send
+	pending   int                      // events waiting in the outgoing queue
 }
 
 type keyMap struct {
@@ -74,6 +79,7 @@ type keyMap struct {
 	addTag        key.Binding
 	removeTag     key.Binding
 	deselectTag   key.Binding
+	mention       key.Binding
 }
 
 func newKeyMap() *keyMap {
@@ -110,27 +116,33 @@ func newKeyMap() *keyMap {
 			key.WithKeys("esc"),
 			key.WithHelp("esc", "deselect"),
 		),
+		mention: key.NewBinding(
+			key.WithKeys("ctrl+o"),
+			key.WithHelp("ctrl+o", "mention"),
+		),
 	}
 }
 
 func (k *keyMap) ShortHelp() []key.Binding {
-	return []key.Binding{k.send, k.quit, k.nextField}
+	return []key.Binding{k.send, k.quit, k.nextField, k.mention}
 }
 
 func (k *keyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
 		{k.send, k.quit},
 		{k.nextField, k.prevField},
-		{k.addTag},
+		{k.addTag, k.mention},
 	}
 }
 
 type sendErrorMsg struct {
-	err string
+	err    string
+	report *nostr_sdk.PublishReport
 }
 
 type sendSuccessMsg struct {
 	eventID string
+	report  *nostr_sdk.PublishReport
 }
 
 func NewNoteCompose(app *config.AppContext) *model {
@@ -184,10 +196,19 @@ m.styles = newStyles(m.app.Theme())
 
 	m.spinner = spinner.New(spinner.WithSpinner(spinner.Dot))
 	m.spinner.Style = lipgloss.NewStyle().Foreground(m.styles.t.Spinner)
+	m.picker = people.New(app)
+	m.refreshPending()
 
 	return m
 }
 
+func (m *model) refreshPending() {
+	if m.app == nil {
+		return
+	}
+	m.pending = utils.PendingPublishCount(m.app)
+}
+
 // AddReplyFromTarget sets up the compose model for replying to an event using
 // the pre-computed ReplyTarget from utils.DetermineReplyTarget.
 func (m *model) AddReplyFromTarget(ctx context.Context, app *config.AppContext, parentEvent *nostr.Event, target utils.ReplyTarget) {
@@ -275,6 +296,7 @@ func (m *model) ClearDraft() {
 	m.composeKind = KindNote
 	m.errMsg = ""
 	m.success = false
+	m.report = nil
 }
 
 func (m *model) Init() tea.Cmd {
@@ -291,6 +313,16 @@ func (m *model) ID() string {
 func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 
+	if m.picker.Active() {
+		switch msg.(type) {
+		case tea.BackgroundColorMsg, tea.WindowSizeMsg:
+		default:
+			var cmd tea.Cmd
+			m.picker, cmd = m.picker.Update(msg)
+			return m, cmd
+		}
+	}
+
 	switch msg := msg.(type) {
 case tea.BackgroundColorMsg:
 		m.styles = newStyles(m.app.Theme())
@@ -301,8 +333,16 @@ case tea.BackgroundColorMsg:
 		m.height = msg.Height
 		m.contentInput.SetWidth(msg.Width - 4)
 		m.tagInput.SetWidth(msg.Width - 4)
+		m.picker.SetSize(msg.Width-4, msg.Height-4)
 		return m, nil
 
+	case people.SelectedMsg:
+		m.addMention(msg.Person)
+		return m, m.contentInput.Focus()
+
+	case people.CancelledMsg:
+		return m, m.contentInput.Focus()
+
 	case spinner.TickMsg:
 		newSpinner, cmd := m.spinner.Update(msg)
 		m.spinner = newSpinner
@@ -312,6 +352,8 @@ case tea.BackgroundColorMsg:
 		m.errMsg = msg.err
 		m.statusMsg = "Failed: " + msg.err
 		m.sending = false
+		m.report = msg.report
+		m.refreshPending()
 		// Stay on compose page so user can retry — don't close
 		return m, nil
 
@@ -321,6 +363,13 @@ case tea.BackgroundColorMsg:
 		m.statusMsg = "Posted successfully!"
 		m.sending = false
 		m.ClearDraft()
+		m.refreshPending()
+		// Keep the window open when some relays failed so the user can see which ones
+		if msg.report != nil && len(msg.report.Failed()) > 0 {
+			m.success = true
+			m.report = msg.report
+			return m, nil
+		}
 		if m.isStandalone {
 			return m, tea.Quit
 		}
@@ -337,6 +386,14 @@ case tea.BackgroundColorMsg:
 			os.Exit(0)
 		}
 
+		if key.Matches(msg, m.keys.mention) {
+			m.kindInput.Blur()
+			m.tagInput.Blur()
+			m.contentInput.Blur()
+			m.editingIndex = -2
+			return m, m.picker.Open("")
+		}
+
 		if key.Matches(msg, m.keys.quit) {
 			if m.editingIndex >= 0 {
 				m.saveTagEdit()
@@ -504,6 +561,19 @@ func (m *model) nextField() {
 func (m *model) prevField() {
 }
 
+// addMention inserts a NIP-27 reference to p at the cursor and tags them, so they
+// get notified.
+func (m *model) addMention(p utils.Person) {
+	m.contentInput.InsertString("nostr:" + p.Profile.Npub() + " ")
+	pubkey := p.Profile.PubKey.Hex()
+	for _, t := range m.tags {
+		if len(t) >= 2 && t[0] == "p" && t[1] == pubkey {
+			return
+		}
+	}
+	m.tags = append(m.tags, Tag{"p", pubkey})
+}
+
 func (m *model) saveTagEdit() {
 	if m.editingIndex >= 0 && m.editingIndex < len(m.tags) {
 		tagValue := strings.TrimSpace(m.tagInput.Value())
@@ -543,28 +613,17 @@ func (m *model) sendContent(content string) tea.Cmd {
 			return sendErrorMsg{err: err.Error()}
 		}
 
-		var failedRelays []string
-		var hasSuccess bool
-
 		writableRelays := m.app.AllWritableRelays()
-		if len(writableRelays) > 0 {
-			resultChan := m.app.Pool().PublishMany(ctx, writableRelays, *event)
-			for result := range resultChan {
-				if result.Error != nil {
-					failedRelays = append(failedRelays, result.RelayURL)
-				} else {
-					hasSuccess = true
-				}
-			}
-		} else {
+		if len(writableRelays) == 0 {
 			return sendErrorMsg{err: "no writable relays configured"}
 		}
 
-		if hasSuccess {
-			return sendSuccessMsg{eventID: event.ID.Hex()}
+		report, err := utils.PublishEvent(ctx, m.app, writableRelays, event)
+		if err != nil {
+			return sendErrorMsg{err: "no relay accepted the event", report: report}
 		}
 
-		return sendErrorMsg{err: strings.Join(failedRelays, ", ")}
+		return sendSuccessMsg{eventID: event.ID.Hex(), report: report}
 	}
 }
 
@@ -618,6 +677,9 @@ func (m *model) renderView() string {
 	if m.sending {
 		return m.renderSendingOverlay()
 	}
+	if m.picker.Active() {
+		return m.styles.header.Render("Mention someone") + "\n\n" + m.picker.View()
+	}
 
 	var b strings.Builder
 
@@ -630,7 +692,21 @@ func (m *model) renderView() string {
 	}
 
 	if m.success {
-		b.WriteString(m.styles.successMsg.Render("Posted successfully!"))
+		msg := "Posted successfully!"
+		if m.report != nil && len(m.report.Accepted()) == 0 {
+			msg = "Saved to the outgoing queue; it will be sent once a relay is reachable."
+		}
+		b.WriteString(m.styles.successMsg.Render(msg))
+		b.WriteString("\n\n")
+	}
+
+	if m.report != nil {
+		b.WriteString(m.styles.statusText.Render(utils.FormatPublishReport(m.report)))
+		b.WriteString("\n")
+	}
+
+	if m.pending > 0 {
+		b.WriteString(m.styles.statusText.Render(fmt.Sprintf("%d event(s) waiting in the outgoing queue (nosmec queue list)", m.pending)))
 		b.WriteString("\n\n")
 	}
 
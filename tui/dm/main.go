@@ -10,6 +10,21 @@ import (
 	"github.com/jerry-harm/nosmec/config"
 )
 
+// RunDMPicker asks whom to write to, then opens the DM TUI with them.
+func RunDMPicker(app *config.AppContext) error {
+	if len(os.Getenv("DEBUG")) > 0 {
+		f, err := tea.LogToFile("debug.log", "debug")
+		if err != nil {
+			fmt.Println("fatal:", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+	}
+
+	_, err := tea.NewProgram(newRecipientModel(app)).Run()
+	return err
+}
+
 func RunDM(app *config.AppContext, npubOrHex string) error {
 	_, decoded, err := nip19.Decode(npubOrHex)
 	if err != nil {
@@ -341,7 +341,7 @@ func (m *model) sendDM(content string) tea.Cmd {
 		ctx, cancel := context.WithTimeout(context.Background(), m.app.QueryTimeout())
 		defer cancel()
 
-		err := utils.SendDM(ctx, m.app, m.recipientPubKey, content)
+		_, err := utils.SendDM(ctx, m.app, m.recipientPubKey, content)
 		if err != nil {
 			return sendErrorMsg{err: err.Error()}
 		}
@@ -0,0 +1,65 @@
+package dm
+
+import (
+	tea "charm.land/bubbletea/v2"
+	"github.com/jerry-harm/nosmec/config"
+	"github.com/jerry-harm/nosmec/tui/component/people"
+)
+
+// recipientModel lets the user pick whom to write to, then becomes the chat with them.
+type recipientModel struct {
+	app    *config.AppContext
+	picker people.Model
+	chat   *model
+	width  int
+	height int
+}
+
+func newRecipientModel(app *config.AppContext) *recipientModel {
+	return &recipientModel{app: app, picker: people.New(app)}
+}
+
+func (r *recipientModel) Init() tea.Cmd {
+	return tea.Batch(tea.RequestBackgroundColor, r.picker.Open(""))
+}
+
+func (r *recipientModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if r.chat != nil {
+		// the chat becomes the program's model from here on
+		return r.chat.Update(msg)
+	}
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		r.width, r.height = msg.Width, msg.Height
+		r.picker.SetSize(msg.Width-4, msg.Height-4)
+		return r, nil
+
+	case people.SelectedMsg:
+		r.chat = NewModel(r.app, msg.Person.Profile.PubKey)
+		size := tea.WindowSizeMsg{Width: r.width, Height: r.height}
+		return r, tea.Batch(r.chat.Init(), func() tea.Msg { return size })
+
+	case people.CancelledMsg:
+		return r, tea.Quit
+
+	case tea.KeyPressMsg:
+		if msg.String() == "ctrl+c" {
+			return r, tea.Quit
+		}
+	}
+
+	var cmd tea.Cmd
+	r.picker, cmd = r.picker.Update(msg)
+	return r, cmd
+}
+
+func (r *recipientModel) View() tea.View {
+	if r.chat != nil {
+		return r.chat.View()
+	}
+	s := newStyles(r.app.Theme())
+	v := tea.NewView(s.header.Render("New DM: pick a recipient") + "\n\n" + r.picker.View())
+	v.AltScreen = true
+	return v
+}
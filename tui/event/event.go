@@ -34,6 +34,14 @@ type ProfileLoadedMsg struct {
 	Name string
 }
 
+// CountsLoadedMsg carries the NIP-45 reply and reaction totals of an event. A count is
+// nil when no relay answered for it.
+type CountsLoadedMsg struct {
+	ID        nostr.ID
+	Replies   *nostr_sdk.CountResult
+	Reactions *nostr_sdk.CountResult
+}
+
 type EventView struct {
 	event        *nostr.Event
 	eventID      string
@@ -51,6 +59,8 @@ type EventView struct {
 	ownEvent     bool
 	help         help.Model
 	keys         eventKeyMap
+	replies      *nostr_sdk.CountResult
+	reactions    *nostr_sdk.CountResult
 
 	ctrl           *bubblon.Controller
 	confirmDelete  bool
@@ -159,7 +169,10 @@ func (m *EventView) Init() tea.Cmd {
 	logger.Debug("EventView.Init called", "fetchedName", m.fetchedName, "fetchedEvent", m.fetchedEvent, "loading", m.loading)
 
 	if m.fetchedEvent && !m.fetchedName {
-		return m.fetchProfileNameAsync()
+		return tea.Batch(m.fetchProfileNameAsync(), m.fetchCountsAsync())
+	}
+	if m.fetchedEvent {
+		return m.fetchCountsAsync()
 	}
 
 	if !m.fetchedEvent && m.eventID != "" {
@@ -194,6 +207,32 @@ func (m *EventView) fetchProfileNameAsync() tea.Cmd {
 	}
 }
 
+func (m *EventView) fetchCountsAsync() tea.Cmd {
+	if m.event == nil {
+		return nil
+	}
+	id := m.event.ID
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), m.app.QueryTimeout())
+		defer cancel()
+
+		msg := CountsLoadedMsg{ID: id}
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			if r := m.app.System().CountReactions(ctx, id); r.Answered() > 0 {
+				msg.Reactions = &r
+			}
+		}()
+		if r := m.app.System().CountReplies(ctx, id); r.Answered() > 0 {
+			msg.Replies = &r
+		}
+		<-done
+		logger.Debug("fetchCountsAsync done", "replies", msg.Replies, "reactions", msg.Reactions)
+		return msg
+	}
+}
+
 func (m *EventView) isOwnEvent() bool {
 	if m.event == nil {
 		return false
@@ -356,7 +395,7 @@ func (m *EventView) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.fetchedEvent = true
 		if m.event != nil {
 			m.ownEvent = m.isOwnEvent()
-			return m, m.fetchProfileNameAsync()
+			return m, tea.Batch(m.fetchProfileNameAsync(), m.fetchCountsAsync())
 		}
 		return m, nil
 
@@ -368,6 +407,13 @@ func (m *EventView) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.fetchedName = true
 		return m, nil
 
+	case CountsLoadedMsg:
+		if m.event != nil && msg.ID == m.event.ID {
+			m.replies = msg.Replies
+			m.reactions = msg.Reactions
+		}
+		return m, nil
+
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
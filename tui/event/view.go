@@ -50,9 +50,28 @@ func (m *EventView) renderHeader() string {
 		lines += "\n" + fmt.Sprintf("via: %s", m.styles.relaySource.Render(relays))
 	}
 
+	// Line 5: reply and reaction totals, once a relay answered the COUNT
+	if counts := m.renderCounts(); counts != "" {
+		lines += "\n" + counts
+	}
+
 	return m.styles.header.Render(lines)
 }
 
+func (m *EventView) renderCounts() string {
+	var parts []string
+	if m.replies != nil {
+		parts = append(parts, fmt.Sprintf("Replies: %s", m.replies))
+	}
+	if m.reactions != nil {
+		parts = append(parts, fmt.Sprintf("Reactions: %s", m.reactions))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return m.styles.relaySource.Render(strings.Join(parts, " | "))
+}
+
 func (m *EventView) renderContent() string {
 	// Show loading state
 	if m.loading || m.event == nil {
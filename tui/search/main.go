@@ -0,0 +1,31 @@
+package search
+
+import (
+	"fmt"
+	"os"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/jerry-harm/nosmec/config"
+	"github.com/jerry-harm/nosmec/tui/component/bubblon"
+)
+
+// RunSearch opens the search view, running query right away unless it is empty.
+func RunSearch(app *config.AppContext, query string, localOnly bool) error {
+	if len(os.Getenv("DEBUG")) > 0 {
+		f, err := tea.LogToFile("debug.log", "debug")
+		if err != nil {
+			fmt.Println("fatal:", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+	}
+
+	m := NewModel(app, query, localOnly)
+	ctrl, err := bubblon.New(m)
+	if err != nil {
+		return err
+	}
+	m.SetBubblonController(&ctrl)
+	_, err = tea.NewProgram(ctrl).Run()
+	return err
+}
@@ -0,0 +1,450 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/list"
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"fiatjaf.com/nostr"
+	"github.com/jerry-harm/nosmec/config"
+	"github.com/jerry-harm/nosmec/nostr_sdk"
+	"github.com/jerry-harm/nosmec/tui/component/bubblon"
+	"github.com/jerry-harm/nosmec/tui/event"
+	"github.com/jerry-harm/nosmec/tui/theme"
+	"github.com/jerry-harm/nosmec/tui/timeline"
+	"github.com/jerry-harm/nosmec/utils"
+)
+
+// searchTimeout bounds one page of results; relays that are slower are dropped.
+const searchTimeout = 30 * time.Second
+
+type item struct {
+	result     utils.SearchResult
+	authorName string
+}
+
+func (i item) Title() string {
+	return timeline.RenderTitle(i.result.Event, i.authorName) + " " + formatSources(i.result.Sources)
+}
+func (i item) Description() string { return timeline.RenderDescription(i.result.Event.Content) }
+func (i item) FilterValue() string { return i.result.Event.Content }
+
+type styles struct {
+	app           lipgloss.Style
+	title         lipgloss.Style
+	statusMessage lipgloss.Style
+	prompt        lipgloss.Style
+}
+
+func newStyles(t *theme.Theme) styles {
+	return styles{
+		app: lipgloss.NewStyle().
+			Padding(1, 2),
+		title: lipgloss.NewStyle().
+			Foreground(t.TitleText).
+			Background(t.TitleBg).
+			Padding(0, 1),
+		statusMessage: lipgloss.NewStyle().
+			Foreground(t.StatusText),
+		prompt: lipgloss.NewStyle().
+			Foreground(t.Primary).
+			Bold(true),
+	}
+}
+
+type keyMap struct {
+	search   key.Binding
+	open     key.Binding
+	loadMore key.Binding
+	quit     key.Binding
+	kill     key.Binding
+}
+
+func newKeyMap() keyMap {
+	return keyMap{
+		search: key.NewBinding(
+			key.WithKeys("/"),
+			key.WithHelp("/", "new search"),
+		),
+		open: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "view"),
+		),
+		loadMore: key.NewBinding(
+			key.WithKeys("m"),
+			key.WithHelp("m", "load more"),
+		),
+		quit: key.NewBinding(
+			key.WithKeys("esc"),
+			key.WithHelp("esc", "quit"),
+		),
+		kill: key.NewBinding(
+			key.WithKeys("ctrl+c"),
+			key.WithHelp("ctrl+c", "kill"),
+		),
+	}
+}
+
+// searchStartedMsg carries the hits of a page once the query has been compiled.
+type searchStartedMsg struct {
+	gen    int
+	hits   <-chan utils.SearchHit
+	cancel context.CancelFunc
+}
+
+type searchHitMsg struct {
+	gen int
+	hit utils.SearchHit
+}
+
+type searchDoneMsg struct {
+	gen int
+}
+
+type searchErrorMsg struct {
+	gen int
+	err error
+}
+
+type nameMsg struct {
+	pubkey nostr.PubKey
+	name   string
+}
+
+type model struct {
+	styles styles
+	width  int
+	height int
+	keys   keyMap
+	input  textinput.Model
+	list   list.Model
+
+	app       *config.AppContext
+	ctrl      *bubblon.Controller
+	localOnly bool
+
+	// gen identifies the current page, so hits of a cancelled one are dropped
+	gen       int
+	query     string
+	hits      <-chan utils.SearchHit
+	cancel    context.CancelFunc
+	searching bool
+	pageNew   int  // results the current page added
+	hasMore   bool // the last page added something, so older results may exist
+	seen      map[nostr.ID]bool
+	names     map[nostr.PubKey]string
+}
+
+// NewModel returns the search view. With a query the search starts right away and
+// the result list has the focus, otherwise the query input has it.
+func NewModel(app *config.AppContext, query string, localOnly bool) *model {
+	m := &model{
+		app:       app,
+		localOnly: localOnly,
+		keys:      newKeyMap(),
+		seen:      make(map[nostr.ID]bool),
+		names:     make(map[nostr.PubKey]string),
+	}
+	m.styles = newStyles(app.Theme())
+
+	m.input = textinput.New()
+	m.input.Prompt = "search> "
+	m.input.Placeholder = "bitcoin OR lightning kinds:1 since:7d -scam"
+	m.input.SetValue(query)
+
+	delegate := list.NewDefaultDelegate()
+	m.list = list.New(nil, delegate, 0, 0)
+	m.list.Title = "Search"
+	m.list.Styles.Title = m.styles.title
+	m.list.SetFilteringEnabled(false)
+	m.list.AdditionalShortHelpKeys = func() []key.Binding {
+		return []key.Binding{m.keys.open, m.keys.search, m.keys.loadMore}
+	}
+
+	if query == "" {
+		m.input.Focus()
+	}
+	return m
+}
+
+// SetBubblonController stores the controller used to open the event view.
+func (m *model) SetBubblonController(ctrl *bubblon.Controller) {
+	m.ctrl = ctrl
+}
+
+func (m *model) Init() tea.Cmd {
+	cmds := []tea.Cmd{tea.RequestBackgroundColor}
+	if q := strings.TrimSpace(m.input.Value()); q != "" {
+		cmds = append(cmds, m.newSearch(q))
+	} else {
+		cmds = append(cmds, textinput.Blink)
+	}
+	return tea.Batch(cmds...)
+}
+
+// newSearch drops the current results and runs q.
+func (m *model) newSearch(q string) tea.Cmd {
+	if m.cancel != nil {
+		m.cancel()
+		m.cancel = nil
+	}
+	m.query = q
+	m.seen = make(map[nostr.ID]bool)
+	m.hasMore = true
+	m.list.Title = "Search: " + q
+	return tea.Batch(m.list.SetItems(nil), m.startPage(0))
+}
+
+// loadMore fetches the results older than the oldest one shown. until is inclusive, so
+// results sharing that second are asked for again, to not skip the ones not shown yet;
+// those already shown are told apart by m.seen.
+func (m *model) loadMore() tea.Cmd {
+	items := m.list.Items()
+	if m.searching || !m.hasMore || len(items) == 0 {
+		return nil
+	}
+	oldest := items[len(items)-1].(item).result.Event.CreatedAt
+	return m.startPage(oldest)
+}
+
+func (m *model) startPage(until nostr.Timestamp) tea.Cmd {
+	m.gen++
+	m.searching = true
+	m.pageNew = 0
+
+	gen, query := m.gen, m.query
+	opts := utils.SearchOptions{Until: until, LocalOnly: m.localOnly}
+	return tea.Batch(m.list.StartSpinner(), func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), searchTimeout)
+		hits, err := utils.StreamSearch(ctx, m.app, query, opts)
+		if err != nil {
+			cancel()
+			return searchErrorMsg{gen: gen, err: err}
+		}
+		return searchStartedMsg{gen: gen, hits: hits, cancel: cancel}
+	})
+}
+
+// waitForHit delivers the next hit of a page, or searchDoneMsg once it is complete.
+func waitForHit(gen int, hits <-chan utils.SearchHit) tea.Cmd {
+	return func() tea.Msg {
+		hit, ok := <-hits
+		if !ok {
+			return searchDoneMsg{gen: gen}
+		}
+		return searchHitMsg{gen: gen, hit: hit}
+	}
+}
+
+func (m *model) fetchName(pubkey nostr.PubKey) tea.Cmd {
+	if _, ok := m.names[pubkey]; ok {
+		return nil
+	}
+	m.names[pubkey] = ""
+	return func() tea.Msg {
+		pm := m.app.System().FetchProfileMetadata(context.Background(), pubkey)
+		if pm.Event != nil {
+			if meta, err := nostr_sdk.ParseMetadata(*pm.Event); err == nil && meta.Name != "" {
+				return nameMsg{pubkey: pubkey, name: meta.Name}
+			}
+		}
+		return nameMsg{pubkey: pubkey, name: pm.ShortName()}
+	}
+}
+
+// addHit merges hit into the list, which stays sorted newest first.
+func (m *model) addHit(hit utils.SearchHit) tea.Cmd {
+	items := m.list.Items()
+	if m.seen[hit.Event.ID] {
+		for i, li := range items {
+			if it := li.(item); it.result.Event.ID == hit.Event.ID {
+				it.result.AddSource(hit.Source)
+				return m.list.SetItem(i, it)
+			}
+		}
+		return nil
+	}
+	m.seen[hit.Event.ID] = true
+	m.pageNew++
+
+	it := item{authorName: m.names[hit.Event.PubKey]}
+	it.result.Event = hit.Event
+	it.result.AddSource(hit.Source)
+
+	pos := sort.Search(len(items), func(i int) bool {
+		return items[i].(item).result.Event.CreatedAt < hit.Event.CreatedAt
+	})
+	return tea.Batch(m.list.InsertItem(pos, it), m.fetchName(hit.Event.PubKey))
+}
+
+func (m *model) resize() {
+	h, v := m.styles.app.GetFrameSize()
+	m.input.SetWidth(m.width - h - len(m.input.Prompt) - 1)
+	// the input takes one line and a blank one separates it from the list
+	m.list.SetSize(m.width-h, m.height-v-2)
+}
+
+func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.BackgroundColorMsg:
+		m.styles = newStyles(m.app.Theme())
+		m.list.Styles.Title = m.styles.title
+		return m, nil
+
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.resize()
+		return m, nil
+
+	case searchStartedMsg:
+		if msg.gen != m.gen {
+			msg.cancel()
+			return m, nil
+		}
+		m.hits, m.cancel = msg.hits, msg.cancel
+		return m, waitForHit(msg.gen, msg.hits)
+
+	case searchHitMsg:
+		if msg.gen != m.gen {
+			return m, nil
+		}
+		return m, tea.Batch(m.addHit(msg.hit), waitForHit(m.gen, m.hits))
+
+	case searchDoneMsg:
+		if msg.gen != m.gen {
+			return m, nil
+		}
+		m.searching = false
+		m.list.StopSpinner()
+		if m.pageNew == 0 {
+			m.hasMore = false
+		}
+		status := fmt.Sprintf("%d results", len(m.list.Items()))
+		if !m.hasMore {
+			status += ", no more"
+		}
+		return m, m.list.NewStatusMessage(m.styles.statusMessage.Render(status))
+
+	case searchErrorMsg:
+		if msg.gen != m.gen {
+			return m, nil
+		}
+		m.searching = false
+		m.list.StopSpinner()
+		return m, m.list.NewStatusMessage(m.styles.statusMessage.Render("Error: " + msg.err.Error()))
+
+	case nameMsg:
+		m.names[msg.pubkey] = msg.name
+		var cmds []tea.Cmd
+		for i, li := range m.list.Items() {
+			if it := li.(item); it.result.Event.PubKey == msg.pubkey {
+				it.authorName = msg.name
+				cmds = append(cmds, m.list.SetItem(i, it))
+			}
+		}
+		return m, tea.Batch(cmds...)
+
+	case event.ProfileLoadedMsg:
+		// Forward profile name result to the event view via controller
+		_, cmd := m.ctrl.Update(msg)
+		return m, cmd
+
+	case tea.KeyPressMsg:
+		if key.Matches(msg, m.keys.kill) {
+			if m.cancel != nil {
+				m.cancel()
+			}
+			os.Exit(0)
+		}
+		if m.input.Focused() {
+			return m.updateInput(msg)
+		}
+
+		switch {
+		case key.Matches(msg, m.keys.search):
+			m.input.SetValue("")
+			return m, m.input.Focus()
+
+		case key.Matches(msg, m.keys.open):
+			if it, ok := m.list.SelectedItem().(item); ok {
+				ev := event.New(&it.result.Event, m.app, m.width, m.height, it.authorName, m.ctrl)
+				return m, bubblon.Open(ev)
+			}
+			return m, nil
+
+		case key.Matches(msg, m.keys.loadMore):
+			return m, m.loadMore()
+
+		case key.Matches(msg, m.keys.quit):
+			if m.cancel != nil {
+				m.cancel()
+			}
+			if m.ctrl != nil && m.ctrl.Models() > 1 {
+				return m, func() tea.Msg { return bubblon.Close() }
+			}
+			return m, tea.Quit
+		}
+	}
+
+	var cmds []tea.Cmd
+	var cmd tea.Cmd
+	m.list, cmd = m.list.Update(msg)
+	cmds = append(cmds, cmd)
+
+	// like the timeline, fetch older results when reaching the last page
+	if p := m.list.Paginator; p.TotalPages > 1 && p.OnLastPage() {
+		cmds = append(cmds, m.loadMore())
+	}
+	return m, tea.Batch(cmds...)
+}
+
+func (m *model) updateInput(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		q := strings.TrimSpace(m.input.Value())
+		if q == "" {
+			return m, nil
+		}
+		m.input.Blur()
+		return m, m.newSearch(q)
+
+	case "esc":
+		if m.query == "" {
+			return m, tea.Quit
+		}
+		// back to the current results
+		m.input.SetValue(m.query)
+		m.input.Blur()
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+func (m *model) View() tea.View {
+	input := m.styles.prompt.Render(m.input.View())
+	v := tea.NewView(m.styles.app.Render(input + "\n\n" + m.list.View()))
+	v.AltScreen = true
+	return v
+}
+
+// formatSources shortens relay URLs to their host for the result title.
+func formatSources(sources []string) string {
+	short := make([]string, len(sources))
+	for i, s := range sources {
+		s = strings.TrimPrefix(s, "wss://")
+		s = strings.TrimPrefix(s, "ws://")
+		short[i] = strings.TrimSuffix(s, "/")
+	}
+	return "[" + strings.Join(short, ", ") + "]"
+}
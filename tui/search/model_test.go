@@ -0,0 +1,65 @@
+package search
+
+import (
+	"testing"
+
+	"charm.land/bubbles/v2/list"
+	"fiatjaf.com/nostr"
+	"github.com/jerry-harm/nosmec/utils"
+)
+
+func newTestModel() *model {
+	delegate := list.NewDefaultDelegate()
+	return &model{
+		list:  list.New(nil, delegate, 0, 0),
+		seen:  make(map[nostr.ID]bool),
+		names: make(map[nostr.PubKey]string),
+	}
+}
+
+func TestAddHit_SortedAndMerged(t *testing.T) {
+	m := newTestModel()
+
+	older := nostr.Event{ID: nostr.ID{1}, CreatedAt: 100}
+	newer := nostr.Event{ID: nostr.ID{2}, CreatedAt: 200}
+	m.addHit(utils.SearchHit{Event: older, Source: "wss://a.example"})
+	m.addHit(utils.SearchHit{Event: newer, Source: utils.LocalSearchSource})
+	m.addHit(utils.SearchHit{Event: older, Source: "wss://b.example"})
+
+	items := m.list.Items()
+	if len(items) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(items))
+	}
+	if got := items[0].(item).result.Event.ID; got != newer.ID {
+		t.Errorf("expected newest event first, got %v", got)
+	}
+	sources := items[1].(item).result.Sources
+	if len(sources) != 2 || sources[0] != "wss://a.example" || sources[1] != "wss://b.example" {
+		t.Errorf("expected both relays as sources, got %v", sources)
+	}
+	if m.pageNew != 2 {
+		t.Errorf("expected 2 new results on the page, got %d", m.pageNew)
+	}
+}
+
+func TestLoadMore_NothingToLoad(t *testing.T) {
+	m := newTestModel()
+	m.hasMore = true
+	if cmd := m.loadMore(); cmd != nil {
+		t.Error("expected no command for an empty list")
+	}
+
+	m.addHit(utils.SearchHit{Event: nostr.Event{ID: nostr.ID{1}, CreatedAt: 100}, Source: "wss://a.example"})
+	m.hasMore = false
+	if cmd := m.loadMore(); cmd != nil {
+		t.Error("expected no command once results ran out")
+	}
+}
+
+func TestFormatSources(t *testing.T) {
+	got := formatSources([]string{utils.LocalSearchSource, "wss://relay.example/", "ws://other.example"})
+	want := "[local, relay.example, other.example]"
+	if got != want {
+		t.Errorf("formatSources() = %q, want %q", got, want)
+	}
+}
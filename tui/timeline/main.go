@@ -10,6 +10,19 @@ import (
 )
 
 func RunTimeline(app *config.AppContext, filter string, hashtags []string, limit int, communityAddr string) error {
+	return run(NewModel(app, filter, hashtags, limit, communityAddr))
+}
+
+// RunSearchTimeline shows the hits of the saved search name, live.
+func RunSearchTimeline(app *config.AppContext, name string, limit int) error {
+	tlModel, err := NewSearchModel(app, name, limit)
+	if err != nil {
+		return err
+	}
+	return run(tlModel)
+}
+
+func run(tlModel *model) error {
 	if len(os.Getenv("DEBUG")) > 0 {
 		f, err := tea.LogToFile("debug.log", "debug")
 		if err != nil {
@@ -19,7 +32,6 @@ func RunTimeline(app *config.AppContext, filter string, hashtags []string, limit
 		defer f.Close()
 	}
 
-	tlModel := NewModel(app, filter, hashtags, limit, communityAddr)
 	ctrl, err := bubblon.New(tlModel)
 	if err != nil {
 		fmt.Println("fatal:", err)
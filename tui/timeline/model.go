@@ -1,9 +1,11 @@
 package timeline
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"os"
+	"slices"
 	"strings"
 	"time"
 
@@ -43,6 +45,7 @@ type item struct {
 	event      TimelineEvent
 	authorName string
 	kind       eventKind
+	isNew      bool // a saved search hit newer than the last one seen
 }
 
 func (i item) Title() string       { return formatItemTitle(i) }
@@ -109,6 +112,13 @@ type model struct {
 	limit         int
 	communityAddr string
 
+	// Saved search timeline (filter "search")
+	searchName  string
+	searchQuery string
+	lastSeen    nostr.Timestamp // newest hit shown in an earlier session
+	newestShown nostr.Timestamp
+	newHits     int
+
 	ctrl *bubblon.Controller
 
 	// Infinite scroll state
@@ -235,6 +245,21 @@ func NewModel(app *config.AppContext, filter string, hashtags []string, limit in
 	return m
 }
 
+// NewSearchModel returns a timeline of the hits of the saved search name. Hits newer
+// than the newest one shown last time are highlighted.
+func NewSearchModel(app *config.AppContext, name string, limit int) (*model, error) {
+	query, ok := app.GetSavedSearch(name)
+	if !ok {
+		return nil, fmt.Errorf("saved search not found: %s", name)
+	}
+	m := NewModel(app, "search", nil, limit, "")
+	m.searchName = name
+	m.searchQuery = query
+	m.lastSeen = app.System().SavedSearchLastSeen(name)
+	m.list.Title = m.title()
+	return m, nil
+}
+
 // SetBubblonController stores the bubblon controller so the timeline
 // model can navigate back to the parent when used as a child view.
 func (m *model) SetBubblonController(ctrl *bubblon.Controller) {
@@ -300,6 +325,8 @@ func (m *model) fetchTimeline() tea.Cmd {
 			}
 
 			rawEvents, err = ext.FetchFollowedTimelinePage(ctx, nil, []string{m.communityAddr}, m.limit, 0)
+		case "search":
+			rawEvents, err = m.fetchSearchPage(ctx, 0)
 		default:
 			subs := m.app.ListSubscriptions("")
 			var authors []nostr.PubKey
@@ -362,6 +389,48 @@ func (m *model) fetchTimeline() tea.Cmd {
 	}
 }
 
+// fetchSearchPage runs the saved search, returning the hits older than until (if set)
+// newest first.
+func (m *model) fetchSearchPage(ctx context.Context, until nostr.Timestamp) ([]nostr.Event, error) {
+	results, err := utils.SearchEvents(ctx, m.app, m.searchQuery, utils.SearchOptions{Limit: m.limit, Until: until})
+	if err != nil {
+		return nil, err
+	}
+	events := make([]nostr.Event, len(results))
+	for i, r := range results {
+		events[i] = r.Event
+	}
+	slices.SortFunc(events, func(a, b nostr.Event) int {
+		return cmp.Compare(b.CreatedAt, a.CreatedAt)
+	})
+	return events, nil
+}
+
+// trackHit records an event shown on a saved search timeline and reports whether it
+// is newer than the last hit seen before.
+func (m *model) trackHit(evt nostr.Event) bool {
+	if m.searchName == "" {
+		return false
+	}
+	m.newestShown = max(m.newestShown, evt.CreatedAt)
+	if evt.CreatedAt <= m.lastSeen {
+		return false
+	}
+	m.newHits++
+	return true
+}
+
+// markSearchSeen remembers the newest hit shown, so the next session only highlights
+// what came after it.
+func (m *model) markSearchSeen() {
+	if m.searchName == "" || m.newestShown <= m.lastSeen {
+		return
+	}
+	if err := m.app.System().SetSavedSearchLastSeen(m.searchName, m.newestShown); err != nil {
+		logger.Debug("saving search last seen failed", "search", m.searchName, "error", err)
+	}
+}
+
 func (m *model) fetchProfileNames(pubkeys []string) tea.Cmd {
 	return func() tea.Msg {
 		if len(pubkeys) == 0 {
@@ -442,6 +511,8 @@ func (m *model) fetchMoreOld() tea.Cmd {
 				return loadMoreErrorMsg{err: parseErr, isNew: false}
 			}
 			rawEvents, err = ext.FetchFollowedTimelinePage(ctx, nil, []string{m.communityAddr}, m.limit, until)
+		case "search":
+			rawEvents, err = m.fetchSearchPage(ctx, until)
 		default:
 			subs := m.app.ListSubscriptions("")
 			var authors []nostr.PubKey
@@ -523,6 +594,16 @@ func (m *model) startSubscription(since nostr.Timestamp) tea.Cmd {
 		m.subCtx = ctx
 		m.subCancel = cancel
 
+		if m.filter == "search" {
+			ch, err := utils.SubscribeSearch(ctx, m.app, m.searchQuery, since)
+			if err != nil {
+				return errorMsg{err: err}
+			}
+			m.subCh = ch
+			m.subStarted = true
+			return nil
+		}
+
 		relays := m.app.AllReadableRelays()
 
 		// Build filter based on timeline type
@@ -652,6 +733,7 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case fetchMsg:
 		m.list.StopSpinner()
+		m.newHits = 0
 		items := make([]list.Item, 0, len(msg.events))
 		pubkeys := make([]string, 0, len(msg.events))
 		seenPubkeys := make(map[string]bool)
@@ -691,13 +773,18 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				event:      e,
 				authorName: authorName,
 				kind:       kind,
+				isNew:      m.trackHit(e.Event),
 			})
 		}
 		m.list.SetItems(items)
+		m.list.Title = m.title()
 
 		// Start subscription after initial fetch
 		if newestTimestamp > 0 {
 			m.newestSince = newestTimestamp
+		} else if m.filter == "search" {
+			// no hits yet, but keep watching for new ones
+			m.newestSince = nostr.Now()
 		}
 
 		// Fetch profile names asynchronously
@@ -788,6 +875,7 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				event:      e,
 				authorName: authorName,
 				kind:       kind,
+				isNew:      m.trackHit(e.Event),
 			})
 		}
 
@@ -800,6 +888,9 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 		m.list.SetItems(currentItems)
+		if m.searchName != "" {
+			m.list.Title = m.title()
+		}
 
 		// Fetch profile names for new items
 		if len(pubkeys) > 0 {
@@ -825,11 +916,15 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			event:      msg.event,
 			authorName: npubStr[:16], // placeholder truncated
 			kind:       kind,
+			isNew:      m.trackHit(msg.event.Event),
 		}
 
 		currentItems := m.list.Items()
 		currentItems = append([]list.Item{newItem}, currentItems...)
 		m.list.SetItems(currentItems)
+		if m.searchName != "" {
+			m.list.Title = m.title()
+		}
 
 		// Fetch profile name for new item and continue polling
 		return m, tea.Batch(
@@ -852,6 +947,7 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 		if key.Matches(msg, m.keys.kill) {
+			m.markSearchSeen()
 			os.Exit(0)
 		}
 
@@ -869,6 +965,7 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if m.subCancel != nil {
 				m.subCancel()
 			}
+			m.markSearchSeen()
 			if m.ctrl.Models() > 0 {
 				return m, func() tea.Msg { return bubblon.Close() }
 			}
@@ -919,6 +1016,25 @@ func (m *model) View() tea.View {
 	return v
 }
 
+// title is the list title: the saved search with its number of new hits, or the timeline.
+func (m *model) title() string {
+	if m.searchName == "" {
+		return timelineTitle(utils.PendingPublishCount(m.app))
+	}
+	if m.newHits == 0 {
+		return "Search: " + m.searchName
+	}
+	return fmt.Sprintf("Search: %s (%d new)", m.searchName, m.newHits)
+}
+
+// timelineTitle appends the number of queued outgoing events, if any, to the list title.
+func timelineTitle(pending int) string {
+	if pending == 0 {
+		return "Timeline"
+	}
+	return fmt.Sprintf("Timeline (%d queued)", pending)
+}
+
 func detectEventKind(e TimelineEvent) eventKind {
 	ev := e.Event
 	if ev.Kind == 6 || ev.Kind == 16 {
@@ -940,6 +1056,18 @@ func detectEventKind(e TimelineEvent) eventKind {
 	return kindNote
 }
 
+// RenderTitle renders the list title of evt the way the timeline does, so other
+// views listing events look the same. An empty authorName shows a loading label.
+func RenderTitle(evt nostr.Event, authorName string) string {
+	e := TimelineEvent{Event: evt}
+	return formatItemTitle(item{event: e, authorName: authorName, kind: detectEventKind(e)})
+}
+
+// RenderDescription renders the content preview of an event the way the timeline does.
+func RenderDescription(content string) string {
+	return formatItemDescription(content)
+}
+
 func formatItemTitle(i item) string {
 	pubkey := i.event.Event.PubKey.Hex()
 	author := i.authorName
@@ -964,6 +1092,10 @@ func formatItemTitle(i item) string {
 		prefix += " [Note]"
 	}
 
+	if i.isNew {
+		prefix = lipgloss.NewStyle().Foreground(theme.Default().Primary).Bold(true).Render("● ") + prefix
+	}
+
 	return prefix
 }
 
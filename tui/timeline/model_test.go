@@ -71,4 +71,25 @@ func TestListItem_EmptyItems(t *testing.T) {
 	if len(items) != 0 {
 		t.Errorf("len(items) = %d, want 0", len(items))
 	}
-}
\ No newline at end of file
+}
+func TestTrackHit_SavedSearch(t *testing.T) {
+	m := &model{searchName: "daily", lastSeen: 100}
+
+	if m.trackHit(nostr.Event{CreatedAt: 90}) {
+		t.Error("expected a hit older than the last seen one not to be new")
+	}
+	if !m.trackHit(nostr.Event{CreatedAt: 150}) {
+		t.Error("expected a hit newer than the last seen one to be new")
+	}
+	if m.newestShown != 150 || m.newHits != 1 {
+		t.Errorf("expected newestShown=150 newHits=1, got %d %d", m.newestShown, m.newHits)
+	}
+	if got := m.title(); got != "Search: daily (1 new)" {
+		t.Errorf("unexpected title %q", got)
+	}
+
+	plain := &model{}
+	if plain.trackHit(nostr.Event{CreatedAt: 150}) {
+		t.Error("expected no new hits outside a saved search timeline")
+	}
+}
@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"fiatjaf.com/nostr"
+	"github.com/jerry-harm/nosmec/config"
+	"github.com/jerry-harm/nosmec/nostr_sdk"
+)
+
+// maxBroadcastMentions caps how many mentioned users' inboxes an event is sent to.
+const maxBroadcastMentions = 20
+
+// BroadcastTargets selects the relays an event is rebroadcast to. When nothing is
+// selected our write relays are used.
+type BroadcastTargets struct {
+	Relays    []string       // explicit relay URLs
+	Write     bool           // our write relays
+	Outbox    bool           // the outbox relays of the event author
+	Mentioned bool           // the inbox relays of the users in the p tags
+	InboxOf   []nostr.PubKey // the inbox relays of these users
+}
+
+func (t BroadcastTargets) empty() bool {
+	return len(t.Relays) == 0 && !t.Write && !t.Outbox && !t.Mentioned && len(t.InboxOf) == 0
+}
+
+// BroadcastRelays resolves targets into a sorted list of normalized relay URLs for evt.
+func BroadcastRelays(ctx context.Context, app *config.AppContext, evt nostr.Event, targets BroadcastTargets) []string {
+	if targets.empty() {
+		targets.Write = true
+	}
+	sys := app.System()
+
+	relays := append([]string{}, targets.Relays...)
+	if targets.Write {
+		relays = append(relays, app.WritableRelays()...)
+	}
+	if targets.Outbox {
+		relays = append(relays, sys.FetchOutboxRelays(ctx, evt.PubKey, 5)...)
+	}
+	inboxOf := slices.Clone(targets.InboxOf)
+	if targets.Mentioned {
+		for _, tag := range evt.Tags {
+			if len(inboxOf) >= len(targets.InboxOf)+maxBroadcastMentions {
+				break
+			}
+			if len(tag) < 2 || tag[0] != "p" {
+				continue
+			}
+			if pk, err := nostr.PubKeyFromHex(tag[1]); err == nil && !slices.Contains(inboxOf, pk) {
+				inboxOf = append(inboxOf, pk)
+			}
+		}
+	}
+	for _, pk := range inboxOf {
+		relays = append(relays, sys.FetchInboxRelays(ctx, pk, 5)...)
+	}
+
+	for i, url := range relays {
+		relays[i] = nostr.NormalizeURL(url)
+	}
+	return slices.Compact(slices.Sorted(slices.Values(relays)))
+}
+
+// BroadcastEvent fetches the event given as a hex ID or a note, nevent or naddr code and
+// publishes it unchanged to the relays chosen by targets. Events whose ID or signature
+// does not check out are not sent anywhere. Rebroadcasts do not go through the outgoing
+// queue: relays that fail are only reported.
+func BroadcastEvent(ctx context.Context, app *config.AppContext, input string, targets BroadcastTargets) (*nostr_sdk.PublishReport, error) {
+	evt, _, err := app.System().FetchSpecificEventFromInput(ctx, input, nostr_sdk.FetchSpecificEventParameters{})
+	if err != nil {
+		return nil, err
+	}
+	if evt == nil {
+		return nil, fmt.Errorf("event not found")
+	}
+	if !evt.CheckID() || !evt.VerifySignature() {
+		return nil, fmt.Errorf("event %s has an invalid ID or signature", evt.ID.Hex())
+	}
+
+	relays := BroadcastRelays(ctx, app, *evt, targets)
+	if len(relays) == 0 {
+		return nil, fmt.Errorf("no relays to broadcast to")
+	}
+	return app.System().Publish(ctx, relays, *evt), nil
+}
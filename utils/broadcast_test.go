@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"fiatjaf.com/nostr"
+	"github.com/jerry-harm/nosmec/config"
+	"github.com/spf13/viper"
+)
+
+func TestBroadcastRelays(t *testing.T) {
+	app := config.NewAppContext(nil, config.Config{
+		DataDir: t.TempDir(),
+		RelayList: []config.Relay{
+			{URL: "wss://write.example.com", Write: config.BoolPtr(true)},
+			{URL: "wss://read.example.com"},
+		},
+	}, viper.New())
+	defer app.Close()
+
+	evt := nostr.Event{Kind: nostr.KindTextNote, PubKey: nostr.GetPublicKey(nostr.Generate())}
+	ctx := context.Background()
+
+	// nothing selected means our write relays
+	if got := BroadcastRelays(ctx, app, evt, BroadcastTargets{}); !slices.Equal(got, []string{"wss://write.example.com"}) {
+		t.Errorf("default targets = %v, want only the write relay", got)
+	}
+
+	// explicit relays replace the default, and are normalized and deduplicated
+	got := BroadcastRelays(ctx, app, evt, BroadcastTargets{Relays: []string{"other.example.com", "wss://other.example.com/"}})
+	if !slices.Equal(got, []string{"wss://other.example.com"}) {
+		t.Errorf("explicit targets = %v, want [wss://other.example.com]", got)
+	}
+
+	got = BroadcastRelays(ctx, app, evt, BroadcastTargets{Relays: []string{"wss://other.example.com"}, Write: true})
+	if !slices.Equal(got, []string{"wss://other.example.com", "wss://write.example.com"}) {
+		t.Errorf("combined targets = %v", got)
+	}
+}
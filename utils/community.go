@@ -56,7 +56,7 @@ func FetchCommunityEvents(ctx context.Context, app *config.AppContext) ([]Commun
 	return communities, nil
 }
 
-func CreateCommunity(ctx context.Context, app *config.AppContext, def CommunityDefinition) (*nostr.Event, error) {
+func CreateCommunity(ctx context.Context, app *config.AppContext, def CommunityDefinition) (*nostr_sdk.PublishReport, error) {
 	secretKey, err := app.GetMySecretKey()
 	if err != nil {
 		return nil, err
@@ -100,17 +100,7 @@ func CreateCommunity(ctx context.Context, app *config.AppContext, def CommunityD
 		return nil, fmt.Errorf("failed to sign community event: %v", err)
 	}
 
-	writableRelays := app.WritableRelays()
-	if len(writableRelays) > 0 {
-		resultChan := app.Pool().PublishMany(ctx, writableRelays, *event)
-		for result := range resultChan {
-			if result.Error != nil {
-				return nil, fmt.Errorf("failed to publish to %s: %w", result.RelayURL, result.Error)
-			}
-		}
-	}
-
-	return event, nil
+	return PublishEvent(ctx, app, app.WritableRelays(), event)
 }
 
 func ParseCommunityAddr(addr string) (nostr.PubKey, string, error) {
@@ -131,7 +121,7 @@ func ParseCommunityAddr(addr string) (nostr.PubKey, string, error) {
 	return pubKey, parts[2], nil
 }
 
-func PostToCommunity(ctx context.Context, app *config.AppContext, communityAddr string, content string, parentID string) (*nostr.Event, error) {
+func PostToCommunity(ctx context.Context, app *config.AppContext, communityAddr string, content string, parentID string) (*nostr_sdk.PublishReport, error) {
 	secretKey, err := app.GetMySecretKey()
 	if err != nil {
 		return nil, err
@@ -167,17 +157,7 @@ func PostToCommunity(ctx context.Context, app *config.AppContext, communityAddr
 		return nil, fmt.Errorf("failed to sign community post: %v", err)
 	}
 
-	writableRelays := app.WritableRelays()
-	if len(writableRelays) > 0 {
-		resultChan := app.Pool().PublishMany(ctx, writableRelays, *event)
-		for result := range resultChan {
-			if result.Error != nil {
-				return nil, fmt.Errorf("failed to publish to %s: %w", result.RelayURL, result.Error)
-			}
-		}
-	}
-
-	return event, nil
+	return PublishEvent(ctx, app, app.WritableRelays(), event)
 }
 
 func ApproveCommunityPost(ctx context.Context, app *config.AppContext, communityAuthor nostr.PubKey, communityID string, postEvent *nostr.Event) (*nostr.Event, error) {
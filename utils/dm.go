@@ -2,6 +2,7 @@ package utils
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sort"
 
@@ -11,12 +12,16 @@ import (
 	"fiatjaf.com/nostr/nip59"
 	"github.com/jerry-harm/nosmec/config"
 	"github.com/jerry-harm/nosmec/logger"
+	"github.com/jerry-harm/nosmec/nostr_sdk"
 )
 
-func SendDM(ctx context.Context, app *config.AppContext, recipientPubKey nostr.PubKey, content string) error {
+// SendDM gift-wraps a NIP-17 message and publishes one copy to the recipient's relays and
+// one to ours. Both copies go through the outgoing queue, so a message written while offline
+// is delivered once the relays are reachable.
+func SendDM(ctx context.Context, app *config.AppContext, recipientPubKey nostr.PubKey, content string) ([]*nostr_sdk.PublishReport, error) {
 	secretKey, err := app.GetMySecretKey()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	kr := keyer.NewPlainKeySigner(secretKey)
@@ -41,20 +46,34 @@ func SendDM(ctx context.Context, app *config.AppContext, recipientPubKey nostr.P
 
 	if len(theirRelays) == 0 {
 		logger.Debug("recipient has no published relay list, sending to our relays only")
-		theirRelays = nil
+		theirRelays = ourRelays
 	}
 
-	return nip17.PublishMessage(
+	toUs, toThem, err := nip17.PrepareMessage(
 		ctx,
 		content,
 		nostr.Tags{{"p", recipientPubKey.Hex()}},
-		app.Pool(),
-		ourRelays,
-		theirRelays,
 		kr,
 		recipientPubKey,
 		nil,
 	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap message: %w", err)
+	}
+
+	// publish our copy even if the recipient's fails, so the message still shows up in our
+	// history once the queue gets it out
+	theirReport, theirErr := PublishEvent(ctx, app, theirRelays, &toThem)
+	ourReport, ourErr := PublishEvent(ctx, app, ourRelays, &toUs)
+	reports := []*nostr_sdk.PublishReport{theirReport, ourReport}
+
+	if theirErr != nil {
+		theirErr = fmt.Errorf("failed to deliver message: %w", theirErr)
+	}
+	if ourErr != nil {
+		ourErr = fmt.Errorf("failed to store our copy: %w", ourErr)
+	}
+	return reports, errors.Join(theirErr, ourErr)
 }
 
 func ListenForDMs(ctx context.Context, app *config.AppContext, since nostr.Timestamp) chan nostr.Event {
@@ -82,15 +101,15 @@ func ListenForDMs(ctx context.Context, app *config.AppContext, since nostr.Times
 }
 
 type Conversation struct {
-	PubKey   string
-	LatestDM DMMessage
-	LatestAt nostr.Timestamp
+	PubKey   string          `json:"pubkey"`
+	LatestDM DMMessage       `json:"latest"`
+	LatestAt nostr.Timestamp `json:"latest_at"`
 }
 
 type DMMessage struct {
-	Content   string
-	FromMe    bool
-	Timestamp nostr.Timestamp
+	Content   string          `json:"content"`
+	FromMe    bool            `json:"from_me"`
+	Timestamp nostr.Timestamp `json:"created_at"`
 }
 
 func ListDMConversations(ctx context.Context, app *config.AppContext, limit int) ([]Conversation, error) {
@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"fiatjaf.com/nostr"
+	"github.com/jerry-harm/nosmec/config"
+	"github.com/jerry-harm/nosmec/nostr_sdk"
+)
+
+// EventSyncScopes are the sets of events `sync events` knows how to reconcile.
+var EventSyncScopes = []string{"own", "dms", "follows"}
+
+// EventSyncTargets returns the relay and filter pairs to reconcile for a scope:
+//   - own: our events, with our write relays
+//   - dms: gift wraps addressed to us, with our DM relays
+//   - follows: notes and reposts of followed users, with each author's outbox relays
+func EventSyncTargets(ctx context.Context, app *config.AppContext, scope string) ([]nostr.DirectedFilter, error) {
+	me, err := app.GetMyPubKey()
+	if err != nil {
+		return nil, err
+	}
+
+	switch scope {
+	case "own":
+		relays := config.GetWritableRelaysFromList(app.ListRelays())
+		if len(relays) == 0 {
+			return nil, fmt.Errorf("no write relays configured")
+		}
+		return directedFilters(relays, nostr.Filter{Authors: []nostr.PubKey{me}}), nil
+
+	case "dms":
+		relays := app.ListDMRelays()
+		if len(relays) == 0 {
+			return nil, fmt.Errorf("no DM relays configured")
+		}
+		return directedFilters(relays, nostr.Filter{
+			Kinds: []nostr.Kind{nostr.KindGiftWrap},
+			Tags:  nostr.TagMap{"p": []string{me.Hex()}},
+		}), nil
+
+	case "follows":
+		byRelay := make(map[string][]nostr.PubKey)
+		for _, sub := range app.ListSubscriptions("user") {
+			pk, err := ResolveAliasToPubKey(app, sub.ID)
+			if err != nil {
+				continue
+			}
+			for _, relay := range app.System().FetchOutboxRelays(ctx, pk, 2) {
+				byRelay[relay] = append(byRelay[relay], pk)
+			}
+		}
+		if len(byRelay) == 0 {
+			return nil, fmt.Errorf("not following anyone")
+		}
+
+		targets := make([]nostr.DirectedFilter, 0, len(byRelay))
+		for _, relay := range slices.Sorted(maps.Keys(byRelay)) {
+			targets = append(targets, nostr.DirectedFilter{
+				Relay: relay,
+				Filter: nostr.Filter{
+					Kinds:   []nostr.Kind{nostr.KindTextNote, nostr.KindRepost},
+					Authors: byRelay[relay],
+				},
+			})
+		}
+		return targets, nil
+	}
+
+	return nil, fmt.Errorf("unknown scope %q (expected %s)", scope, strings.Join(EventSyncScopes, ", "))
+}
+
+func directedFilters(relays []string, filter nostr.Filter) []nostr.DirectedFilter {
+	targets := make([]nostr.DirectedFilter, len(relays))
+	for i, relay := range relays {
+		targets[i] = nostr.DirectedFilter{Relay: relay, Filter: filter}
+	}
+	return targets
+}
+
+// FormatEventSyncResults prints one line per relay and a total for the scope.
+func FormatEventSyncResults(scope string, results []nostr_sdk.EventSyncResult) string {
+	var b strings.Builder
+	downloaded, uploaded := 0, 0
+	for _, r := range results {
+		method := "negentropy"
+		if !r.Negentropy {
+			method = "paging"
+		}
+		if r.Err != nil {
+			fmt.Fprintf(&b, "  %-40s failed: %v\n", r.Relay, r.Err)
+			continue
+		}
+		fmt.Fprintf(&b, "  %-40s %-10s %d down, %d up\n", r.Relay, method, r.Downloaded, r.Uploaded)
+		downloaded += r.Downloaded
+		uploaded += r.Uploaded
+	}
+	return fmt.Sprintf("%s: %d events downloaded, %d uploaded\n", scope, downloaded, uploaded) + b.String()
+}
@@ -2,9 +2,14 @@ package utils
 
 import (
 	"errors"
+	"fmt"
 	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
 	"fiatjaf.com/nostr"
+	"fiatjaf.com/nostr/nip19"
 )
 
 var ErrInvalidNoteID = errors.New("invalid note ID: must be 64 hex characters")
@@ -95,3 +100,58 @@ func BuildParentEventFilter(noteID string) (nostr.Filter, error) {
 		Limit: 1,
 	}, nil
 }
+
+// ParseEventID accepts a hex event ID or a note1/nevent1 code.
+func ParseEventID(s string) (nostr.ID, error) {
+	if noteIDRegex.MatchString(s) {
+		return nostr.IDFromHex(s)
+	}
+	_, decoded, err := nip19.Decode(s)
+	if err != nil {
+		return nostr.ID{}, ErrInvalidNoteID
+	}
+	switch v := decoded.(type) {
+	case nostr.EventPointer:
+		return v.ID, nil
+	case nostr.ID:
+		return v, nil
+	}
+	return nostr.ID{}, ErrInvalidNoteID
+}
+
+// ParseTimestamp accepts a unix timestamp, a date ("2024-05-01"), an RFC 3339 time
+// or a duration meaning that long ago ("7d", "12h").
+func ParseTimestamp(s string) (nostr.Timestamp, error) {
+	s = strings.TrimSpace(s)
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil && n >= 0 {
+		return nostr.Timestamp(n), nil
+	}
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return nostr.Timestamp(t.Unix()), nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return nostr.Timestamp(t.Unix()), nil
+	}
+	if d, err := ParseDuration(s); err == nil {
+		return nostr.Timestamp(time.Now().Add(-d).Unix()), nil
+	}
+	return 0, fmt.Errorf("invalid time %q", s)
+}
+
+// ParseTagFilters turns "name=value" pairs into a filter tag map. Repeating a name
+// matches any of its values.
+func ParseTagFilters(pairs []string) (nostr.TagMap, error) {
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+	tags := make(nostr.TagMap, len(pairs))
+	for _, pair := range pairs {
+		name, value, ok := strings.Cut(pair, "=")
+		name = strings.TrimPrefix(name, "#")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid tag filter %q, expected name=value", pair)
+		}
+		tags[name] = append(tags[name], value)
+	}
+	return tags, nil
+}
@@ -1,9 +1,12 @@
 package utils
 
 import (
+	"slices"
 	"testing"
+	"time"
 
 	"fiatjaf.com/nostr"
+	"fiatjaf.com/nostr/nip19"
 )
 
 func TestBuildNoteFilter(t *testing.T) {
@@ -68,6 +71,28 @@ func TestBuildNoteFilter_ID(t *testing.T) {
 	}
 }
 
+func TestParseEventID(t *testing.T) {
+	hex := "a1b2c3d4e5f6789012345678901234567890abcdef1234567890abcdef123456"
+	want, err := nostr.IDFromHex(hex)
+	if err != nil {
+		t.Fatalf("nostr.IDFromHex failed: %v", err)
+	}
+
+	for _, input := range []string{hex, nip19.EncodeNevent(want, nil, nostr.PubKey{})} {
+		got, err := ParseEventID(input)
+		if err != nil {
+			t.Fatalf("ParseEventID(%q) failed: %v", input, err)
+		}
+		if got != want {
+			t.Errorf("ParseEventID(%q) = %s, want %s", input, got.Hex(), hex)
+		}
+	}
+
+	if _, err := ParseEventID("not-an-id"); err == nil {
+		t.Error("ParseEventID accepted an invalid id")
+	}
+}
+
 func pubKeyFromHex(t *testing.T, hex string) nostr.PubKey {
 	pk, err := nostr.PubKeyFromHex(hex)
 	if err != nil {
@@ -214,4 +239,53 @@ func TestBuildParentEventFilter_InvalidID(t *testing.T) {
 	if err == nil {
 		t.Errorf("BuildParentEventFilter(%q) error = nil, wantErr true", "invalid")
 	}
-}
\ No newline at end of file
+}
+func TestParseTimestamp(t *testing.T) {
+	tests := []struct {
+		input string
+		want  nostr.Timestamp
+	}{
+		{"1700000000", 1700000000},
+		{"2024-05-01", 1714521600},
+		{"2024-05-01T12:00:00Z", 1714564800},
+	}
+	for _, tt := range tests {
+		got, err := ParseTimestamp(tt.input)
+		if err != nil {
+			t.Errorf("ParseTimestamp(%q) error: %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseTimestamp(%q) = %d, want %d", tt.input, got, tt.want)
+		}
+	}
+
+	got, err := ParseTimestamp("1d")
+	if err != nil {
+		t.Fatalf("ParseTimestamp(1d) error: %v", err)
+	}
+	if ago := time.Since(got.Time()); ago < 23*time.Hour || ago > 25*time.Hour {
+		t.Errorf("ParseTimestamp(1d) = %v ago, want about a day", ago)
+	}
+
+	if _, err := ParseTimestamp("yesterday"); err == nil {
+		t.Error("ParseTimestamp(yesterday) should fail")
+	}
+}
+
+func TestParseTagFilters(t *testing.T) {
+	tags, err := ParseTagFilters([]string{"t=nostr", "#t=go", "d=profile"})
+	if err != nil {
+		t.Fatalf("ParseTagFilters error: %v", err)
+	}
+	if !slices.Equal(tags["t"], []string{"nostr", "go"}) {
+		t.Errorf("t = %v", tags["t"])
+	}
+	if !slices.Equal(tags["d"], []string{"profile"}) {
+		t.Errorf("d = %v", tags["d"])
+	}
+
+	if _, err := ParseTagFilters([]string{"nostr"}); err == nil {
+		t.Error("ParseTagFilters without = should fail")
+	}
+}
@@ -0,0 +1,343 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"fiatjaf.com/nostr"
+	"fiatjaf.com/nostr/nip19"
+	"fiatjaf.com/nostr/nip27"
+	"github.com/jerry-harm/nosmec/config"
+	"github.com/jerry-harm/nosmec/nostr_sdk"
+)
+
+// EventInspection is what InspectEvent found out about an event.
+type EventInspection struct {
+	Event    nostr.Event `json:"event"`
+	Nevent   string      `json:"nevent"`
+	Note     string      `json:"note"`
+	Naddr    string      `json:"naddr,omitempty"` // addressable events only
+	Npub     string      `json:"npub"`
+	IDValid  bool        `json:"id_valid"`
+	SigValid bool        `json:"signature_valid"`
+
+	Tags     []InspectedTag `json:"tags"`
+	Mentions []Mention      `json:"mentions"` // nostr: references in the content
+
+	ExpiresAt nostr.Timestamp `json:"expires_at,omitempty"` // NIP-40
+	Expired   bool            `json:"expired,omitempty"`
+
+	Relays    []RelayPresence `json:"relays"`
+	Deleted   bool            `json:"deleted"`
+	Deletions []string        `json:"deletions,omitempty"` // nevent codes of the author's kind 5 events
+}
+
+// InspectedTag is a tag with its value decoded into a NIP-19 code where that makes sense.
+type InspectedTag struct {
+	Tag     nostr.Tag `json:"tag"`
+	Decoded string    `json:"decoded,omitempty"`
+}
+
+// Mention is a nostr: URI found in the content.
+type Mention struct {
+	URI     string `json:"uri"`
+	Type    string `json:"type"` // the NIP-19 prefix: npub, nprofile, note, nevent or naddr
+	Decoded string `json:"decoded"`
+}
+
+// RelayPresence tells whether a relay returned the event when asked for it. Error is set
+// when the relay could not be asked: it was unreachable, refused the query or timed out,
+// so the event may still be there.
+type RelayPresence struct {
+	Relay string `json:"relay"`
+	Found bool   `json:"found"`
+	Error string `json:"error,omitempty"`
+}
+
+// Verified reports whether both the ID and the signature are correct.
+func (i *EventInspection) Verified() bool {
+	return i.IDValid && i.SigValid
+}
+
+// NewEventInspection checks and decodes evt without contacting any relay; InspectEvent
+// adds where the event was found and whether it was deleted.
+func NewEventInspection(evt nostr.Event) *EventInspection {
+	insp := &EventInspection{
+		Event:    evt,
+		Nevent:   nip19.EncodeNevent(evt.ID, nil, evt.PubKey),
+		Note:     nip19.EncodeNote(evt.ID),
+		Npub:     nip19.EncodeNpub(evt.PubKey),
+		IDValid:  evt.CheckID(),
+		SigValid: evt.VerifySignature(),
+		Tags:     make([]InspectedTag, 0, len(evt.Tags)),
+		Mentions: []Mention{},
+	}
+	if evt.Kind.IsAddressable() {
+		insp.Naddr = nip19.EncodeNaddr(evt.PubKey, evt.Kind, evt.Tags.GetD(), nil)
+	}
+
+	for _, tag := range evt.Tags {
+		insp.Tags = append(insp.Tags, InspectedTag{Tag: tag, Decoded: decodeTag(tag)})
+		if len(tag) >= 2 && tag[0] == "expiration" {
+			if ts, err := strconv.ParseInt(tag[1], 10, 64); err == nil {
+				insp.ExpiresAt = nostr.Timestamp(ts)
+				insp.Expired = insp.ExpiresAt < nostr.Now()
+			}
+		}
+	}
+
+	for block := range nip27.Parse(evt.Content) {
+		if block.Pointer == nil {
+			continue
+		}
+		uri := strings.TrimPrefix(block.Text, "nostr:")
+		prefix, _, err := nip19.Decode(uri)
+		if err != nil {
+			continue
+		}
+		insp.Mentions = append(insp.Mentions, Mention{
+			URI:     block.Text,
+			Type:    prefix,
+			Decoded: describePointer(block.Pointer),
+		})
+	}
+
+	return insp
+}
+
+// decodeTag turns the value of a reference tag into a NIP-19 code, using the relay hint
+// in the third element if there is one.
+func decodeTag(tag nostr.Tag) string {
+	if len(tag) < 2 {
+		return ""
+	}
+	var relays []string
+	if len(tag) >= 3 && tag[2] != "" {
+		relays = []string{tag[2]}
+	}
+
+	switch tag[0] {
+	case "e", "E":
+		id, err := nostr.IDFromHex(tag[1])
+		if err != nil {
+			return ""
+		}
+		var author nostr.PubKey
+		if len(tag) >= 5 {
+			author, _ = nostr.PubKeyFromHex(tag[4])
+		}
+		return nip19.EncodeNevent(id, relays, author)
+	case "p", "P":
+		pk, err := nostr.PubKeyFromHex(tag[1])
+		if err != nil {
+			return ""
+		}
+		return nip19.EncodeNpub(pk)
+	case "a", "A":
+		return decodeAddress(tag[1], relays)
+	case "q":
+		if id, err := nostr.IDFromHex(tag[1]); err == nil {
+			var author nostr.PubKey
+			if len(tag) >= 4 {
+				author, _ = nostr.PubKeyFromHex(tag[3])
+			}
+			return nip19.EncodeNevent(id, relays, author)
+		}
+		return decodeAddress(tag[1], relays)
+	case "k", "K":
+		if _, err := strconv.Atoi(tag[1]); err == nil {
+			return "kind " + tag[1]
+		}
+	}
+	return ""
+}
+
+// decodeAddress turns a "<kind>:<pubkey>:<d>" address into an naddr code.
+func decodeAddress(addr string, relays []string) string {
+	parts := strings.SplitN(addr, ":", 3)
+	if len(parts) != 3 {
+		return ""
+	}
+	kind, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return ""
+	}
+	pk, err := nostr.PubKeyFromHex(parts[1])
+	if err != nil {
+		return ""
+	}
+	return nip19.EncodeNaddr(pk, nostr.Kind(kind), parts[2], relays)
+}
+
+// describePointer spells out what a decoded NIP-19 code points to.
+func describePointer(pointer nostr.Pointer) string {
+	var desc string
+	var relays []string
+	switch p := pointer.(type) {
+	case nostr.ProfilePointer:
+		desc, relays = "pubkey "+p.PublicKey.Hex(), p.Relays
+	case nostr.EventPointer:
+		desc, relays = "event "+p.ID.Hex(), p.Relays
+		if p.Author != nostr.ZeroPK {
+			desc += " by " + nip19.EncodeNpub(p.Author)
+		}
+	case nostr.EntityPointer:
+		desc, relays = fmt.Sprintf("address %d:%s:%s", p.Kind, p.PublicKey.Hex(), p.Identifier), p.Relays
+	default:
+		return ""
+	}
+	if len(relays) > 0 {
+		desc += " on " + strings.Join(relays, ", ")
+	}
+	return desc
+}
+
+// InspectEvent fetches the event given as a hex ID, note, nevent or naddr code, then asks
+// every relay it is known to be on, the author's outbox relays, the relay hints and our
+// read relays whether they have it and whether its author deleted it (NIP-09).
+func InspectEvent(ctx context.Context, app *config.AppContext, input string) (*EventInspection, error) {
+	sys := app.System()
+	evt, _, err := sys.FetchSpecificEventFromInput(ctx, input, nostr_sdk.FetchSpecificEventParameters{})
+	if err != nil {
+		return nil, err
+	}
+	if evt == nil {
+		return nil, fmt.Errorf("event not found")
+	}
+	insp := NewEventInspection(*evt)
+
+	relays := append([]string{}, sys.GetEventRelays(evt.ID)...)
+	if _, data, err := nip19.Decode(input); err == nil {
+		switch p := data.(type) {
+		case nostr.EventPointer:
+			relays = nostr.AppendUnique(relays, p.Relays...)
+		case nostr.EntityPointer:
+			relays = nostr.AppendUnique(relays, p.Relays...)
+		}
+	}
+	relays = nostr.AppendUnique(relays, sys.FetchOutboxRelays(ctx, evt.PubKey, 5)...)
+	relays = nostr.AppendUnique(relays, app.AllReadableRelays()...)
+	for i, url := range relays {
+		relays[i] = nostr.NormalizeURL(url)
+	}
+	relays = slices.Compact(slices.Sorted(slices.Values(relays)))
+
+	insp.Relays = make([]RelayPresence, len(relays))
+	wg := sync.WaitGroup{}
+	filter := nostr.Filter{IDs: []nostr.ID{evt.ID}}
+	for i, url := range relays {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			insp.Relays[i] = queryPresence(ctx, app, url, filter)
+		}()
+	}
+
+	deletions := findDeletions(ctx, app, relays, *evt)
+	wg.Wait()
+
+	for _, del := range deletions {
+		insp.Deletions = append(insp.Deletions, nip19.EncodeNevent(del.ID, nil, del.PubKey))
+	}
+	insp.Deleted = len(insp.Deletions) > 0
+	return insp, nil
+}
+
+// queryPresence asks one relay for the event in filter and tells apart a relay that does
+// not have it (EOSE without the event) from one that could not answer.
+func queryPresence(ctx context.Context, app *config.AppContext, url string, filter nostr.Filter) RelayPresence {
+	presence := RelayPresence{Relay: url}
+	relay, err := app.Pool().EnsureRelay(url)
+	if err != nil {
+		presence.Error = err.Error()
+		return presence
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, app.QueryTimeout())
+	defer cancel()
+	sub, err := relay.Subscribe(ctx, filter, nostr.SubscriptionOptions{Label: "inspect"})
+	if err != nil {
+		presence.Error = err.Error()
+		return presence
+	}
+	defer sub.Unsub()
+
+	for {
+		select {
+		case evt, more := <-sub.Events:
+			if !more {
+				presence.Error = "subscription ended before EOSE"
+				return presence
+			}
+			if slices.Contains(filter.IDs, evt.ID) {
+				presence.Found = true
+				return presence
+			}
+		case <-sub.EndOfStoredEvents:
+			return presence
+		case reason := <-sub.ClosedReason:
+			presence.Error = "closed: " + reason
+			return presence
+		case <-ctx.Done():
+			presence.Error = "timed out"
+			return presence
+		}
+	}
+}
+
+// findDeletions returns the kind 5 events by the author of evt that reference it, from
+// the local store and the given relays.
+func findDeletions(ctx context.Context, app *config.AppContext, relays []string, evt nostr.Event) []nostr.Event {
+	filters := []nostr.Filter{{
+		Kinds:   []nostr.Kind{nostr.KindDeletion},
+		Authors: []nostr.PubKey{evt.PubKey},
+		Tags:    nostr.TagMap{"e": []string{evt.ID.Hex()}},
+	}}
+	if evt.Kind.IsAddressable() || evt.Kind.IsReplaceable() {
+		addr := fmt.Sprintf("%d:%s:%s", evt.Kind, evt.PubKey.Hex(), evt.Tags.GetD())
+		filters = append(filters, nostr.Filter{
+			Kinds:   []nostr.Kind{nostr.KindDeletion},
+			Authors: []nostr.PubKey{evt.PubKey},
+			Tags:    nostr.TagMap{"a": []string{addr}},
+		})
+	}
+
+	var found []nostr.Event
+	seen := make(map[nostr.ID]bool)
+	add := func(del nostr.Event) {
+		// only the author can delete an event, and an address is only deleted up to the deletion time
+		if del.PubKey != evt.PubKey || seen[del.ID] {
+			return
+		}
+		if !referencesID(del, evt.ID) && del.CreatedAt < evt.CreatedAt {
+			return
+		}
+		seen[del.ID] = true
+		found = append(found, del)
+	}
+
+	for _, filter := range filters {
+		for del := range app.System().Store.QueryEvents(filter, 10) {
+			add(del)
+		}
+		if len(relays) > 0 {
+			for re := range app.Pool().FetchMany(ctx, relays, filter, nostr.SubscriptionOptions{Label: "inspect"}) {
+				add(re.Event)
+			}
+		}
+	}
+	return found
+}
+
+func referencesID(evt nostr.Event, id nostr.ID) bool {
+	for _, tag := range evt.Tags {
+		if len(tag) >= 2 && tag[0] == "e" && tag[1] == id.Hex() {
+			return true
+		}
+	}
+	return false
+}
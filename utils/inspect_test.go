@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"fiatjaf.com/nostr"
+)
+
+func TestNewEventInspection(t *testing.T) {
+	sk := nostr.Generate()
+	other := nostr.GetPublicKey(nostr.Generate())
+	evt := nostr.Event{
+		Kind:      nostr.KindTextNote,
+		CreatedAt: nostr.Now(),
+		Content:   "hello",
+		Tags: nostr.Tags{
+			{"p", other.Hex()},
+			{"e", strings.Repeat("ab", 32), "wss://relay.example.com", "reply"},
+			{"a", fmt.Sprintf("30023:%s:post", other.Hex())},
+			{"k", "1"},
+			{"t", "nostr"},
+			{"expiration", strconv.FormatInt(int64(nostr.Now())-60, 10)},
+		},
+	}
+	if err := evt.Sign(sk); err != nil {
+		t.Fatal(err)
+	}
+
+	insp := NewEventInspection(evt)
+	if !insp.Verified() {
+		t.Errorf("expected a valid ID and signature, got id=%v sig=%v", insp.IDValid, insp.SigValid)
+	}
+	if insp.Naddr != "" {
+		t.Errorf("expected no naddr for a text note, got %s", insp.Naddr)
+	}
+	if !insp.Expired || insp.ExpiresAt == 0 {
+		t.Errorf("expected the event to be expired, got expires_at=%d expired=%v", insp.ExpiresAt, insp.Expired)
+	}
+
+	prefixes := []string{"npub1", "nevent1", "naddr1", "kind 1", ""}
+	if len(insp.Tags) != len(evt.Tags) {
+		t.Fatalf("expected %d tags, got %d", len(evt.Tags), len(insp.Tags))
+	}
+	for i, want := range prefixes {
+		got := insp.Tags[i].Decoded
+		if want == "" && got != "" || !strings.HasPrefix(got, want) {
+			t.Errorf("tag %v decoded to %q, want prefix %q", insp.Tags[i].Tag, got, want)
+		}
+	}
+
+	evt.Content = "tampered"
+	if insp := NewEventInspection(evt); insp.IDValid || insp.Verified() {
+		t.Error("expected a tampered event to fail the ID check")
+	}
+}
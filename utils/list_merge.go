@@ -0,0 +1,222 @@
+package utils
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"fiatjaf.com/nostr"
+)
+
+// MergeStrategy decides how a list found on the network is combined with the local one.
+type MergeStrategy string
+
+const (
+	// MergeThreeWay applies changes made on either side since the last sync;
+	// entries changed on both sides keep the local version.
+	MergeThreeWay MergeStrategy = "merge"
+	// MergeInteractive is like MergeThreeWay but asks about each conflict.
+	MergeInteractive MergeStrategy = "interactive"
+	// MergeLocal keeps the local list untouched.
+	MergeLocal MergeStrategy = "local"
+	// MergeRemote replaces the local list with the network one.
+	MergeRemote MergeStrategy = "remote"
+)
+
+var MergeStrategies = []MergeStrategy{MergeThreeWay, MergeInteractive, MergeLocal, MergeRemote}
+
+func ParseMergeStrategy(s string) (MergeStrategy, error) {
+	for _, strategy := range MergeStrategies {
+		if string(strategy) == s {
+			return strategy, nil
+		}
+	}
+	return "", fmt.Errorf("unknown merge strategy %q (want merge, interactive, local or remote)", s)
+}
+
+// ConflictResolver picks the tag to keep for an entry changed both locally and on the network.
+// Returning nil drops the entry.
+type ConflictResolver func(change ListChange) nostr.Tag
+
+// SyncOptions controls how lists fetched from the network are applied locally.
+type SyncOptions struct {
+	Strategy MergeStrategy
+	DryRun   bool
+	Resolve  ConflictResolver // used by MergeInteractive; conflicts keep local when nil
+}
+
+// ListChange is one entry on which the local list and the network disagree.
+// Base, Local and Remote are nil when the entry is absent from that side.
+type ListChange struct {
+	Key      string
+	Base     nostr.Tag
+	Local    nostr.Tag
+	Remote   nostr.Tag
+	Result   nostr.Tag
+	Conflict bool
+}
+
+// ListDiff is the outcome of merging one list kind.
+type ListDiff struct {
+	Kind    nostr.Kind
+	Found   bool // whether the list exists on the network at all
+	Changes []ListChange
+	Result  nostr.Tags
+	Remote  nostr.Tags
+}
+
+// Modified reports whether applying the merge would change the local list.
+func (d ListDiff) Modified() bool {
+	for _, c := range d.Changes {
+		if !tagsEqual(c.Local, c.Result) {
+			return true
+		}
+	}
+	return false
+}
+
+// RemoteOutdated reports whether the merged list differs from the network one,
+// meaning it should be published again.
+func (d ListDiff) RemoteOutdated() bool {
+	for _, c := range d.Changes {
+		if !tagsEqual(c.Remote, c.Result) {
+			return true
+		}
+	}
+	return false
+}
+
+// MergeLists performs a three-way merge of list tags. base is the last synced snapshot
+// and may be nil, in which case entries present on only one side are kept.
+func MergeLists(kind nostr.Kind, base, local, remote nostr.Tags, strategy MergeStrategy, resolve ConflictResolver) ListDiff {
+	baseIdx := indexListTags(base)
+	localIdx := indexListTags(local)
+	remoteIdx := indexListTags(remote)
+
+	keys := make([]string, 0, len(local)+len(remote))
+	for _, tags := range []nostr.Tags{local, remote} {
+		for _, tag := range tags {
+			if key := listTagKey(tag); key != "" && !slices.Contains(keys, key) {
+				keys = append(keys, key)
+			}
+		}
+	}
+	// entries only in the base were removed on both sides and need no decision
+
+	diff := ListDiff{Kind: kind, Found: true, Remote: remote}
+	for _, key := range keys {
+		b, l, r := baseIdx[key], localIdx[key], remoteIdx[key]
+		if tagsEqual(l, r) {
+			diff.Result = append(diff.Result, l)
+			continue
+		}
+
+		change := ListChange{Key: key, Base: b, Local: l, Remote: r}
+		switch {
+		case strategy == MergeLocal:
+			change.Result = l
+		case strategy == MergeRemote:
+			change.Result = r
+		case tagsEqual(l, b):
+			change.Result = r
+		case tagsEqual(r, b):
+			change.Result = l
+		default:
+			change.Conflict = true
+			change.Result = l
+			if strategy == MergeInteractive && resolve != nil {
+				change.Result = resolve(change)
+			}
+		}
+
+		diff.Changes = append(diff.Changes, change)
+		if change.Result != nil {
+			diff.Result = append(diff.Result, change.Result)
+		}
+	}
+
+	return diff
+}
+
+// FormatListDiff describes what a merge does to the local list, one line per entry:
+// "+" added locally, "-" removed locally, "~" replaced, "=" kept as is.
+func FormatListDiff(d ListDiff) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "kind %d: ", d.Kind)
+	if !d.Found {
+		b.WriteString("not found on the network\n")
+		return b.String()
+	}
+	if len(d.Changes) == 0 {
+		b.WriteString("in sync\n")
+		return b.String()
+	}
+	fmt.Fprintf(&b, "%d differences\n", len(d.Changes))
+
+	for _, c := range d.Changes {
+		mark := "="
+		switch {
+		case c.Local == nil && c.Result != nil:
+			mark = "+"
+		case c.Local != nil && c.Result == nil:
+			mark = "-"
+		case !tagsEqual(c.Local, c.Result):
+			mark = "~"
+		}
+
+		fmt.Fprintf(&b, "  %s %s", mark, c.Key)
+		if c.Conflict {
+			b.WriteString("  [conflict]")
+		}
+		fmt.Fprintf(&b, "\n      local: %s  network: %s  base: %s\n",
+			formatListTag(c.Local), formatListTag(c.Remote), formatListTag(c.Base))
+	}
+	return b.String()
+}
+
+func formatListTag(tag nostr.Tag) string {
+	if tag == nil {
+		return "(none)"
+	}
+	return "[" + strings.Join(tag, " ") + "]"
+}
+
+// listTagKey identifies the entry a tag describes, regardless of its extra fields.
+func listTagKey(tag nostr.Tag) string {
+	if len(tag) < 2 {
+		return ""
+	}
+	value := tag[1]
+	if tag[0] == "r" || tag[0] == "relay" {
+		value = nostr.NormalizeURL(value)
+	}
+	return tag[0] + " " + value
+}
+
+func indexListTags(tags nostr.Tags) map[string]nostr.Tag {
+	idx := make(map[string]nostr.Tag, len(tags))
+	for _, tag := range tags {
+		if key := listTagKey(tag); key != "" {
+			idx[key] = tag
+		}
+	}
+	return idx
+}
+
+// tagsEqual compares two list tags ignoring trailing empty fields and relay URL normalization.
+func tagsEqual(a, b nostr.Tag) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	if listTagKey(a) != listTagKey(b) {
+		return false
+	}
+	return slices.Equal(trimTag(a[2:]), trimTag(b[2:]))
+}
+
+func trimTag(fields []string) []string {
+	for len(fields) > 0 && fields[len(fields)-1] == "" {
+		fields = fields[:len(fields)-1]
+	}
+	return fields
+}
@@ -0,0 +1,148 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"fiatjaf.com/nostr"
+)
+
+func TestMergeLists_ThreeWay(t *testing.T) {
+	base := nostr.Tags{{"r", "wss://a.example"}, {"r", "wss://b.example"}, {"r", "wss://c.example"}}
+	// locally c was removed and d added; on the network b was removed and e added
+	local := nostr.Tags{{"r", "wss://a.example"}, {"r", "wss://b.example"}, {"r", "wss://d.example"}}
+	remote := nostr.Tags{{"r", "wss://a.example"}, {"r", "wss://c.example"}, {"r", "wss://e.example"}}
+
+	diff := MergeLists(nostr.KindRelayListMetadata, base, local, remote, MergeThreeWay, nil)
+
+	got := relayURLs(diff.Result)
+	want := []string{"wss://a.example", "wss://d.example", "wss://e.example"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("MergeLists() result = %v, want %v", got, want)
+	}
+	for _, c := range diff.Changes {
+		if c.Conflict {
+			t.Errorf("unexpected conflict on %s", c.Key)
+		}
+	}
+	if !diff.Modified() {
+		t.Error("Modified() = false, want true")
+	}
+	if !diff.RemoteOutdated() {
+		t.Error("RemoteOutdated() = false, want true")
+	}
+}
+
+func TestMergeLists_Conflict(t *testing.T) {
+	base := nostr.Tags{{"r", "wss://a.example"}}
+	local := nostr.Tags{{"r", "wss://a.example", "read"}}
+	remote := nostr.Tags{{"r", "wss://a.example", "write"}}
+
+	tests := []struct {
+		strategy MergeStrategy
+		resolve  ConflictResolver
+		want     nostr.Tag
+	}{
+		{MergeThreeWay, nil, local[0]},
+		{MergeLocal, nil, local[0]},
+		{MergeRemote, nil, remote[0]},
+		{MergeInteractive, func(c ListChange) nostr.Tag { return c.Remote }, remote[0]},
+		{MergeInteractive, func(c ListChange) nostr.Tag { return nil }, nil},
+	}
+
+	for _, tt := range tests {
+		diff := MergeLists(nostr.KindRelayListMetadata, base, local, remote, tt.strategy, tt.resolve)
+		if len(diff.Changes) != 1 {
+			t.Fatalf("%s: got %d changes, want 1", tt.strategy, len(diff.Changes))
+		}
+		if tt.strategy != MergeLocal && tt.strategy != MergeRemote && !diff.Changes[0].Conflict {
+			t.Errorf("%s: change not marked as conflict", tt.strategy)
+		}
+		if !tagsEqual(diff.Changes[0].Result, tt.want) {
+			t.Errorf("%s: result = %v, want %v", tt.strategy, diff.Changes[0].Result, tt.want)
+		}
+		if tt.want == nil && len(diff.Result) != 0 {
+			t.Errorf("%s: dropped entry still in result %v", tt.strategy, diff.Result)
+		}
+	}
+}
+
+func TestMergeLists_NoBase(t *testing.T) {
+	local := nostr.Tags{{"t", "nostr"}}
+	remote := nostr.Tags{{"t", "golang"}}
+
+	diff := MergeLists(nostr.KindInterestList, nil, local, remote, MergeThreeWay, nil)
+	if len(diff.Result) != 2 {
+		t.Errorf("MergeLists() without base = %v, want union of both sides", diff.Result)
+	}
+}
+
+func TestMergeLists_InSync(t *testing.T) {
+	// trailing empty fields and URL normalization do not count as differences
+	local := nostr.Tags{{"p", strings.Repeat("a", 64), ""}, {"r", "wss://a.example/"}}
+	remote := nostr.Tags{{"p", strings.Repeat("a", 64)}, {"r", "wss://a.example"}}
+
+	diff := MergeLists(nostr.KindFollowList, nil, local, remote, MergeThreeWay, nil)
+	if len(diff.Changes) != 0 {
+		t.Errorf("MergeLists() changes = %v, want none", diff.Changes)
+	}
+	if !strings.Contains(FormatListDiff(diff), "in sync") {
+		t.Errorf("FormatListDiff() = %q, want in sync", FormatListDiff(diff))
+	}
+}
+
+func TestFormatListDiff(t *testing.T) {
+	base := nostr.Tags{{"t", "old"}}
+	local := nostr.Tags{{"t", "old"}}
+	remote := nostr.Tags{{"t", "new"}}
+
+	got := FormatListDiff(MergeLists(nostr.KindInterestList, base, local, remote, MergeThreeWay, nil))
+	for _, want := range []string{"2 differences", "+ t new", "- t old"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("FormatListDiff() missing %q: %q", want, got)
+		}
+	}
+
+	notFound := FormatListDiff(ListDiff{Kind: nostr.KindInterestList})
+	if !strings.Contains(notFound, "not found") {
+		t.Errorf("FormatListDiff() = %q, want not found", notFound)
+	}
+}
+
+func TestRelayListTagsRoundTrip(t *testing.T) {
+	tags := nostr.Tags{
+		{"r", "wss://both.example"},
+		{"r", "wss://read.example", "read"},
+		{"r", "wss://write.example", "write"},
+	}
+
+	got := relayListTags(relaysFromTags(tags))
+	if len(got) != len(tags) {
+		t.Fatalf("round trip = %v, want %v", got, tags)
+	}
+	for i := range tags {
+		if !tagsEqual(got[i], tags[i]) {
+			t.Errorf("round trip tag %d = %v, want %v", i, got[i], tags[i])
+		}
+	}
+}
+
+func TestParseMergeStrategy(t *testing.T) {
+	for _, strategy := range MergeStrategies {
+		got, err := ParseMergeStrategy(string(strategy))
+		if err != nil || got != strategy {
+			t.Errorf("ParseMergeStrategy(%q) = %q, %v", strategy, got, err)
+		}
+	}
+	if _, err := ParseMergeStrategy("theirs"); err == nil {
+		t.Error("ParseMergeStrategy(\"theirs\") succeeded, want error")
+	}
+}
+
+func relayURLs(tags nostr.Tags) []string {
+	urls := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		urls = append(urls, tag[1])
+	}
+	return urls
+}
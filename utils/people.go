@@ -0,0 +1,141 @@
+package utils
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"fiatjaf.com/nostr"
+	"github.com/jerry-harm/nosmec/config"
+	"github.com/jerry-harm/nosmec/nostr_sdk"
+)
+
+// Person is a profile found by SearchPeople.
+type Person struct {
+	Profile  nostr_sdk.ProfileMetadata
+	Alias    string // the alias pointing at this profile, if any
+	Followed bool   // in our follow list
+	InWoT    bool   // followed by us or by someone we follow
+	Local    bool   // found in the local store
+	Remote   bool   // returned by a user search relay
+}
+
+// PeopleSearchOptions tune SearchPeople.
+type PeopleSearchOptions struct {
+	Limit     int  // 20 if zero
+	LocalOnly bool // do not ask the user search relays
+	SkipWoT   bool // do not load the web of trust, which takes a while the first time
+}
+
+// SearchPeople finds profiles matching query in the aliases, the locally stored kind 0
+// events and, unless LocalOnly is set, on the NIP-50 user search relays. Profiles we
+// follow come first, then the ones in our web of trust, then name matches.
+func SearchPeople(ctx context.Context, app *config.AppContext, query string, opts PeopleSearchOptions) ([]Person, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, fmt.Errorf("empty query")
+	}
+	if opts.Limit <= 0 {
+		opts.Limit = 20
+	}
+
+	sys := app.System()
+	var remote []nostr_sdk.ProfileMetadata
+	wg := sync.WaitGroup{}
+	if !opts.LocalOnly {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			remote = sys.SearchUsers(ctx, query)
+		}()
+	}
+
+	people := make(map[nostr.PubKey]*Person)
+	add := func(pm nostr_sdk.ProfileMetadata) *Person {
+		p, ok := people[pm.PubKey]
+		if !ok {
+			p = &Person{Profile: pm}
+			people[pm.PubKey] = p
+		} else if pm.Event != nil && (p.Profile.Event == nil || pm.Event.CreatedAt > p.Profile.Event.CreatedAt) {
+			p.Profile = pm
+		}
+		return p
+	}
+
+	q := strings.ToLower(query)
+	for name := range ListAliases(app) {
+		if !strings.Contains(strings.ToLower(name), q) {
+			continue
+		}
+		pk, err := ResolveAliasToPubKey(app, name)
+		if err != nil {
+			continue
+		}
+		p := add(sys.FetchProfileMetadata(ctx, pk))
+		p.Alias = name
+	}
+	for _, pm := range sys.SearchLocalProfiles(ctx, query, opts.Limit*5) {
+		add(pm).Local = true
+	}
+	wg.Wait()
+	for _, pm := range remote {
+		add(pm).Remote = true
+	}
+
+	if me, err := app.GetMyPubKey(); err == nil {
+		for _, f := range sys.FetchFollowList(ctx, me).Items {
+			if p, ok := people[f.Pubkey]; ok {
+				p.Followed = true
+				p.InWoT = true
+			}
+		}
+		if !opts.SkipWoT {
+			if wot, err := sys.LoadWoTFilter(ctx, me); err == nil {
+				for pk, p := range people {
+					if wot.Contains(pk) {
+						p.InWoT = true
+					}
+				}
+			}
+		}
+	}
+
+	result := make([]Person, 0, len(people))
+	for _, p := range people {
+		result = append(result, *p)
+	}
+	SortPeople(result, query)
+	if len(result) > opts.Limit {
+		result = result[:opts.Limit]
+	}
+	return result, nil
+}
+
+// SortPeople ranks people: aliases and follows first, then the web of trust, then
+// profiles whose name starts with query, then by name.
+func SortPeople(people []Person, query string) {
+	q := strings.ToLower(query)
+	rank := func(p Person) int {
+		r := 0
+		if p.Alias != "" || p.Followed {
+			r += 4
+		}
+		if p.InWoT {
+			r += 2
+		}
+		if strings.HasPrefix(strings.ToLower(p.Profile.Name), q) ||
+			strings.HasPrefix(strings.ToLower(p.Profile.DisplayName), q) {
+			r++
+		}
+		return r
+	}
+	slices.SortStableFunc(people, func(a, b Person) int {
+		if c := cmp.Compare(rank(b), rank(a)); c != 0 {
+			return c
+		}
+		return cmp.Compare(strings.ToLower(a.Profile.ShortName()), strings.ToLower(b.Profile.ShortName()))
+	})
+}
@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"context"
+	"testing"
+
+	"fiatjaf.com/nostr"
+	"github.com/jerry-harm/nosmec/config"
+	"github.com/jerry-harm/nosmec/nostr_sdk"
+	"github.com/spf13/viper"
+)
+
+func TestSearchPeople_LocalProfiles(t *testing.T) {
+	app := config.NewAppContext(nil, config.Config{DataDir: t.TempDir()}, viper.New())
+	defer app.Close()
+
+	store := func(content string) nostr.PubKey {
+		sk := nostr.Generate()
+		evt := nostr.Event{Kind: nostr.KindProfileMetadata, CreatedAt: nostr.Now(), Content: content}
+		if err := evt.Sign(sk); err != nil {
+			t.Fatal(err)
+		}
+		if err := app.System().Store.SaveEvent(evt); err != nil {
+			t.Fatal(err)
+		}
+		return evt.PubKey
+	}
+	alice := store(`{"name":"alice","nip05":"alice@example.com"}`)
+	store(`{"name":"bob","display_name":"Bob Alison"}`)
+	store(`{"name":"carol"}`)
+	store(`not json`)
+
+	found, err := SearchPeople(context.Background(), app, "ALI", PeopleSearchOptions{LocalOnly: true, SkipWoT: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 2 {
+		t.Fatalf("expected 2 people, got %d", len(found))
+	}
+	if found[0].Profile.PubKey != alice || !found[0].Local {
+		t.Errorf("expected alice first and found locally, got %+v", found[0])
+	}
+
+	if _, err := SearchPeople(context.Background(), app, "  ", PeopleSearchOptions{LocalOnly: true}); err == nil {
+		t.Error("expected an error for an empty query")
+	}
+}
+
+func TestSortPeople(t *testing.T) {
+	person := func(name string) Person {
+		return Person{Profile: nostr_sdk.ProfileMetadata{Name: name}}
+	}
+	stranger := person("annie")
+	prefix := person("anna")
+	wot := person("joanna")
+	wot.InWoT = true
+	followed := person("zoe anne")
+	followed.Followed = true
+	followed.InWoT = true
+
+	people := []Person{stranger, wot, prefix, followed}
+	SortPeople(people, "ann")
+
+	want := []string{"zoe anne", "joanna", "anna", "annie"}
+	for i, name := range want {
+		if people[i].Profile.Name != name {
+			t.Errorf("position %d: got %s, want %s", i, people[i].Profile.Name, name)
+		}
+	}
+}
@@ -3,15 +3,15 @@ package utils
 import (
 	"context"
 	"fmt"
-	"strings"
 	"time"
 
 	"fiatjaf.com/nostr"
 	"fiatjaf.com/nostr/nip10"
 	"github.com/jerry-harm/nosmec/config"
+	"github.com/jerry-harm/nosmec/nostr_sdk"
 )
 
-func PostNote(ctx context.Context, app *config.AppContext, content string) (*nostr.Event, error) {
+func PostNote(ctx context.Context, app *config.AppContext, content string) (*nostr_sdk.PublishReport, error) {
 	secretKey, err := app.GetMySecretKey()
 	if err != nil {
 		return nil, err
@@ -29,20 +29,10 @@ func PostNote(ctx context.Context, app *config.AppContext, content string) (*nos
 		return nil, err
 	}
 
-	writableRelays := app.AllWritableRelays()
-	if len(writableRelays) > 0 {
-		resultChan := app.Pool().PublishMany(ctx, writableRelays, *event)
-		for result := range resultChan {
-			if result.Error != nil {
-				return nil, fmt.Errorf("failed to publish to %s: %w", result.RelayURL, result.Error)
-			}
-		}
-	}
-
-	return event, nil
+	return PublishEvent(ctx, app, app.AllWritableRelays(), event)
 }
 
-func ReplyToNote(ctx context.Context, app *config.AppContext, parentID, content string) (*nostr.Event, error) {
+func ReplyToNote(ctx context.Context, app *config.AppContext, parentID, content string) (*nostr_sdk.PublishReport, error) {
 	secretKey, err := app.GetMySecretKey()
 	if err != nil {
 		return nil, err
@@ -82,17 +72,7 @@ func ReplyToNote(ctx context.Context, app *config.AppContext, parentID, content
 		return nil, err
 	}
 
-	writableRelays := app.AllWritableRelays()
-	if len(writableRelays) > 0 {
-		resultChan := app.Pool().PublishMany(ctx, writableRelays, *event)
-		for result := range resultChan {
-			if result.Error != nil {
-				return nil, fmt.Errorf("failed to publish to %s: %w", result.RelayURL, result.Error)
-			}
-		}
-	}
-
-	return event, nil
+	return PublishEvent(ctx, app, app.AllWritableRelays(), event)
 }
 
 func BuildReplyTags(app *config.AppContext, parentEvent *nostr.Event) nostr.Tags {
@@ -113,7 +93,7 @@ func BuildReplyTagsWithRoot(app *config.AppContext, parentEvent *nostr.Event, ro
 	}
 }
 
-func QuoteNote(ctx context.Context, app *config.AppContext, quotedID, quotedAuthorPubkey, content string) (*nostr.Event, error) {
+func QuoteNote(ctx context.Context, app *config.AppContext, quotedID, quotedAuthorPubkey, content string) (*nostr_sdk.PublishReport, error) {
 	secretKey, err := app.GetMySecretKey()
 	if err != nil {
 		return nil, err
@@ -136,20 +116,10 @@ func QuoteNote(ctx context.Context, app *config.AppContext, quotedID, quotedAuth
 		return nil, err
 	}
 
-	writableRelays := app.AllWritableRelays()
-	if len(writableRelays) > 0 {
-		resultChan := app.Pool().PublishMany(ctx, writableRelays, *event)
-		for result := range resultChan {
-			if result.Error != nil {
-				return nil, fmt.Errorf("failed to publish to %s: %w", result.RelayURL, result.Error)
-			}
-		}
-	}
-
-	return event, nil
+	return PublishEvent(ctx, app, app.AllWritableRelays(), event)
 }
 
-func DeleteNote(ctx context.Context, app *config.AppContext, eventID, authorPubkey string) (*nostr.Event, error) {
+func DeleteNote(ctx context.Context, app *config.AppContext, eventID, authorPubkey string) (*nostr_sdk.PublishReport, error) {
 	secretKey, err := app.GetMySecretKey()
 	if err != nil {
 		return nil, err
@@ -167,23 +137,7 @@ func DeleteNote(ctx context.Context, app *config.AppContext, eventID, authorPubk
 		return nil, err
 	}
 
-	writableRelays := app.AllWritableRelays()
-	if len(writableRelays) == 0 {
-		return event, nil
-	}
-
-	var failed []string
-	resultChan := app.Pool().PublishMany(ctx, writableRelays, *event)
-	for result := range resultChan {
-		if result.Error != nil {
-			failed = append(failed, result.RelayURL)
-		}
-	}
-	if len(failed) > 0 {
-		return event, fmt.Errorf("partial failure on %d/%d relays: %s", len(failed), len(writableRelays), strings.Join(failed, ", "))
-	}
-
-	return event, nil
+	return PublishEvent(ctx, app, app.AllWritableRelays(), event)
 }
 
 func findRootEvent(event *nostr.Event) (rootID nostr.ID, isRoot bool) {
@@ -42,6 +42,7 @@ type FullProfile struct {
 	Follows     []FollowInfo         `json:"follows,omitempty"`
 	Communities []CommunityInfo      `json:"communities,omitempty"`
 	Hashtags    []HashtagInfo        `json:"hashtags,omitempty"`
+	Followers   *sdk.CountResult     `json:"followers,omitempty"`
 }
 
 func profileConfigToMetadata(pc config.ProfileConfig) sdk.ProfileMetadata {
@@ -72,7 +73,7 @@ func metadataToProfileConfig(pm sdk.ProfileMetadata, bot *bool, birthday string)
 	}
 }
 
-func SetProfile(ctx context.Context, app *config.AppContext, publishOnly bool, name, about, picture, displayName, website, banner, bot, birthday, nip05, lud06, lud16 string) (*nostr.Event, error) {
+func SetProfile(ctx context.Context, app *config.AppContext, publishOnly bool, name, about, picture, displayName, website, banner, bot, birthday, nip05, lud06, lud16 string) (*sdk.PublishReport, error) {
 	secretKey, err := app.GetMySecretKey()
 	if err != nil {
 		return nil, err
@@ -164,17 +165,7 @@ func SetProfile(ctx context.Context, app *config.AppContext, publishOnly bool, n
 		return nil, err
 	}
 
-	writableRelays := app.WritableRelays()
-	if len(writableRelays) > 0 {
-		resultChan := app.Pool().PublishMany(ctx, writableRelays, *event)
-		for result := range resultChan {
-			if result.Error != nil {
-				return nil, fmt.Errorf("failed to publish profile: %w", result.Error)
-			}
-		}
-	}
-
-	return event, nil
+	return PublishEvent(ctx, app, app.WritableRelays(), event)
 }
 
 func SyncProfile(ctx context.Context, app *config.AppContext) error {
@@ -404,9 +395,24 @@ func GetFullProfile(ctx context.Context, pubKey nostr.PubKey, app *config.AppCon
 		}
 	}
 
+	fp.Followers = CountFollowers(ctx, app, pubKey)
+
 	return fp, nil
 }
 
+// CountFollowers asks the relays supporting NIP-45 how many people follow pubKey.
+// It returns nil when none of them answered in time.
+func CountFollowers(ctx context.Context, app *config.AppContext, pubKey nostr.PubKey) *sdk.CountResult {
+	ctx, cancel := context.WithTimeout(ctx, app.QueryTimeout())
+	defer cancel()
+
+	result := app.System().CountFollowers(ctx, pubKey)
+	if result.Answered() == 0 {
+		return nil
+	}
+	return &result
+}
+
 func SerializeProfile(fp *FullProfile) ([]byte, error) {
 	return json.MarshalIndent(fp, "", "  ")
 }
@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"fiatjaf.com/nostr"
+	"github.com/jerry-harm/nosmec/config"
+	"github.com/jerry-harm/nosmec/logger"
+	"github.com/jerry-harm/nosmec/nostr_sdk"
+)
+
+// PublishEvent writes an already signed event to the outgoing queue, publishes it and
+// returns the per-relay report. Relays that failed for a transient reason stay queued
+// so the event goes out once they are reachable again.
+// The returned error is non-nil only when the event was neither accepted nor queued.
+func PublishEvent(ctx context.Context, app *config.AppContext, relays []string, event *nostr.Event) (*nostr_sdk.PublishReport, error) {
+	report, err := app.System().PublishQueued(ctx, relays, *event)
+	if err != nil {
+		logger.Warn("failed to update outgoing queue", "id", event.ID.Hex(), "error", err.Error())
+	}
+	return report, report.Err()
+}
+
+// PendingPublishCount returns how many events are waiting in the outgoing queue.
+func PendingPublishCount(app *config.AppContext) int {
+	pending, err := app.System().ListPendingPublishes()
+	if err != nil {
+		return 0
+	}
+	return len(pending)
+}
+
+// FormatPublishReport renders a report as one line per relay.
+func FormatPublishReport(report *nostr_sdk.PublishReport) string {
+	if report == nil || len(report.Results) == 0 {
+		return "No relays to publish to.\n"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Accepted by %d/%d relays\n", len(report.Accepted()), len(report.Results))
+	for _, res := range report.Results {
+		switch {
+		case res.OK:
+			fmt.Fprintf(&b, "  ok       %s\n", res.Relay)
+		case res.TimedOut:
+			fmt.Fprintf(&b, "  timeout  %s\n", res.Relay)
+		default:
+			fmt.Fprintf(&b, "  failed   %s: %s\n", res.Relay, res.Reason)
+		}
+	}
+	if len(report.Queued) > 0 {
+		fmt.Fprintf(&b, "Queued for %d relays; it will be sent when they are reachable\n", len(report.Queued))
+	}
+	return b.String()
+}
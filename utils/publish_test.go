@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jerry-harm/nosmec/nostr_sdk"
+)
+
+func TestFormatPublishReport(t *testing.T) {
+	report := &nostr_sdk.PublishReport{Results: []nostr_sdk.RelayPublishResult{
+		{Relay: "wss://ok.example", OK: true},
+		{Relay: "wss://slow.example", TimedOut: true, Reason: "no response before timeout"},
+		{Relay: "wss://strict.example", Reason: "msg: blocked: not allowed"},
+	}}
+
+	got := FormatPublishReport(report)
+	if !strings.Contains(got, "Accepted by 1/3 relays") {
+		t.Errorf("FormatPublishReport() missing summary: %q", got)
+	}
+	for _, want := range []string{"ok       wss://ok.example", "timeout  wss://slow.example", "failed   wss://strict.example: msg: blocked: not allowed"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("FormatPublishReport() missing %q in %q", want, got)
+		}
+	}
+}
+
+func TestFormatPublishReport_NoRelays(t *testing.T) {
+	if got := FormatPublishReport(&nostr_sdk.PublishReport{}); got != "No relays to publish to.\n" {
+		t.Errorf("FormatPublishReport() = %q", got)
+	}
+}
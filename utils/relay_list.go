@@ -7,83 +7,71 @@ import (
 
 	"fiatjaf.com/nostr"
 	"github.com/jerry-harm/nosmec/config"
+	"github.com/jerry-harm/nosmec/logger"
+	"github.com/jerry-harm/nosmec/nostr_sdk"
 )
 
-func SyncRelaysFromNetwork(ctx context.Context, app *config.AppContext) error {
-	pubKey, err := app.GetMyPubKey()
+func SyncRelaysFromNetwork(ctx context.Context, app *config.AppContext, opts SyncOptions) ([]ListDiff, error) {
+	relayDiff, err := SyncRelayListFromNetwork(ctx, app, opts)
 	if err != nil {
-		return fmt.Errorf("failed to get public key: %w", err)
+		return nil, fmt.Errorf("failed to sync relay list: %w", err)
 	}
 
-	relays := app.WritableRelays()
-	if len(relays) == 0 {
-		relays = app.ReadableRelays()
-	}
-	if len(relays) == 0 {
-		return fmt.Errorf("no relays available to query")
-	}
-
-	if err := syncRelayListFromNetwork(ctx, app, pubKey, relays); err != nil {
-		return fmt.Errorf("failed to sync relay list: %w", err)
-	}
-
-	if err := syncDMRelaysFromNetworkImpl(ctx, app, pubKey, relays); err != nil {
-		return fmt.Errorf("failed to sync DM relays: %w", err)
+	dmDiff, err := SyncDMRelaysFromNetwork(ctx, app, opts)
+	if err != nil {
+		return []ListDiff{relayDiff}, fmt.Errorf("failed to sync DM relays: %w", err)
 	}
 
-	return nil
+	return []ListDiff{relayDiff, dmDiff}, nil
 }
 
-func syncRelayListFromNetwork(ctx context.Context, app *config.AppContext, pubKey nostr.PubKey, relays []string) error {
-	filter := nostr.Filter{
-		Kinds:   []nostr.Kind{nostr.KindRelayListMetadata},
-		Authors: []nostr.PubKey{pubKey},
-		Limit:   1,
+// SyncRelayListFromNetwork merges our kind 10002 from the network into the configured relays.
+func SyncRelayListFromNetwork(ctx context.Context, app *config.AppContext, opts SyncOptions) (ListDiff, error) {
+	pubKey, relays, err := ownListQueryRelays(app)
+	if err != nil {
+		return ListDiff{}, err
 	}
 
-	result := app.Pool().QuerySingle(ctx, relays, filter, nostr.SubscriptionOptions{})
-	if result == nil {
-		return nil
+	local := app.ListRelays()
+	remote := fetchOwnRelayList(ctx, app, pubKey, relays, nostr.KindRelayListMetadata)
+	diff, err := mergeOwnList(app, nostr.KindRelayListMetadata, relayListTags(local), remote, opts)
+	if err != nil || !diff.Found || opts.DryRun {
+		return diff, err
 	}
 
-	relayMap := make(map[string]struct{ read, write bool })
-	for _, tag := range result.Event.Tags {
-		if len(tag) >= 2 && tag[0] == "r" {
-			url := tag[1]
-			r := relayMap[url]
-			if len(tag) == 2 {
-				r.read = true
-				r.write = true
-			} else {
-				for _, p := range tag[2:] {
-					if p == "read" {
-						r.read = true
-					} else if p == "write" {
-						r.write = true
-					}
-				}
-			}
-			relayMap[url] = r
+	merged := relaysFromTags(diff.Result)
+	// relays with neither flag never make it into kind 10002, keep them
+	for _, relay := range local {
+		if !relayReads(relay) && !relayWrites(relay) {
+			merged = append(merged, relay)
 		}
 	}
 
-	relayList := make([]config.Relay, 0, len(relayMap))
-	for url, r := range relayMap {
-		relayList = append(relayList, config.Relay{
-			URL:   url,
-			Read:  config.BoolPtr(r.read),
-			Write: config.BoolPtr(r.write),
-		})
+	app.SyncRelayList(merged)
+	return diff, saveListSnapshot(app, nostr.KindRelayListMetadata, remote.Tags)
+}
+
+// SyncDMRelaysFromNetwork merges our kind 10050 from the network into the configured DM relays.
+func SyncDMRelaysFromNetwork(ctx context.Context, app *config.AppContext, opts SyncOptions) (ListDiff, error) {
+	pubKey, relays, err := ownListQueryRelays(app)
+	if err != nil {
+		return ListDiff{}, err
 	}
 
-	app.SyncRelayList(relayList)
-	return nil
+	remote := fetchOwnRelayList(ctx, app, pubKey, relays, nostr.KindDMRelayList)
+	diff, err := mergeOwnList(app, nostr.KindDMRelayList, dmRelayTags(app.ListDMRelays()), remote, opts)
+	if err != nil || !diff.Found || opts.DryRun {
+		return diff, err
+	}
+
+	app.SyncDMRelays(dmRelaysFromTags(diff.Result))
+	return diff, saveListSnapshot(app, nostr.KindDMRelayList, remote.Tags)
 }
 
-func SyncDMRelaysFromNetwork(ctx context.Context, app *config.AppContext) error {
+func ownListQueryRelays(app *config.AppContext) (nostr.PubKey, []string, error) {
 	pubKey, err := app.GetMyPubKey()
 	if err != nil {
-		return fmt.Errorf("failed to get public key: %w", err)
+		return nostr.PubKey{}, nil, fmt.Errorf("failed to get public key: %w", err)
 	}
 
 	relays := app.WritableRelays()
@@ -91,15 +79,14 @@ func SyncDMRelaysFromNetwork(ctx context.Context, app *config.AppContext) error
 		relays = app.ReadableRelays()
 	}
 	if len(relays) == 0 {
-		return fmt.Errorf("no relays available to query")
+		return pubKey, nil, fmt.Errorf("no relays available to query")
 	}
-
-	return syncDMRelaysFromNetworkImpl(ctx, app, pubKey, relays)
+	return pubKey, relays, nil
 }
 
-func syncDMRelaysFromNetworkImpl(ctx context.Context, app *config.AppContext, pubKey nostr.PubKey, relays []string) error {
+func fetchOwnRelayList(ctx context.Context, app *config.AppContext, pubKey nostr.PubKey, relays []string, kind nostr.Kind) *nostr.Event {
 	filter := nostr.Filter{
-		Kinds:   []nostr.Kind{nostr.KindDMRelayList},
+		Kinds:   []nostr.Kind{kind},
 		Authors: []nostr.PubKey{pubKey},
 		Limit:   1,
 	}
@@ -108,43 +95,61 @@ func syncDMRelaysFromNetworkImpl(ctx context.Context, app *config.AppContext, pu
 	if result == nil {
 		return nil
 	}
+	return &result.Event
+}
 
-	event := result.Event
-	var dmRelays []string
-	for _, tag := range event.Tags {
-		if len(tag) >= 2 && tag[0] == "relay" {
-			dmRelays = append(dmRelays, tag[1])
-		}
+// mergeOwnList merges the local tags of one of our lists with the network event,
+// using the snapshot from the last sync as the common base.
+func mergeOwnList(app *config.AppContext, kind nostr.Kind, local nostr.Tags, remote *nostr.Event, opts SyncOptions) (ListDiff, error) {
+	if remote == nil {
+		return ListDiff{Kind: kind}, nil
 	}
 
-	app.SyncDMRelays(dmRelays)
-	return nil
-}
-
-func PublishRelayList(ctx context.Context, app *config.AppContext) error {
-	secretKey, err := app.GetMySecretKey()
+	base, err := app.System().LoadListSnapshot(kind)
 	if err != nil {
-		return fmt.Errorf("failed to get secret key: %w", err)
+		return ListDiff{}, fmt.Errorf("failed to load last synced list: %w", err)
 	}
 
-	if err := publishRelayListMetadata(ctx, app, secretKey); err != nil {
-		return fmt.Errorf("failed to publish relay list metadata: %w", err)
+	strategy := opts.Strategy
+	if strategy == "" {
+		strategy = MergeThreeWay
 	}
+	return MergeLists(kind, base, local, remote.Tags, strategy, opts.Resolve), nil
+}
 
-	if err := publishDMRelayList(ctx, app, secretKey); err != nil {
-		return fmt.Errorf("failed to publish DM relay list: %w", err)
+func saveListSnapshot(app *config.AppContext, kind nostr.Kind, tags nostr.Tags) error {
+	if err := app.System().SaveListSnapshot(kind, tags); err != nil {
+		return fmt.Errorf("failed to record synced list: %w", err)
 	}
-
 	return nil
 }
 
-func publishRelayListMetadata(ctx context.Context, app *config.AppContext, secretKey nostr.SecretKey) error {
-	relayList := app.ListRelays()
+// publishOwnList publishes one of our lists and records its tags as the new sync base.
+func publishOwnList(ctx context.Context, app *config.AppContext, event *nostr.Event) (*nostr_sdk.PublishReport, error) {
+	report, err := PublishEvent(ctx, app, app.WritableRelays(), event)
+	if err != nil {
+		return report, err
+	}
+	if err := app.System().SaveListSnapshot(event.Kind, event.Tags); err != nil {
+		logger.Warn("failed to record published list", "kind", event.Kind, "error", err.Error())
+	}
+	return report, nil
+}
+
+func relayReads(relay config.Relay) bool {
+	return relay.Read != nil && *relay.Read
+}
+
+func relayWrites(relay config.Relay) bool {
+	return relay.Write != nil && *relay.Write
+}
 
+// relayListTags builds the kind 10002 tags for the configured relays.
+func relayListTags(relays []config.Relay) nostr.Tags {
 	tags := nostr.Tags{}
-	for _, relay := range relayList {
-		read := relay.Read != nil && *relay.Read
-		write := relay.Write != nil && *relay.Write
+	for _, relay := range relays {
+		read := relayReads(relay)
+		write := relayWrites(relay)
 		if read && write {
 			tags = append(tags, nostr.Tag{"r", relay.URL})
 		} else if read {
@@ -153,61 +158,106 @@ func publishRelayListMetadata(ctx context.Context, app *config.AppContext, secre
 			tags = append(tags, nostr.Tag{"r", relay.URL, "write"})
 		}
 	}
+	return tags
+}
 
-	event := &nostr.Event{
-		Kind:      nostr.KindRelayListMetadata,
-		CreatedAt: nostr.Timestamp(time.Now().Unix()),
-		Tags:      tags,
-		Content:   "",
-		PubKey:    secretKey.Public(),
+// relaysFromTags parses kind 10002 tags; an "r" tag without a marker is both read and write.
+func relaysFromTags(tags nostr.Tags) []config.Relay {
+	relayList := make([]config.Relay, 0, len(tags))
+	for _, tag := range tags {
+		if len(tag) < 2 || tag[0] != "r" {
+			continue
+		}
+		read, write := len(tag) == 2, len(tag) == 2
+		for _, p := range tag[2:] {
+			if p == "read" {
+				read = true
+			} else if p == "write" {
+				write = true
+			}
+		}
+		relayList = append(relayList, config.Relay{
+			URL:   tag[1],
+			Read:  config.BoolPtr(read),
+			Write: config.BoolPtr(write),
+		})
 	}
+	return relayList
+}
 
-	if err := event.Sign(secretKey); err != nil {
-		return fmt.Errorf("failed to sign event: %w", err)
+func dmRelayTags(relays []string) nostr.Tags {
+	tags := nostr.Tags{}
+	for _, relay := range relays {
+		tags = append(tags, nostr.Tag{"relay", relay})
 	}
+	return tags
+}
 
-	writableRelays := app.WritableRelays()
-	if len(writableRelays) > 0 {
-		resultChan := app.Pool().PublishMany(ctx, writableRelays, *event)
-		for result := range resultChan {
-			if result.Error != nil {
-				return fmt.Errorf("failed to publish to %s: %w", result.RelayURL, result.Error)
-			}
+func dmRelaysFromTags(tags nostr.Tags) []string {
+	var dmRelays []string
+	for _, tag := range tags {
+		if len(tag) >= 2 && tag[0] == "relay" {
+			dmRelays = append(dmRelays, tag[1])
 		}
 	}
-
-	return nil
+	return dmRelays
 }
 
-func publishDMRelayList(ctx context.Context, app *config.AppContext, secretKey nostr.SecretKey) error {
-	dmRelays := app.ListDMRelays()
+func PublishRelayList(ctx context.Context, app *config.AppContext) ([]*nostr_sdk.PublishReport, error) {
+	secretKey, err := app.GetMySecretKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret key: %w", err)
+	}
 
-	tags := nostr.Tags{}
-	for _, relay := range dmRelays {
-		tags = append(tags, nostr.Tag{"relay", relay})
+	var reports []*nostr_sdk.PublishReport
+
+	report, err := publishRelayListMetadata(ctx, app, secretKey)
+	if report != nil {
+		reports = append(reports, report)
+	}
+	if err != nil {
+		return reports, fmt.Errorf("failed to publish relay list metadata: %w", err)
+	}
+
+	report, err = publishDMRelayList(ctx, app, secretKey)
+	if report != nil {
+		reports = append(reports, report)
+	}
+	if err != nil {
+		return reports, fmt.Errorf("failed to publish DM relay list: %w", err)
 	}
 
+	return reports, nil
+}
+
+func publishRelayListMetadata(ctx context.Context, app *config.AppContext, secretKey nostr.SecretKey) (*nostr_sdk.PublishReport, error) {
 	event := &nostr.Event{
-		Kind:      nostr.KindDMRelayList,
+		Kind:      nostr.KindRelayListMetadata,
 		CreatedAt: nostr.Timestamp(time.Now().Unix()),
-		Tags:      tags,
+		Tags:      relayListTags(app.ListRelays()),
 		Content:   "",
 		PubKey:    secretKey.Public(),
 	}
 
 	if err := event.Sign(secretKey); err != nil {
-		return fmt.Errorf("failed to sign event: %w", err)
+		return nil, fmt.Errorf("failed to sign event: %w", err)
 	}
 
-	writableRelays := app.WritableRelays()
-	if len(writableRelays) > 0 {
-		resultChan := app.Pool().PublishMany(ctx, writableRelays, *event)
-		for result := range resultChan {
-			if result.Error != nil {
-				return fmt.Errorf("failed to publish to %s: %w", result.RelayURL, result.Error)
-			}
-		}
+	return publishOwnList(ctx, app, event)
+}
+
+func publishDMRelayList(ctx context.Context, app *config.AppContext, secretKey nostr.SecretKey) (*nostr_sdk.PublishReport, error) {
+	event := &nostr.Event{
+		Kind:      nostr.KindDMRelayList,
+		CreatedAt: nostr.Timestamp(time.Now().Unix()),
+		Tags:      dmRelayTags(app.ListDMRelays()),
+		Content:   "",
+		PubKey:    secretKey.Public(),
 	}
 
-	return nil
+	if err := event.Sign(secretKey); err != nil {
+		return nil, fmt.Errorf("failed to sign event: %w", err)
+	}
+
+	return publishOwnList(ctx, app, event)
 }
@@ -3,118 +3,210 @@ package utils
 import (
 	"context"
 	"fmt"
-	"regexp"
-	"strconv"
-	"strings"
+	"slices"
+	"sync"
 
 	"fiatjaf.com/nostr"
-	"fiatjaf.com/nostr/nip19"
 	"github.com/jerry-harm/nosmec/config"
 )
 
+// LocalSearchSource labels results that came from the local search index.
+const LocalSearchSource = "local"
+
 // SearchResult holds a search result with its source relay
 type SearchResult struct {
+	Event   nostr.Event `json:"event"`
+	Relay   string      `json:"relay,omitempty"` // first relay that returned the event, empty if it was only found locally
+	Sources []string    `json:"sources"`         // LocalSearchSource and/or relay URLs, in the order they answered
+}
+
+// SearchOptions tune a search.
+type SearchOptions struct {
+	Limit     int             // per alternative and source, 50 if zero
+	Until     nostr.Timestamp // only older events, to page through results
+	LocalOnly bool            // do not contact relays
+}
+
+// SearchHit is an event as returned by one source.
+type SearchHit struct {
 	Event  nostr.Event
-	Relay  string
+	Source string // LocalSearchSource or a relay URL
 }
 
-// ParseSearchFilter parses a NIP-50 search string and extracts filter parameters.
-// Supports: kinds:1,3 authors:npub1... #t:hashtag
-// Remaining text becomes the search query.
-func ParseSearchFilter(input string) (nostr.Filter, string) {
-	filter := nostr.Filter{}
-
-	// Match kinds:1,3 or kinds: 1,3
-	kindsRegex := regexp.MustCompile(`(?i)\bkinds:([\d, ]+)\b`)
-	if match := kindsRegex.FindStringSubmatch(input); len(match) > 1 {
-		kindsStr := strings.ReplaceAll(match[1], " ", "")
-		parts := strings.Split(kindsStr, ",")
-		for _, p := range parts {
-			if k, err := strconv.Atoi(p); err == nil {
-				filter.Kinds = append(filter.Kinds, nostr.Kind(k))
-			}
-		}
-		// Remove matched portion from input
-		input = kindsRegex.ReplaceAllString(input, "")
-	}
-
-	// Match authors:npub1... or authors: hexpubkey
-	authorsRegex := regexp.MustCompile(`(?i)\bauthors:([^\s#]+)\b`)
-	if match := authorsRegex.FindStringSubmatch(input); len(match) > 1 {
-		authorStr := match[1]
-		_, decoded, err := nip19.Decode(authorStr)
-		if err == nil {
-			if pk, ok := decoded.(nostr.PubKey); ok {
-				filter.Authors = append(filter.Authors, pk)
-			}
-		}
-		input = authorsRegex.ReplaceAllString(input, "")
+// StreamSearch searches for events using NIP-50, querying the local search index in
+// parallel with the search relays. The query uses the syntax of ParseSearchQuery; each
+// of its alternatives is a separate request. Hits are sent as sources answer, already
+// checked with CompiledSearch.Match but not deduplicated, and the channel is closed
+// once every source is done or ctx is cancelled.
+func StreamSearch(ctx context.Context, app *config.AppContext, query string, opts SearchOptions) (<-chan SearchHit, error) {
+	if opts.Limit <= 0 {
+		opts.Limit = 50
+	}
+
+	parsed, err := ParseSearchQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	sys := app.System()
+	local := sys.HasSearchIndex()
+	var relays []string
+	if !opts.LocalOnly {
+		relays = buildSearchRelayList(app)
+	}
+
+	if opts.LocalOnly && !local {
+		return nil, fmt.Errorf("local search index is not enabled")
+	}
+	if len(relays) == 0 && !local {
+		return nil, fmt.Errorf("no search relays configured")
 	}
 
-	// Match #t:hashtag or #t hashtag
-	tagRegex := regexp.MustCompile(`(?i)#t:(\S+)`)
-	if match := tagRegex.FindStringSubmatch(input); len(match) > 1 {
-		tag := strings.ToLower(match[1])
-		if filter.Tags == nil {
-			filter.Tags = make(nostr.TagMap)
+	search, err := parsed.Compile(ctx, app, opts.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	hits := make(chan SearchHit)
+	send := func(evt nostr.Event, source string) bool {
+		// relays cannot exclude terms and some ignore parts of the filter
+		if !search.Match(evt) {
+			return true
+		}
+		select {
+		case hits <- SearchHit{Event: evt, Source: source}:
+			return true
+		case <-ctx.Done():
+			return false
 		}
-		filter.Tags["t"] = append(filter.Tags["t"], tag)
-		input = tagRegex.ReplaceAllString(input, "")
 	}
+	wg := sync.WaitGroup{}
 
-	// Remaining text is the search query
-	searchText := strings.TrimSpace(input)
-	if searchText != "" {
-		filter.Search = searchText
+	for _, filter := range search.Filters {
+		if opts.Until != 0 && (filter.Until == 0 || opts.Until < filter.Until) {
+			filter.Until = opts.Until
+		}
+		if local {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for _, evt := range sys.SearchLocal(filter) {
+					if !send(evt, LocalSearchSource) {
+						return
+					}
+				}
+			}()
+		}
+		if len(relays) > 0 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for re := range app.Pool().FetchMany(ctx, relays, filter, nostr.SubscriptionOptions{
+					Label: "search",
+				}) {
+					if !send(re.Event, re.Relay.URL) {
+						return
+					}
+				}
+			}()
+		}
 	}
+	go func() {
+		wg.Wait()
+		close(hits)
+	}()
 
-	return filter, filter.Search
+	return hits, nil
 }
 
-// SearchEvents searches for events across multiple relays using NIP-50
-func SearchEvents(ctx context.Context, app *config.AppContext, query string, limit int) ([]SearchResult, error) {
-	// Parse the search string
-	filter, searchText := ParseSearchFilter(query)
+// SearchEvents runs StreamSearch to completion. Results are deduplicated, keeping
+// every source they were found in, in the order they first arrived.
+func SearchEvents(ctx context.Context, app *config.AppContext, query string, opts SearchOptions) ([]SearchResult, error) {
+	hits, err := StreamSearch(ctx, app, query, opts)
+	if err != nil {
+		return nil, err
+	}
 
-	// Set limit
-	if limit > 0 {
-		filter.Limit = limit
-	} else {
-		filter.Limit = 50
+	var results []SearchResult
+	index := make(map[nostr.ID]int)
+	for h := range hits {
+		i, seen := index[h.Event.ID]
+		if !seen {
+			i = len(results)
+			index[h.Event.ID] = i
+			results = append(results, SearchResult{Event: h.Event})
+		}
+		results[i].AddSource(h.Source)
 	}
 
-	// Build relay list: search_relays + local relay
-	relays := buildSearchRelayList(app)
+	return results, nil
+}
 
+// SubscribeSearch keeps a live NIP-50 subscription on the search relays for events
+// matching query that are created from since on. Events are deduplicated and checked
+// with CompiledSearch.Match; the channel is closed once ctx is cancelled.
+func SubscribeSearch(ctx context.Context, app *config.AppContext, query string, since nostr.Timestamp) (chan nostr.RelayEvent, error) {
+	parsed, err := ParseSearchQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	relays := buildSearchRelayList(app)
 	if len(relays) == 0 {
 		return nil, fmt.Errorf("no search relays configured")
 	}
-
-	if searchText == "" && filter.Search == "" && len(filter.Kinds) == 0 && len(filter.Authors) == 0 {
-		return nil, fmt.Errorf("no search terms provided")
+	search, err := parsed.Compile(ctx, app, 0)
+	if err != nil {
+		return nil, err
 	}
 
-	var results []SearchResult
+	events := make(chan nostr.RelayEvent)
+	var mu sync.Mutex
 	seen := make(map[nostr.ID]bool)
+	wg := sync.WaitGroup{}
+	for _, filter := range search.Filters {
+		filter.Since = max(filter.Since, since)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for re := range app.Pool().SubscribeMany(ctx, relays, filter, nostr.SubscriptionOptions{
+				Label: "search",
+			}) {
+				mu.Lock()
+				dup := seen[re.Event.ID]
+				seen[re.Event.ID] = true
+				mu.Unlock()
+				if dup || !search.Match(re.Event) {
+					continue
+				}
+				select {
+				case events <- re:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(events)
+	}()
 
-	// Query all relays and collect results
-	ch := app.Pool().FetchMany(ctx, relays, filter, nostr.SubscriptionOptions{})
+	return events, nil
+}
 
-	for re := range ch {
-		if seen[re.Event.ID] {
-			continue
-		}
-		seen[re.Event.ID] = true
-		results = append(results, SearchResult{
-			Event: re.Event,
-			Relay: re.Relay.URL,
-		})
+// AddSource records that the event was also found in source.
+func (r *SearchResult) AddSource(source string) {
+	if slices.Contains(r.Sources, source) {
+		return
+	}
+	r.Sources = append(r.Sources, source)
+	if r.Relay == "" && source != LocalSearchSource {
+		r.Relay = source
 	}
-
-	return results, nil
 }
 
-// buildSearchRelayList builds the list of relays to search: search_relays + local relay
+// buildSearchRelayList builds the list of remote relays to search; the local index
+// is queried separately by StreamSearch
 func buildSearchRelayList(app *config.AppContext) []string {
 	relaySet := make(map[string]struct{})
 
@@ -0,0 +1,308 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"fiatjaf.com/nostr"
+	"github.com/jerry-harm/nosmec/config"
+	"github.com/jerry-harm/nosmec/nostr_sdk"
+)
+
+// SearchQueryError reports the part of a search query that could not be parsed.
+type SearchQueryError struct {
+	Pos    int // byte offset of Token in the query
+	Token  string
+	Reason string
+}
+
+func (e *SearchQueryError) Error() string {
+	if e.Token == "" {
+		return "invalid search: " + e.Reason
+	}
+	return fmt.Sprintf("invalid search: %s at position %d (%s)", e.Reason, e.Pos+1, e.Token)
+}
+
+// SearchQuery is a parsed search. The full-text alternatives are searched separately,
+// while kinds, authors, time bounds, tags and exclusions apply to all of them.
+//
+// The syntax is:
+//
+//	kinds:1,30023            event kinds
+//	authors:alice,npub1...   npub, nprofile, hex, alias or NIP-05 address
+//	since:7d until:2024-05-01  unix time, date, RFC 3339 or a duration ago
+//	#t:nostr,bitcoin         any single-letter tag
+//	"exact phrase" -term     phrases, and terms the content must not contain
+//	bitcoin OR lightning     alternatives
+type SearchQuery struct {
+	Alternatives []string // full-text search of each alternative, a single "" when there is none
+	Kinds        []nostr.Kind
+	Authors      []string // as written, resolved by Compile
+	Since        nostr.Timestamp
+	Until        nostr.Timestamp
+	Tags         nostr.TagMap
+	Exclude      []string // lower-cased
+}
+
+type searchToken struct {
+	text    string
+	raw     string
+	pos     int
+	quoted  bool // a phrase is never a keyword or a field
+	negated bool
+}
+
+// ParseSearchQuery parses a search query, returning a *SearchQueryError when it is malformed.
+func ParseSearchQuery(input string) (SearchQuery, error) {
+	var q SearchQuery
+
+	tokens, err := tokenizeSearch(input)
+	if err != nil {
+		return q, err
+	}
+
+	var groups [][]string
+	var group []string
+	var lastOr *searchToken
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+
+		if tok.text == "OR" && !tok.quoted && !tok.negated {
+			if len(group) == 0 {
+				return q, tok.errorf("OR needs a search term on both sides")
+			}
+			groups = append(groups, group)
+			group = nil
+			lastOr = &tokens[i]
+			continue
+		}
+
+		if !tok.quoted {
+			if key, value, ok := cutSearchField(tok.text); ok {
+				if tok.negated {
+					return q, tok.errorf("only search terms can be negated")
+				}
+				// allow "kinds: 1, 3"
+				for (value == "" || strings.HasSuffix(value, ",")) && i+1 < len(tokens) && !tokens[i+1].quoted && !tokens[i+1].negated {
+					i++
+					value += tokens[i].text
+					tok.raw = input[tok.pos : tokens[i].pos+len(tokens[i].raw)]
+				}
+				if value == "" {
+					return q, tok.errorf("missing value")
+				}
+				if err := q.addField(key, value); err != nil {
+					return q, tok.errorf("%s", err)
+				}
+				continue
+			}
+		}
+
+		if tok.negated {
+			q.Exclude = append(q.Exclude, strings.ToLower(tok.text))
+			continue
+		}
+		term := tok.text
+		if tok.quoted && strings.ContainsAny(term, " \t") {
+			term = `"` + term + `"`
+		}
+		group = append(group, term)
+	}
+
+	if lastOr != nil && len(group) == 0 {
+		return q, lastOr.errorf("OR needs a search term on both sides")
+	}
+	if len(group) > 0 {
+		groups = append(groups, group)
+	}
+	for _, g := range groups {
+		q.Alternatives = append(q.Alternatives, strings.Join(g, " "))
+	}
+	if len(q.Alternatives) == 0 {
+		q.Alternatives = []string{""}
+		if len(q.Kinds) == 0 && len(q.Authors) == 0 && len(q.Tags) == 0 && q.Since == 0 && q.Until == 0 {
+			return q, &SearchQueryError{Reason: "nothing to search for"}
+		}
+	}
+	if q.Since != 0 && q.Until != 0 && q.Since > q.Until {
+		return q, &SearchQueryError{Reason: "since is after until"}
+	}
+
+	return q, nil
+}
+
+func tokenizeSearch(input string) ([]searchToken, error) {
+	isSpace := func(c byte) bool { return c == ' ' || c == '\t' || c == '\n' || c == '\r' }
+
+	var tokens []searchToken
+	for i := 0; i < len(input); {
+		if isSpace(input[i]) {
+			i++
+			continue
+		}
+
+		tok := searchToken{pos: i}
+		if input[i] == '-' && i+1 < len(input) && !isSpace(input[i+1]) {
+			tok.negated = true
+			i++
+		}
+
+		var b strings.Builder
+		for i < len(input) && !isSpace(input[i]) {
+			if input[i] == '"' {
+				end := strings.IndexByte(input[i+1:], '"')
+				if end < 0 {
+					return nil, &SearchQueryError{Pos: i, Token: input[i:], Reason: "unterminated quote"}
+				}
+				b.WriteString(input[i+1 : i+1+end])
+				tok.quoted = true
+				i += end + 2
+				continue
+			}
+			b.WriteByte(input[i])
+			i++
+		}
+
+		tok.text = b.String()
+		tok.raw = input[tok.pos:i]
+		if tok.text != "" {
+			tokens = append(tokens, tok)
+		}
+	}
+	return tokens, nil
+}
+
+// cutSearchField splits "kinds:1" or "#t:nostr" into its field and value.
+// Anything else, URLs included, is a search term.
+func cutSearchField(text string) (key, value string, ok bool) {
+	if len(text) > 5 && strings.EqualFold(text[:5], "tags:") {
+		text = text[5:]
+	}
+	if strings.HasPrefix(text, "#") {
+		return strings.Cut(text, ":")
+	}
+
+	key, value, ok = strings.Cut(text, ":")
+	switch strings.ToLower(key) {
+	case "kind", "kinds", "author", "authors", "since", "until":
+		return key, value, ok
+	}
+	return "", "", false
+}
+
+func (q *SearchQuery) addField(key, value string) error {
+	switch strings.ToLower(key) {
+	case "kind", "kinds":
+		for _, part := range strings.Split(value, ",") {
+			if part = strings.TrimSpace(part); part == "" {
+				continue
+			}
+			k, err := strconv.Atoi(part)
+			if err != nil || k < 0 || k > 65535 {
+				return fmt.Errorf("invalid kind %q", part)
+			}
+			q.Kinds = append(q.Kinds, nostr.Kind(k))
+		}
+
+	case "author", "authors":
+		for _, part := range strings.Split(value, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				q.Authors = append(q.Authors, part)
+			}
+		}
+
+	case "since", "until":
+		ts, err := ParseTimestamp(value)
+		if err != nil {
+			return err
+		}
+		if strings.EqualFold(key, "since") {
+			q.Since = ts
+		} else {
+			q.Until = ts
+		}
+
+	default:
+		name := key[1:]
+		if len(name) != 1 || !(name[0] >= 'a' && name[0] <= 'z' || name[0] >= 'A' && name[0] <= 'Z') {
+			return fmt.Errorf("tag filters take a single letter, not %q", name)
+		}
+		if q.Tags == nil {
+			q.Tags = make(nostr.TagMap)
+		}
+		for _, part := range strings.Split(value, ",") {
+			if part == "" {
+				continue
+			}
+			if name == "t" {
+				part = strings.ToLower(part)
+			}
+			q.Tags[name] = append(q.Tags[name], part)
+		}
+	}
+	return nil
+}
+
+func (tok searchToken) errorf(format string, args ...any) *SearchQueryError {
+	return &SearchQueryError{Pos: tok.pos, Token: tok.raw, Reason: fmt.Sprintf(format, args...)}
+}
+
+// CompiledSearch is a SearchQuery turned into one filter per alternative. Match does
+// what relays cannot, so every result should go through it.
+type CompiledSearch struct {
+	Filters []nostr.Filter
+	Exclude []string
+}
+
+// Compile resolves the authors of q and builds its filters with the given limit.
+func (q SearchQuery) Compile(ctx context.Context, app *config.AppContext, limit int) (CompiledSearch, error) {
+	base := nostr.Filter{
+		Kinds: q.Kinds,
+		Since: q.Since,
+		Until: q.Until,
+		Tags:  q.Tags,
+		Limit: limit,
+	}
+	for _, author := range q.Authors {
+		resolved, err := ResolveAlias(app, author)
+		if err != nil {
+			return CompiledSearch{}, err
+		}
+		pp := nostr_sdk.InputToProfile(ctx, resolved)
+		if pp == nil {
+			return CompiledSearch{}, fmt.Errorf("unknown author %q", author)
+		}
+		base.Authors = append(base.Authors, pp.PublicKey)
+	}
+
+	search := CompiledSearch{Exclude: q.Exclude}
+	for _, alt := range q.Alternatives {
+		filter := base
+		filter.Search = alt
+		search.Filters = append(search.Filters, filter)
+	}
+	return search, nil
+}
+
+// Match reports whether evt satisfies one of the filters, ignoring the full-text part
+// which only relays and the local index can judge, and contains no excluded term.
+func (c CompiledSearch) Match(evt nostr.Event) bool {
+	content := strings.ToLower(evt.Content)
+	for _, term := range c.Exclude {
+		if strings.Contains(content, term) {
+			return false
+		}
+	}
+	if len(c.Filters) == 0 {
+		return true
+	}
+	for _, filter := range c.Filters {
+		filter.Search = ""
+		if filter.Matches(evt) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,169 +1,252 @@
 package utils
 
 import (
+	"context"
+	"errors"
+	"strings"
 	"testing"
 
 	"fiatjaf.com/nostr"
+	"github.com/jerry-harm/nosmec/config"
+	"github.com/spf13/viper"
 )
 
-func TestParseSearchFilter_BasicKeywords(t *testing.T) {
+func TestParseSearchQuery_BasicKeywords(t *testing.T) {
 	tests := []struct {
 		name     string
 		input    string
 		wantText string
-		wantLen  int
 	}{
-		{"plain text", "hello world", "hello world", 1},
-		{"text with spaces", "search term here", "search term here", 1},
-		{"empty string", "", "", 0},
-		{"only whitespace", "   ", "", 0},
+		{"plain text", "hello world", "hello world"},
+		{"text with spaces", "search term here", "search term here"},
+		{"extra whitespace", "  hello   world ", "hello world"},
+		{"phrase", `"hello world" nostr`, `"hello world" nostr`},
+		{"url is text", "https://example.com/a:b", "https://example.com/a:b"},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			filter, searchText := ParseSearchFilter(tt.input)
-			if searchText != tt.wantText {
-				t.Errorf("searchText = %q, want %q", searchText, tt.wantText)
+			q, err := ParseSearchQuery(tt.input)
+			if err != nil {
+				t.Fatalf("ParseSearchQuery(%q): %v", tt.input, err)
 			}
-			if filter.Search != tt.wantText {
-				t.Errorf("filter.Search = %q, want %q", filter.Search, tt.wantText)
+			if len(q.Alternatives) != 1 || q.Alternatives[0] != tt.wantText {
+				t.Errorf("Alternatives = %q, want [%q]", q.Alternatives, tt.wantText)
 			}
 		})
 	}
 }
 
-func TestParseSearchFilter_Kinds(t *testing.T) {
+func TestParseSearchQuery_Kinds(t *testing.T) {
 	tests := []struct {
 		name     string
 		input    string
-		wantLen  int
 		wantKind []int
 	}{
-		{"single kind", "kinds:1", 1, []int{1}},
-		{"multiple kinds", "kinds:1,3", 2, []int{1, 3}},
-		{"kinds with space", "kinds: 1, 3", 2, []int{1, 3}},
-		{"kinds at start", "kinds:1 hello", 1, []int{1}},
-		{"kinds at end", "hello kinds:1", 1, []int{1}},
-		{"kinds middle", "hello kinds:1 world", 1, []int{1}},
-		{"no kinds", "hello world", 0, nil},
+		{"single kind", "kinds:1", []int{1}},
+		{"multiple kinds", "kinds:1,3", []int{1, 3}},
+		{"kinds with space", "kinds: 1, 3", []int{1, 3}},
+		{"kinds at start", "kinds:1 hello", []int{1}},
+		{"kinds at end", "hello kinds:1", []int{1}},
+		{"kinds middle", "hello kinds:1 world", []int{1}},
+		{"repeated", "kind:1 kinds:30023", []int{1, 30023}},
+		{"no kinds", "hello world", nil},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			filter, _ := ParseSearchFilter(tt.input)
-			if len(filter.Kinds) != tt.wantLen {
-				t.Errorf("len(filter.Kinds) = %d, want %d", len(filter.Kinds), tt.wantLen)
+			q, err := ParseSearchQuery(tt.input)
+			if err != nil {
+				t.Fatalf("ParseSearchQuery(%q): %v", tt.input, err)
+			}
+			if len(q.Kinds) != len(tt.wantKind) {
+				t.Fatalf("Kinds = %v, want %v", q.Kinds, tt.wantKind)
 			}
 			for i, wantK := range tt.wantKind {
-				if i < len(filter.Kinds) && int(filter.Kinds[i]) != wantK {
-					t.Errorf("filter.Kinds[%d] = %d, want %d", i, filter.Kinds[i], wantK)
+				if int(q.Kinds[i]) != wantK {
+					t.Errorf("Kinds[%d] = %d, want %d", i, q.Kinds[i], wantK)
 				}
 			}
 		})
 	}
 }
 
-func TestParseSearchFilter_NoAuthor(t *testing.T) {
-	filter, _ := ParseSearchFilter("hello world")
-	if len(filter.Authors) != 0 {
-		t.Errorf("len(filter.Authors) = %d, want 0", len(filter.Authors))
+func TestParseSearchQuery_CaseInsensitive(t *testing.T) {
+	for _, input := range []string{"kinds:1", "KINDS:1", "Kinds:1", "kInDs:1"} {
+		q, err := ParseSearchQuery(input)
+		if err != nil {
+			t.Fatalf("ParseSearchQuery(%q): %v", input, err)
+		}
+		if len(q.Kinds) != 1 || int(q.Kinds[0]) != 1 {
+			t.Errorf("input %q: Kinds = %v, want [1]", input, q.Kinds)
+		}
 	}
 }
 
-func TestParseSearchFilter_CaseInsensitive(t *testing.T) {
-	tests := []string{
-		"kinds:1",
-		"KINDS:1",
-		"Kinds:1",
-		"kInDs:1",
+func TestParseSearchQuery_Authors(t *testing.T) {
+	q, err := ParseSearchQuery("authors:alice,npub1xyz author:bob@example.com hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"alice", "npub1xyz", "bob@example.com"}
+	if strings.Join(q.Authors, " ") != strings.Join(want, " ") {
+		t.Errorf("Authors = %q, want %q", q.Authors, want)
 	}
 
-	for _, input := range tests {
-		filter, _ := ParseSearchFilter(input)
-		if len(filter.Kinds) != 1 || int(filter.Kinds[0]) != 1 {
-			t.Errorf("input %q: filter.Kinds = %v, want [1]", input, filter.Kinds)
-		}
+	q, _ = ParseSearchQuery("hello world")
+	if len(q.Authors) != 0 {
+		t.Errorf("len(Authors) = %d, want 0", len(q.Authors))
 	}
 }
 
-func TestParseSearchFilter_Tags(t *testing.T) {
+func TestParseSearchQuery_Tags(t *testing.T) {
 	tests := []struct {
-		name    string
-		input   string
-		wantLen int
-		wantTag string
+		name  string
+		input string
+		want  nostr.TagMap
 	}{
-		{"hashtag with hash", "#t:nostr", 1, "nostr"},
-		{"hashtag with tag prefix", "tags:#t:nostr", 1, "nostr"},
-		{"no tag", "hello world", 0, ""},
+		{"hashtag", "#t:nostr", nostr.TagMap{"t": {"nostr"}}},
+		{"hashtag with tag prefix", "tags:#t:nostr", nostr.TagMap{"t": {"nostr"}}},
+		{"hashtags are lower-cased", "#t:Nostr,Bitcoin", nostr.TagMap{"t": {"nostr", "bitcoin"}}},
+		{"any letter", "#p:abc #K:1 #e:def", nostr.TagMap{"p": {"abc"}, "K": {"1"}, "e": {"def"}}},
+		{"no tag", "hello #world", nil},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			filter, _ := ParseSearchFilter(tt.input)
-			if tt.wantLen > 0 {
-				if len(filter.Tags) == 0 {
-					t.Errorf("filter.Tags is empty, want non-empty")
-					return
-				}
-				if tags, ok := filter.Tags["t"]; !ok || len(tags) == 0 {
-					t.Errorf("filter.Tags[\"t\"] not found or empty")
-				} else if tags[0] != tt.wantTag {
-					t.Errorf("tag = %q, want %q", tags[0], tt.wantTag)
+			q, err := ParseSearchQuery(tt.input)
+			if err != nil {
+				t.Fatalf("ParseSearchQuery(%q): %v", tt.input, err)
+			}
+			if len(q.Tags) != len(tt.want) {
+				t.Fatalf("Tags = %v, want %v", q.Tags, tt.want)
+			}
+			for name, values := range tt.want {
+				if strings.Join(q.Tags[name], ",") != strings.Join(values, ",") {
+					t.Errorf("Tags[%q] = %q, want %q", name, q.Tags[name], values)
 				}
 			}
 		})
 	}
 }
 
-func TestParseSearchFilter_Combined(t *testing.T) {
-	input := "kinds:1,3 #t:nostr hello world"
-
-	filter, searchText := ParseSearchFilter(input)
-
-	if searchText != "hello world" {
-		t.Errorf("searchText = %q, want %q", searchText, "hello world")
+func TestParseSearchQuery_Combined(t *testing.T) {
+	q, err := ParseSearchQuery("kinds:1,3 #t:nostr hello world -spam since:2024-01-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(q.Alternatives) != 1 || q.Alternatives[0] != "hello world" {
+		t.Errorf("Alternatives = %q, want [\"hello world\"]", q.Alternatives)
 	}
-	if len(filter.Kinds) != 2 {
-		t.Errorf("len(filter.Kinds) = %d, want 2", len(filter.Kinds))
+	if len(q.Kinds) != 2 || int(q.Kinds[0]) != 1 || int(q.Kinds[1]) != 3 {
+		t.Errorf("Kinds = %v, want [1 3]", q.Kinds)
 	}
-	if int(filter.Kinds[0]) != 1 || int(filter.Kinds[1]) != 3 {
-		t.Errorf("filter.Kinds = %v, want [1, 3]", filter.Kinds)
+	if len(q.Tags["t"]) != 1 || q.Tags["t"][0] != "nostr" {
+		t.Errorf("Tags[t] = %v, want [nostr]", q.Tags["t"])
 	}
-	if filter.Tags == nil || len(filter.Tags["t"]) == 0 {
-		t.Errorf("filter.Tags['t'] missing")
-	} else if filter.Tags["t"][0] != "nostr" {
-		t.Errorf("filter.Tags['t'][0] = %q, want %q", filter.Tags["t"][0], "nostr")
+	if len(q.Exclude) != 1 || q.Exclude[0] != "spam" {
+		t.Errorf("Exclude = %v, want [spam]", q.Exclude)
+	}
+	if q.Since.Time().UTC().Format("2006-01-02") != "2024-01-01" {
+		t.Errorf("Since = %v", q.Since.Time())
 	}
 }
 
-func TestParseSearchFilter_InvalidKinds(t *testing.T) {
-	filter, _ := ParseSearchFilter("kinds:abc")
+func TestParseSearchQuery_Or(t *testing.T) {
+	q, err := ParseSearchQuery(`bitcoin OR "lightning network" kinds:1 OR zaps -scam`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"bitcoin", `"lightning network"`, "zaps"}
+	if strings.Join(q.Alternatives, "|") != strings.Join(want, "|") {
+		t.Errorf("Alternatives = %q, want %q", q.Alternatives, want)
+	}
+	if len(q.Kinds) != 1 || len(q.Exclude) != 1 {
+		t.Errorf("Kinds = %v, Exclude = %v", q.Kinds, q.Exclude)
+	}
 
-	if len(filter.Kinds) != 0 {
-		t.Errorf("len(filter.Kinds) = %d, want 0 for invalid kind", len(filter.Kinds))
+	// a quoted or lower-case or is a search term
+	q, err = ParseSearchQuery(`this or "OR" that`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(q.Alternatives) != 1 {
+		t.Errorf("Alternatives = %q, want one", q.Alternatives)
 	}
 }
 
-func TestParseSearchFilter_ValidKind(t *testing.T) {
-	filter, _ := ParseSearchFilter("kinds:1,3")
+func TestParseSearchQuery_Relative(t *testing.T) {
+	q, err := ParseSearchQuery("nostr since:7d until:1d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	week := nostr.Now() - 7*24*60*60
+	if q.Since < week-5 || q.Since > week+5 {
+		t.Errorf("Since = %d, want about %d", q.Since, week)
+	}
+	if q.Until <= q.Since {
+		t.Errorf("Until = %d, want after Since %d", q.Until, q.Since)
+	}
+}
 
-	if len(filter.Kinds) != 2 {
-		t.Errorf("len(filter.Kinds) = %d, want 2", len(filter.Kinds))
+func TestParseSearchQuery_Errors(t *testing.T) {
+	tests := []struct {
+		input   string
+		wantPos int
+	}{
+		{"", -1},
+		{"   ", -1},
+		{"-spam", -1},
+		{"kinds:abc", 0},
+		{"hello kinds:", 6},
+		{"kinds:70000", 0},
+		{"OR hello", 0},
+		{"hello OR", 6},
+		{"hello OR OR world", 9},
+		{`hello "world`, 6},
+		{"#tt:nostr", 0},
+		{"since:yesterdayish", 0},
+		{"-kinds:1 hello", 0},
+		{"hello since:2024-02-01 until:2024-01-01", -1},
 	}
-	if int(filter.Kinds[0]) != 1 || int(filter.Kinds[1]) != 3 {
-		t.Errorf("filter.Kinds = %v, want [1, 3]", filter.Kinds)
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := ParseSearchQuery(tt.input)
+			var qerr *SearchQueryError
+			if !errors.As(err, &qerr) {
+				t.Fatalf("ParseSearchQuery(%q) error = %v, want a SearchQueryError", tt.input, err)
+			}
+			if tt.wantPos >= 0 && (qerr.Token == "" || qerr.Pos != tt.wantPos) {
+				t.Errorf("error %q at %d, want position %d", qerr, qerr.Pos, tt.wantPos)
+			}
+		})
 	}
 }
 
-func TestParseSearchFilter_EmptyKinds(t *testing.T) {
-	filter, searchText := ParseSearchFilter("kinds: ")
+func TestCompiledSearch_Match(t *testing.T) {
+	pk := nostr.GetPublicKey(nostr.Generate())
+	search := CompiledSearch{
+		Filters: []nostr.Filter{
+			{Kinds: []nostr.Kind{1}, Search: "bitcoin"},
+			{Kinds: []nostr.Kind{1}, Search: "lightning"},
+		},
+		Exclude: []string{"scam"},
+	}
 
-	if searchText != "kinds:" && searchText != "" {
-		t.Errorf("searchText = %q, expected cleared or empty", searchText)
+	tests := []struct {
+		name string
+		evt  nostr.Event
+		want bool
+	}{
+		{"match", nostr.Event{Kind: 1, PubKey: pk, Content: "anything"}, true},
+		{"excluded term", nostr.Event{Kind: 1, PubKey: pk, Content: "a SCAM coin"}, false},
+		{"wrong kind", nostr.Event{Kind: 7, PubKey: pk, Content: "anything"}, false},
 	}
-	if len(filter.Kinds) != 0 {
-		t.Errorf("len(filter.Kinds) = %d, want 0", len(filter.Kinds))
+	for _, tt := range tests {
+		if got := search.Match(tt.evt); got != tt.want {
+			t.Errorf("%s: Match = %v, want %v", tt.name, got, tt.want)
+		}
 	}
 }
 
@@ -179,4 +262,43 @@ func TestSearchResult_Struct(t *testing.T) {
 	if result.Relay != "wss://relay.example.com" {
 		t.Errorf("result.Relay = %q, want %q", result.Relay, "wss://relay.example.com")
 	}
-}
\ No newline at end of file
+}
+
+func TestSearchEvents_LocalOnly(t *testing.T) {
+	app := config.NewAppContext(nil, config.Config{
+		DataDir:      t.TempDir(),
+		SearchRelays: []string{"wss://unreachable.invalid"},
+	}, viper.New())
+	defer app.Close()
+
+	sys := app.System()
+	if !sys.HasSearchIndex() {
+		t.Fatal("expected a search index")
+	}
+
+	sk := nostr.Generate()
+	for _, content := range []string{"offline search works", "something else entirely"} {
+		evt := nostr.Event{Kind: 1, CreatedAt: nostr.Now(), Content: content}
+		if err := evt.Sign(sk); err != nil {
+			t.Fatal(err)
+		}
+		if err := sys.Store.SaveEvent(evt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	results, err := SearchEvents(context.Background(), app, "offline", SearchOptions{Limit: 10, LocalOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("len(results) = %d, want 1", len(results))
+	}
+	r := results[0]
+	if r.Event.Content != "offline search works" {
+		t.Errorf("result content = %q", r.Event.Content)
+	}
+	if r.Relay != "" || len(r.Sources) != 1 || r.Sources[0] != LocalSearchSource {
+		t.Errorf("result relay = %q, sources = %v, want only %q", r.Relay, r.Sources, LocalSearchSource)
+	}
+}
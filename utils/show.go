@@ -3,6 +3,7 @@ package utils
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 
 	"fiatjaf.com/nostr"
@@ -11,23 +12,24 @@ import (
 )
 
 func PrintEvent(ev *nostr.Event, j bool) {
+	FprintEvent(os.Stdout, ev, j)
+}
+
+// FprintEvent is PrintEvent writing to w.
+func FprintEvent(w io.Writer, ev *nostr.Event, j bool) {
 	if j {
-		json.NewEncoder(os.Stdout).Encode(ev)
+		json.NewEncoder(w).Encode(ev)
 		return
 	}
 
-	color.Set(color.FgHiBlue)
 	nevent := nip19.EncodeNevent(ev.ID, nil, ev.PubKey)
-	fmt.Println(nevent)
-	color.Set(color.Reset)
+	color.New(color.FgHiBlue).Fprintln(w, nevent)
 
-	fmt.Print(ev.CreatedAt.Time().Format("2006-01-02T15:04:05") + "\n")
+	fmt.Fprint(w, ev.CreatedAt.Time().Format("2006-01-02T15:04:05")+"\n")
 
-	color.Set(color.FgRed)
 	npub := nip19.EncodeNpub(ev.PubKey)
-	fmt.Println(npub)
-	color.Set(color.Reset)
+	color.New(color.FgRed).Fprintln(w, npub)
 
-	fmt.Println(ev.Content)
-	fmt.Println()
+	fmt.Fprintln(w, ev.Content)
+	fmt.Fprintln(w)
 }
@@ -4,9 +4,11 @@ import (
 	"bytes"
 	"encoding/json"
 	"os"
+	"strings"
 	"testing"
 
 	"fiatjaf.com/nostr"
+	"fiatjaf.com/nostr/nip19"
 )
 
 func TestPrintEvent_JsonOutput(t *testing.T) {
@@ -129,4 +131,22 @@ func TestPrintEvent_ZeroValueEvent(t *testing.T) {
 	if decoded.Kind != 0 {
 		t.Errorf("decoded.Kind = %v, want 0", decoded.Kind)
 	}
-}
\ No newline at end of file
+}
+func TestFprintEvent_Text(t *testing.T) {
+	ev := &nostr.Event{
+		PubKey:    nostr.GetPublicKey(nostr.Generate()),
+		CreatedAt: nostr.Timestamp(1700000000),
+		Kind:      nostr.KindTextNote,
+		Content:   "Hello, World!",
+	}
+
+	var buf bytes.Buffer
+	FprintEvent(&buf, ev, false)
+
+	out := buf.String()
+	for _, want := range []string{nip19.EncodeNevent(ev.ID, nil, ev.PubKey), nip19.EncodeNpub(ev.PubKey), ev.Content} {
+		if !strings.Contains(out, want) {
+			t.Errorf("FprintEvent() missing %q in %q", want, out)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"fiatjaf.com/nostr"
+	"github.com/jerry-harm/nosmec/config"
+	"github.com/jerry-harm/nosmec/nostr_sdk"
+)
+
+var byteUnits = []struct {
+	suffix string
+	mult   int64
+}{
+	{"GIB", 1 << 30},
+	{"MIB", 1 << 20},
+	{"KIB", 1 << 10},
+	{"GB", 1000 * 1000 * 1000},
+	{"MB", 1000 * 1000},
+	{"KB", 1000},
+	{"G", 1 << 30},
+	{"M", 1 << 20},
+	{"K", 1 << 10},
+	{"B", 1},
+}
+
+// ParseByteSize parses sizes such as "500MB", "2GiB" or "1024". An empty string means no limit.
+func ParseByteSize(s string) (int64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if s == "" {
+		return 0, nil
+	}
+
+	mult := int64(1)
+	for _, unit := range byteUnits {
+		if strings.HasSuffix(s, unit.suffix) {
+			s = strings.TrimSpace(strings.TrimSuffix(s, unit.suffix))
+			mult = unit.mult
+			break
+		}
+	}
+
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return int64(n * float64(mult)), nil
+}
+
+// FormatByteSize renders a byte count with a binary unit, e.g. "12.3 MiB".
+func FormatByteSize(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GiB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
+
+// GCPolicyFromConfig builds the eviction policy described by the storage.gc config section.
+func GCPolicyFromConfig(ctx context.Context, app *config.AppContext, cfg config.GCConfig) (nostr_sdk.GCPolicy, error) {
+	var policy nostr_sdk.GCPolicy
+
+	if cfg.MaxAge != "" {
+		age, err := ParseDuration(cfg.MaxAge)
+		if err != nil {
+			return policy, err
+		}
+		policy.MaxAge = age
+	}
+
+	size, err := ParseByteSize(cfg.MaxSize)
+	if err != nil {
+		return policy, err
+	}
+	policy.MaxSize = size
+
+	policy.Keep = gcKeepFunc(ctx, app, cfg)
+	return policy, nil
+}
+
+// gcKeepFunc protects our own events, events by followed users and bookmarked events.
+// Only the local store is consulted, so garbage collection works offline.
+func gcKeepFunc(ctx context.Context, app *config.AppContext, cfg config.GCConfig) func(evt nostr.Event) bool {
+	authors := make(map[nostr.PubKey]struct{})
+	ids := make(map[nostr.ID]struct{})
+	addrs := make(map[string]struct{})
+
+	me, err := app.GetMyPubKey()
+	hasMe := err == nil
+
+	if cfg.KeepOwn && hasMe {
+		authors[me] = struct{}{}
+	}
+	if cfg.KeepFollows {
+		for _, sub := range app.ListSubscriptions("user") {
+			if pk, err := ResolveAliasToPubKey(app, sub.ID); err == nil {
+				authors[pk] = struct{}{}
+			}
+		}
+	}
+	if cfg.KeepBookmarks && hasMe {
+		filter := nostr.Filter{Kinds: []nostr.Kind{10003}, Authors: []nostr.PubKey{me}, Limit: 1}
+		for evt := range app.System().Store.QueryEvents(filter, 1) {
+			for _, tag := range evt.Tags {
+				if len(tag) < 2 {
+					continue
+				}
+				switch tag[0] {
+				case "e":
+					if id, err := nostr.IDFromHex(tag[1]); err == nil {
+						ids[id] = struct{}{}
+					}
+				case "a":
+					addrs[tag[1]] = struct{}{}
+				}
+			}
+		}
+	}
+
+	return func(evt nostr.Event) bool {
+		if _, ok := authors[evt.PubKey]; ok {
+			return true
+		}
+		if _, ok := ids[evt.ID]; ok {
+			return true
+		}
+		if len(addrs) > 0 && evt.Kind.IsAddressable() {
+			addr := fmt.Sprintf("%d:%s:%s", evt.Kind, evt.PubKey.Hex(), evt.Tags.GetD())
+			if _, ok := addrs[addr]; ok {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// RunAutoGC collects garbage when storage.gc.auto is enabled and the configured interval
+// has passed since the last run. It returns nil when nothing was done.
+func RunAutoGC(ctx context.Context, app *config.AppContext) (*nostr_sdk.GCReport, error) {
+	cfg := app.Config().Storage.GC
+	if !cfg.Auto {
+		return nil, nil
+	}
+
+	interval := 24 * time.Hour
+	if cfg.Interval != "" {
+		d, err := ParseDuration(cfg.Interval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid storage.gc.interval: %w", err)
+		}
+		interval = d
+	}
+	last := app.System().LastGarbageCollection()
+	if last > 0 && time.Since(last.Time()) < interval {
+		return nil, nil
+	}
+
+	policy, err := GCPolicyFromConfig(ctx, app, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid storage.gc policy: %w", err)
+	}
+	report, err := app.System().CollectGarbage(ctx, policy)
+	return &report, err
+}
+
+// FormatGCReport summarizes a garbage collection run.
+func FormatGCReport(report nostr_sdk.GCReport, dryRun bool) string {
+	verb := "Evicted"
+	if dryRun {
+		verb = "Would evict"
+	}
+	out := fmt.Sprintf("%s %d of %d events (%s); %d protected, %s kept\n",
+		verb, report.Evicted, report.Scanned, FormatByteSize(report.FreedBytes),
+		report.Protected, FormatByteSize(report.KeptBytes))
+	if report.ExpiredCache > 0 {
+		out += fmt.Sprintf("Removed %d expired cache entries\n", report.ExpiredCache)
+	}
+	return out
+}
@@ -0,0 +1,42 @@
+package utils
+
+import "testing"
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int64
+	}{
+		{"", 0},
+		{"1024", 1024},
+		{"500MB", 500 * 1000 * 1000},
+		{"2GiB", 2 << 30},
+		{"1.5k", 1536},
+		{" 10 kb ", 10000},
+	}
+	for _, tt := range tests {
+		got, err := ParseByteSize(tt.input)
+		if err != nil {
+			t.Errorf("ParseByteSize(%q) error: %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseByteSize(%q) = %d, want %d", tt.input, got, tt.want)
+		}
+	}
+
+	for _, input := range []string{"MB", "-1GB", "lots"} {
+		if _, err := ParseByteSize(input); err == nil {
+			t.Errorf("ParseByteSize(%q) succeeded, want error", input)
+		}
+	}
+}
+
+func TestFormatByteSize(t *testing.T) {
+	if got := FormatByteSize(512); got != "512 B" {
+		t.Errorf("FormatByteSize(512) = %q", got)
+	}
+	if got := FormatByteSize(3 << 20); got != "3.0 MiB" {
+		t.Errorf("FormatByteSize(3MiB) = %q", got)
+	}
+}
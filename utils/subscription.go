@@ -10,6 +10,7 @@ import (
 	"fiatjaf.com/nostr/nip19"
 	"github.com/jerry-harm/nosmec/config"
 	"github.com/jerry-harm/nosmec/logger"
+	"github.com/jerry-harm/nosmec/nostr_sdk"
 )
 
 func FollowCommunity(ctx context.Context, app *config.AppContext, communityAddr string, relay string) error {
@@ -58,204 +59,158 @@ func UnfollowHashtag(ctx context.Context, app *config.AppContext, hashtag string
 	return app.RemoveSubscription("hashtag", hashtag)
 }
 
-func SyncSubscriptionsFromNetwork(ctx context.Context, app *config.AppContext) error {
-	secretKey, err := app.GetMySecretKey()
-	if err != nil {
-		return fmt.Errorf("failed to get secret key: %w", err)
-	}
-	pubKey := secretKey.Public()
+// subscriptionList ties a subscription type to the list kind it is published as.
+type subscriptionList struct {
+	subType string
+	kind    nostr.Kind
+	toTags  func(app *config.AppContext, subs []config.Subscription) nostr.Tags
+	fromTag func(tag nostr.Tag) (config.Subscription, bool)
+}
 
-	communities, err := syncCommunitiesFromNetwork(ctx, app, pubKey)
-	if err != nil {
-		return fmt.Errorf("failed to sync communities: %w", err)
-	}
+var (
+	communitySubscriptions = subscriptionList{"community", nostr.KindCommunityList, communityListTags, communityFromTag}
+	userSubscriptions      = subscriptionList{"user", nostr.KindFollowList, followListTags, userFromTag}
+	hashtagSubscriptions   = subscriptionList{"hashtag", nostr.KindInterestList, interestListTags, hashtagFromTag}
 
-	users, err := syncUsersFromNetwork(ctx, app, pubKey)
-	if err != nil {
-		return fmt.Errorf("failed to sync users: %w", err)
-	}
+	subscriptionLists = []subscriptionList{communitySubscriptions, userSubscriptions, hashtagSubscriptions}
+)
 
-	hashtags, err := syncHashtagsFromNetwork(ctx, app, pubKey)
+// SyncSubscriptionsFromNetwork merges our follow, community and interest lists from the
+// network into the configured subscriptions. Types whose list is not found are left alone.
+func SyncSubscriptionsFromNetwork(ctx context.Context, app *config.AppContext, opts SyncOptions) ([]ListDiff, error) {
+	secretKey, err := app.GetMySecretKey()
 	if err != nil {
-		return fmt.Errorf("failed to sync hashtags: %w", err)
-	}
-
-	subscriptions := append(communities, users...)
-	subscriptions = append(subscriptions, hashtags...)
-
-	if err := app.ReplaceAllSubscriptions(subscriptions); err != nil {
-		return fmt.Errorf("failed to save subscriptions: %w", err)
+		return nil, fmt.Errorf("failed to get secret key: %w", err)
 	}
+	pubKey := secretKey.Public()
 
-	return nil
-}
-
-func syncCommunitiesFromNetwork(ctx context.Context, app *config.AppContext, pubKey nostr.PubKey) ([]config.Subscription, error) {
-	filter := nostr.Filter{
-		Kinds:   []nostr.Kind{nostr.KindCommunityList},
-		Authors: []nostr.PubKey{pubKey},
-		Limit:   1,
-	}
+	var diffs []ListDiff
+	var subscriptions []config.Subscription
+	for _, list := range subscriptionLists {
+		local := app.ListSubscriptions(list.subType)
+		remote := fetchOwnSubscriptionList(ctx, app, pubKey, list.kind)
 
-	readableRelays := app.ReadableRelays()
-	if len(readableRelays) == 0 {
-		return []config.Subscription{}, nil
-	}
+		diff, err := mergeOwnList(app, list.kind, list.toTags(app, local), remote, opts)
+		if err != nil {
+			return diffs, fmt.Errorf("failed to merge %s subscriptions: %w", list.subType, err)
+		}
+		diffs = append(diffs, diff)
 
-	var event nostr.Event
-	for _, relay := range readableRelays {
-		ctxTimeout, cancel := context.WithTimeout(ctx, app.QueryTimeout())
-		result := app.Pool().QuerySingle(ctxTimeout, []string{relay}, filter, nostr.SubscriptionOptions{})
-		cancel()
-		if result != nil && result.Event.ID != [32]byte{} {
-			event = result.Event
-			break
+		if !diff.Found {
+			subscriptions = append(subscriptions, local...)
+			continue
 		}
+		subscriptions = append(subscriptions, subscriptionsFromMerge(app, list, local, diff.Result)...)
 	}
 
-	if event.ID == [32]byte{} {
-		return []config.Subscription{}, nil
+	if opts.DryRun {
+		return diffs, nil
 	}
 
-	subscriptions := make([]config.Subscription, 0)
-	for _, tag := range event.Tags {
-		if len(tag) >= 2 && tag[0] == "a" && strings.HasPrefix(tag[1], "34550:") {
-			relay := ""
-			if len(tag) >= 3 {
-				relay = tag[2]
+	if err := app.ReplaceAllSubscriptions(subscriptions); err != nil {
+		return diffs, fmt.Errorf("failed to save subscriptions: %w", err)
+	}
+	for _, diff := range diffs {
+		if diff.Found {
+			if err := saveListSnapshot(app, diff.Kind, diff.Remote); err != nil {
+				return diffs, err
 			}
-			subscriptions = append(subscriptions, config.Subscription{
-				Type:  "community",
-				ID:    tag[1],
-				Relay: relay,
-			})
 		}
 	}
 
-	return subscriptions, nil
+	return diffs, nil
 }
 
-func syncUsersFromNetwork(ctx context.Context, app *config.AppContext, pubKey nostr.PubKey) ([]config.Subscription, error) {
-	filter := nostr.Filter{
-		Kinds:   []nostr.Kind{nostr.KindFollowList},
-		Authors: []nostr.PubKey{pubKey},
-		Limit:   1,
-	}
-
-	readableRelays := app.ReadableRelays()
-	if len(readableRelays) == 0 {
-		return []config.Subscription{}, nil
-	}
-
-	var event nostr.Event
-	for _, relay := range readableRelays {
-		ctxTimeout, cancel := context.WithTimeout(ctx, app.QueryTimeout())
-		result := app.Pool().QuerySingle(ctxTimeout, []string{relay}, filter, nostr.SubscriptionOptions{})
-		cancel()
-		if result != nil && result.Event.ID != [32]byte{} {
-			event = result.Event
-			break
+// subscriptionsFromMerge turns merged tags back into subscriptions, reusing the local
+// entry (and so its alias or npub form) whenever the merge kept it unchanged.
+func subscriptionsFromMerge(app *config.AppContext, list subscriptionList, local []config.Subscription, merged nostr.Tags) []config.Subscription {
+	localByKey := make(map[string]config.Subscription, len(local))
+	localTags := make(map[string]nostr.Tag, len(local))
+	for _, sub := range local {
+		tags := list.toTags(app, []config.Subscription{sub})
+		if len(tags) == 1 {
+			key := listTagKey(tags[0])
+			localByKey[key] = sub
+			localTags[key] = tags[0]
 		}
 	}
 
-	if event.ID == [32]byte{} {
-		return []config.Subscription{}, nil
-	}
-
-	subscriptions := make([]config.Subscription, 0)
-	for _, tag := range event.Tags {
-		if len(tag) >= 2 && tag[0] == "p" {
-			relay := ""
-			petname := ""
-			if len(tag) >= 3 {
-				relay = tag[2]
-			}
-			if len(tag) >= 4 {
-				petname = tag[3]
-			}
-
-			pubKeyHex := tag[1]
-			pk, err := nostr.PubKeyFromHex(pubKeyHex)
-			if err != nil {
-				continue
-			}
-			npub := nip19.EncodeNpub(pk)
-			subscriptions = append(subscriptions, config.Subscription{
-				Type:    "user",
-				ID:      npub,
-				Relay:   relay,
-				Petname: petname,
-			})
+	subscriptions := make([]config.Subscription, 0, len(merged))
+	for _, tag := range merged {
+		key := listTagKey(tag)
+		if sub, ok := localByKey[key]; ok && tagsEqual(localTags[key], tag) {
+			subscriptions = append(subscriptions, sub)
+			continue
+		}
+		if sub, ok := list.fromTag(tag); ok {
+			subscriptions = append(subscriptions, sub)
 		}
 	}
-
-	return subscriptions, nil
+	return subscriptions
 }
 
-func syncHashtagsFromNetwork(ctx context.Context, app *config.AppContext, pubKey nostr.PubKey) ([]config.Subscription, error) {
+func fetchOwnSubscriptionList(ctx context.Context, app *config.AppContext, pubKey nostr.PubKey, kind nostr.Kind) *nostr.Event {
 	filter := nostr.Filter{
-		Kinds:   []nostr.Kind{nostr.KindInterestList},
+		Kinds:   []nostr.Kind{kind},
 		Authors: []nostr.PubKey{pubKey},
 		Limit:   1,
 	}
 
-	readableRelays := app.ReadableRelays()
-	if len(readableRelays) == 0 {
-		return []config.Subscription{}, nil
-	}
-
-	var event nostr.Event
-	for _, relay := range readableRelays {
+	for _, relay := range app.ReadableRelays() {
 		ctxTimeout, cancel := context.WithTimeout(ctx, app.QueryTimeout())
 		result := app.Pool().QuerySingle(ctxTimeout, []string{relay}, filter, nostr.SubscriptionOptions{})
 		cancel()
 		if result != nil && result.Event.ID != [32]byte{} {
-			event = result.Event
-			break
+			return &result.Event
 		}
 	}
+	return nil
+}
 
-	if event.ID == [32]byte{} {
-		return []config.Subscription{}, nil
+func communityFromTag(tag nostr.Tag) (config.Subscription, bool) {
+	if len(tag) < 2 || tag[0] != "a" || !strings.HasPrefix(tag[1], "34550:") {
+		return config.Subscription{}, false
 	}
-
-	subscriptions := make([]config.Subscription, 0)
-	for _, tag := range event.Tags {
-		if len(tag) >= 2 && tag[0] == "t" {
-			subscriptions = append(subscriptions, config.Subscription{
-				Type: "hashtag",
-				ID:   tag[1],
-			})
-		}
+	relay := ""
+	if len(tag) >= 3 {
+		relay = tag[2]
 	}
-
-	return subscriptions, nil
+	return config.Subscription{Type: "community", ID: tag[1], Relay: relay}, true
 }
 
-func PublishSubscriptions(ctx context.Context, app *config.AppContext) error {
-	secretKey, err := app.GetMySecretKey()
+func userFromTag(tag nostr.Tag) (config.Subscription, bool) {
+	if len(tag) < 2 || tag[0] != "p" {
+		return config.Subscription{}, false
+	}
+	pk, err := nostr.PubKeyFromHex(tag[1])
 	if err != nil {
-		return fmt.Errorf("failed to get secret key: %w", err)
+		return config.Subscription{}, false
 	}
 
-	if err := publishFollowList(ctx, app, secretKey); err != nil {
-		return fmt.Errorf("failed to publish follow list: %w", err)
+	relay := ""
+	petname := ""
+	if len(tag) >= 3 {
+		relay = tag[2]
 	}
-
-	if err := publishCommunitiesList(ctx, app, secretKey); err != nil {
-		return fmt.Errorf("failed to publish communities list: %w", err)
+	if len(tag) >= 4 {
+		petname = tag[3]
 	}
+	return config.Subscription{
+		Type:    "user",
+		ID:      nip19.EncodeNpub(pk),
+		Relay:   relay,
+		Petname: petname,
+	}, true
+}
 
-	if err := publishInterestsList(ctx, app, secretKey); err != nil {
-		return fmt.Errorf("failed to publish interests list: %w", err)
+func hashtagFromTag(tag nostr.Tag) (config.Subscription, bool) {
+	if len(tag) < 2 || tag[0] != "t" {
+		return config.Subscription{}, false
 	}
-
-	return nil
+	return config.Subscription{Type: "hashtag", ID: tag[1]}, true
 }
 
-func publishFollowList(ctx context.Context, app *config.AppContext, secretKey nostr.SecretKey) error {
-	subscriptions := app.ListSubscriptions("user")
-
+func followListTags(app *config.AppContext, subscriptions []config.Subscription) nostr.Tags {
 	tags := nostr.Tags{}
 	skipped := 0
 	for _, sub := range subscriptions {
@@ -265,44 +220,16 @@ func publishFollowList(ctx context.Context, app *config.AppContext, secretKey no
 			skipped++
 			continue
 		}
-
-		relay := sub.Relay
-		petname := sub.Petname
-		tags = append(tags, nostr.Tag{"p", pubKey.Hex(), relay, petname})
+		tags = append(tags, nostr.Tag{"p", pubKey.Hex(), sub.Relay, sub.Petname})
 	}
 
 	if skipped > 0 {
-		logger.Warn("skipped user subscriptions during publish", "count", skipped)
-	}
-
-	event := &nostr.Event{
-		Kind:      nostr.KindFollowList,
-		CreatedAt: nostr.Timestamp(time.Now().Unix()),
-		Tags:      tags,
-		Content:   "",
-		PubKey:    secretKey.Public(),
-	}
-
-	if err := event.Sign(secretKey); err != nil {
-		return fmt.Errorf("failed to sign event: %w", err)
-	}
-
-	writableRelays := app.WritableRelays()
-	if len(writableRelays) > 0 {
-		resultChan := app.Pool().PublishMany(ctx, writableRelays, *event)
-		for result := range resultChan {
-			if result.Error != nil {
-				return fmt.Errorf("failed to publish to %s: %w", result.RelayURL, result.Error)
-			}
-		}
+		logger.Warn("skipped user subscriptions", "count", skipped)
 	}
-
-	return nil
+	return tags
 }
 
-func publishCommunitiesList(ctx context.Context, app *config.AppContext, secretKey nostr.SecretKey) error {
-	subscriptions := app.ListSubscriptions("community")
-
+func communityListTags(_ *config.AppContext, subscriptions []config.Subscription) nostr.Tags {
 	tags := nostr.Tags{}
 	for _, sub := range subscriptions {
 		if sub.Relay != "" {
@@ -311,61 +238,76 @@ func publishCommunitiesList(ctx context.Context, app *config.AppContext, secretK
 			tags = append(tags, nostr.Tag{"a", sub.ID})
 		}
 	}
+	return tags
+}
 
-	event := &nostr.Event{
-		Kind:      nostr.KindCommunityList,
-		CreatedAt: nostr.Timestamp(time.Now().Unix()),
-		Tags:      tags,
-		Content:   "",
-		PubKey:    secretKey.Public(),
+func interestListTags(_ *config.AppContext, subscriptions []config.Subscription) nostr.Tags {
+	tags := nostr.Tags{}
+	for _, sub := range subscriptions {
+		tags = append(tags, nostr.Tag{"t", sub.ID})
 	}
+	return tags
+}
 
-	if err := event.Sign(secretKey); err != nil {
-		return fmt.Errorf("failed to sign event: %w", err)
+func PublishSubscriptions(ctx context.Context, app *config.AppContext) ([]*nostr_sdk.PublishReport, error) {
+	secretKey, err := app.GetMySecretKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret key: %w", err)
 	}
 
-	writableRelays := app.WritableRelays()
-	if len(writableRelays) > 0 {
-		resultChan := app.Pool().PublishMany(ctx, writableRelays, *event)
-		for result := range resultChan {
-			if result.Error != nil {
-				return fmt.Errorf("failed to publish to %s: %w", result.RelayURL, result.Error)
-			}
-		}
+	var reports []*nostr_sdk.PublishReport
+
+	report, err := publishFollowList(ctx, app, secretKey)
+	if report != nil {
+		reports = append(reports, report)
+	}
+	if err != nil {
+		return reports, fmt.Errorf("failed to publish follow list: %w", err)
 	}
 
-	return nil
+	report, err = publishCommunitiesList(ctx, app, secretKey)
+	if report != nil {
+		reports = append(reports, report)
+	}
+	if err != nil {
+		return reports, fmt.Errorf("failed to publish communities list: %w", err)
+	}
+
+	report, err = publishInterestsList(ctx, app, secretKey)
+	if report != nil {
+		reports = append(reports, report)
+	}
+	if err != nil {
+		return reports, fmt.Errorf("failed to publish interests list: %w", err)
+	}
+
+	return reports, nil
 }
 
-func publishInterestsList(ctx context.Context, app *config.AppContext, secretKey nostr.SecretKey) error {
-	subscriptions := app.ListSubscriptions("hashtag")
+func publishFollowList(ctx context.Context, app *config.AppContext, secretKey nostr.SecretKey) (*nostr_sdk.PublishReport, error) {
+	return publishSubscriptionList(ctx, app, secretKey, userSubscriptions)
+}
 
-	tags := nostr.Tags{}
-	for _, sub := range subscriptions {
-		tags = append(tags, nostr.Tag{"t", sub.ID})
-	}
+func publishCommunitiesList(ctx context.Context, app *config.AppContext, secretKey nostr.SecretKey) (*nostr_sdk.PublishReport, error) {
+	return publishSubscriptionList(ctx, app, secretKey, communitySubscriptions)
+}
 
+func publishInterestsList(ctx context.Context, app *config.AppContext, secretKey nostr.SecretKey) (*nostr_sdk.PublishReport, error) {
+	return publishSubscriptionList(ctx, app, secretKey, hashtagSubscriptions)
+}
+
+func publishSubscriptionList(ctx context.Context, app *config.AppContext, secretKey nostr.SecretKey, list subscriptionList) (*nostr_sdk.PublishReport, error) {
 	event := &nostr.Event{
-		Kind:      nostr.KindInterestList,
+		Kind:      list.kind,
 		CreatedAt: nostr.Timestamp(time.Now().Unix()),
-		Tags:      tags,
+		Tags:      list.toTags(app, app.ListSubscriptions(list.subType)),
 		Content:   "",
 		PubKey:    secretKey.Public(),
 	}
 
 	if err := event.Sign(secretKey); err != nil {
-		return fmt.Errorf("failed to sign event: %w", err)
+		return nil, fmt.Errorf("failed to sign event: %w", err)
 	}
 
-	writableRelays := app.WritableRelays()
-	if len(writableRelays) > 0 {
-		resultChan := app.Pool().PublishMany(ctx, writableRelays, *event)
-		for result := range resultChan {
-			if result.Error != nil {
-				return fmt.Errorf("failed to publish to %s: %w", result.RelayURL, result.Error)
-			}
-		}
-	}
-
-	return nil
+	return publishOwnList(ctx, app, event)
 }
@@ -12,11 +12,11 @@ func SyncAll(ctx context.Context, app *config.AppContext) error {
 		return fmt.Errorf("failed to sync profile: %w", err)
 	}
 
-	if err := SyncSubscriptionsFromNetwork(ctx, app); err != nil {
+	if _, err := SyncSubscriptionsFromNetwork(ctx, app, SyncOptions{}); err != nil {
 		return fmt.Errorf("failed to sync subscriptions: %w", err)
 	}
 
-	if err := SyncRelaysFromNetwork(ctx, app); err != nil {
+	if _, err := SyncRelaysFromNetwork(ctx, app, SyncOptions{}); err != nil {
 		return fmt.Errorf("failed to sync relays: %w", err)
 	}
 
@@ -28,11 +28,11 @@ func PublishAll(ctx context.Context, app *config.AppContext) error {
 		return fmt.Errorf("failed to publish profile: %w", err)
 	}
 
-	if err := PublishSubscriptions(ctx, app); err != nil {
+	if _, err := PublishSubscriptions(ctx, app); err != nil {
 		return fmt.Errorf("failed to publish subscriptions: %w", err)
 	}
 
-	if err := PublishRelayList(ctx, app); err != nil {
+	if _, err := PublishRelayList(ctx, app); err != nil {
 		return fmt.Errorf("failed to publish relay list: %w", err)
 	}
 
@@ -2,11 +2,15 @@ package utils
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"time"
 
 	"fiatjaf.com/nostr"
 	"fiatjaf.com/nostr/nip65"
 	"github.com/jerry-harm/nosmec/config"
 	"github.com/jerry-harm/nosmec/logger"
+	"github.com/jerry-harm/nosmec/nostr_sdk"
 )
 
 // VerifyRelayConnectivity checks if a relay can be connected to.
@@ -53,14 +57,15 @@ func DiscoverUserRelays(ctx context.Context, app *config.AppContext, pubKey nost
 
 	logger.Debug("DiscoverUserRelays: querying", "pubkey", pubKey.Hex(), "relays", relays)
 
-	results := app.Pool().FetchManyReplaceable(ctx, relays, filter, nostr.SubscriptionOptions{})
-
+	// gossip calls this for every followed user at once, so go through the per-relay limiter
 	var event *nostr.Event
-	results.Range(func(key nostr.ReplaceableKey, ev nostr.Event) bool {
-		logger.Debug("DiscoverUserRelays: got event", "pubkey", pubKey.Hex(), "eventID", ev.ID.Hex())
-		event = &ev
-		return false
-	})
+	for ie := range app.System().FetchManyLimited(ctx, relays, filter, nostr.SubscriptionOptions{Label: "gossip"}) {
+		logger.Debug("DiscoverUserRelays: got event", "pubkey", pubKey.Hex(), "eventID", ie.ID.Hex())
+		if event == nil || ie.CreatedAt > event.CreatedAt {
+			ev := ie.Event
+			event = &ev
+		}
+	}
 
 	if event == nil {
 		logger.Debug("DiscoverUserRelays: no event found", "pubkey", pubKey.Hex())
@@ -167,3 +172,32 @@ func GetQueryRelays(event *nostr.Event, app *config.AppContext) []string {
 
 	return result
 }
+
+// FormatRelayLimitStats summarizes how much the per-relay limiter held requests back.
+// It returns an empty string when nothing was delayed.
+func FormatRelayLimitStats(stats []nostr_sdk.RelayLimitStats) string {
+	var b strings.Builder
+	delayed, limited := 0, 0
+	var waited time.Duration
+	for _, s := range stats {
+		delayed += s.Delayed
+		limited += s.RateLimited
+		waited += s.Waited
+	}
+	if delayed == 0 && limited == 0 {
+		return ""
+	}
+
+	fmt.Fprintf(&b, "Throttled %d requests (waited %s in total)", delayed, waited.Round(time.Millisecond))
+	if limited > 0 {
+		fmt.Fprintf(&b, ", %d rate-limit messages", limited)
+	}
+	b.WriteString("\n")
+	for _, s := range stats {
+		if s.Delayed == 0 && s.RateLimited == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "  %s: %d/%d delayed, %d rate-limited\n", s.Relay, s.Delayed, s.Requests, s.RateLimited)
+	}
+	return b.String()
+}